@@ -0,0 +1,109 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const adminPathPrefix = "/__admin/"
+
+// WithAdminAPI enables the runtime administration API. When enabled, requests
+// with paths beginning with /__admin/ are not matched against the expectations
+// and instead provide the following operations:
+//
+//	GET    /__admin/expectations      - list the expectations created via the admin API
+//	POST   /__admin/expectations      - create an expectation from a FixtureExpectation
+//	DELETE /__admin/expectations/<id> - remove an expectation created via the admin API
+//	POST   /__admin/reset             - remove all expectations and clear the request journal
+//	GET    /__admin/requests          - fetch the request journal
+//
+// This allows for black-box end to end testing where the process setting up
+// expectations is not the one making the API calls.
+func WithAdminAPI() Option {
+	return func(m *MockAPI) error {
+		m.admin = true
+		return nil
+	}
+}
+
+// adminExpectation is an expectation that was created via the admin API.
+type adminExpectation struct {
+	ID          string
+	Expectation FixtureExpectation
+
+	call *MockAPICall
+}
+
+func (m *MockAPI) serveAdmin(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, adminPathPrefix)
+
+	switch {
+	case path == "expectations" && r.Method == http.MethodGet:
+		m.mu.Lock()
+		expectations := make([]*adminExpectation, 0, len(m.adminCalls))
+		for _, exp := range m.adminCalls {
+			expectations = append(expectations, exp)
+		}
+		m.mu.Unlock()
+		writeAdminJSON(w, http.StatusOK, expectations)
+	case path == "expectations" && r.Method == http.MethodPost:
+		var exp FixtureExpectation
+		if err := json.NewDecoder(r.Body).Decode(&exp); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		calls, err := m.withFixtureExpectations([]FixtureExpectation{exp})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		m.mu.Lock()
+		m.adminID++
+		created := &adminExpectation{
+			ID:          strconv.Itoa(m.adminID),
+			Expectation: exp,
+			call:        calls[0],
+		}
+		m.adminCalls = append(m.adminCalls, created)
+		m.mu.Unlock()
+
+		writeAdminJSON(w, http.StatusCreated, created)
+	case strings.HasPrefix(path, "expectations/") && r.Method == http.MethodDelete:
+		id := strings.TrimPrefix(path, "expectations/")
+		if !m.deleteAdminExpectation(id) {
+			http.Error(w, "expectation not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case path == "reset" && r.Method == http.MethodPost:
+		m.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	case path == "requests" && r.Method == http.MethodGet:
+		writeAdminJSON(w, http.StatusOK, m.Journal())
+	default:
+		http.Error(w, "unknown admin API operation", http.StatusNotFound)
+	}
+}
+
+func (m *MockAPI) deleteAdminExpectation(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, exp := range m.adminCalls {
+		if exp.ID == id {
+			exp.call.disable()
+			m.adminCalls = append(m.adminCalls[:i], m.adminCalls[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
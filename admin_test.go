@@ -0,0 +1,112 @@
+package mockapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestAdminAPI(t *testing.T) {
+	m, err := New(WithAdminAPI())
+	if err != nil {
+		t.Fatalf("Error creating the mock API: %v", err)
+	}
+	defer m.Close()
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	resp, err := http.Post(fmt.Sprintf("%s/__admin/expectations", m.URL()), "application/json", bytes.NewBufferString(`{
+		"Request": {"Method": "GET", "Path": "/my/endpoint"},
+		"Response": {"Status": 200, "Text": "hello"}
+	}`))
+	if err != nil {
+		t.Fatalf("Error creating expectation: %v", err)
+	}
+	var created struct{ ID string }
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Unexpected response creating expectation: %d %v", resp.StatusCode, err)
+	}
+
+	get := func() (int, string) {
+		resp, err := http.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+		if err != nil {
+			t.Fatalf("Error issuing GET of /my/endpoint: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if status, body := get(); status != 200 || body != "hello" {
+		t.Fatalf("Didn't get the expected response: %d %q", status, body)
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/__admin/requests", m.URL()))
+	if err != nil {
+		t.Fatalf("Error fetching the request journal: %v", err)
+	}
+	var journal []JournalEntry
+	err = json.NewDecoder(resp.Body).Decode(&journal)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Error decoding the request journal: %v", err)
+	}
+	if len(journal) != 1 || journal[0].Path != "/my/endpoint" {
+		t.Fatalf("Unexpected request journal: %+v", journal)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/__admin/expectations/%s", m.URL(), created.ID), nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error deleting expectation: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected a 204 status code but got %d", resp.StatusCode)
+	}
+
+	if status, _ := get(); status != http.StatusNotFound {
+		t.Fatalf("Expected the deleted expectation to no longer match but got %d", status)
+	}
+}
+
+func TestRequestJournal(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"disabled":  nil,
+		"enabled":   {WithRequestJournal()},
+		"admin-api": {WithAdminAPI()},
+	} {
+		opts := opts
+		t.Run(name, func(t *testing.T) {
+			m := NewMockAPI(t, opts...)
+			m.SetFilteredHeaders([]string{
+				"Accept-Encoding",
+				"User-Agent",
+			})
+
+			m.WithNoResponseBody(NewMockRequest("GET", "/nodes"), 200).Once()
+
+			resp, err := http.Get(fmt.Sprintf("%s/nodes", m.URL()))
+			if err != nil {
+				t.Fatalf("Error issuing GET of /nodes: %v", err)
+			}
+			resp.Body.Close()
+
+			// requests are only held in memory when the journal is needed
+			expected := 1
+			if opts == nil {
+				expected = 0
+			}
+			if journal := m.Journal(); len(journal) != expected {
+				t.Fatalf("Expected %d journal entries but got %d", expected, len(journal))
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package mockapi
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// authorization splits the single Authorization header value into its scheme and
+// credentials.
+func authorization(values []string) (scheme, credentials string, ok bool) {
+	if len(values) != 1 {
+		return "", "", false
+	}
+	scheme, credentials, ok = strings.Cut(values[0], " ")
+	return scheme, strings.TrimSpace(credentials), ok
+}
+
+// WithBasicAuth will expect the request to have an Authorization header with HTTP Basic
+// credentials for the given user and password. The header is parsed so the casing of the
+// scheme does not matter. The Authorization header is then not compared with WithHeaders.
+func (r *MockRequest) WithBasicAuth(user, pass string) *MockRequest {
+	return r.withHeaderMatcher("Authorization", func(values []string) bool {
+		scheme, credentials, ok := authorization(values)
+		if !ok || !strings.EqualFold(scheme, "Basic") {
+			return false
+		}
+		decoded, err := base64.StdEncoding.DecodeString(credentials)
+		if err != nil {
+			return false
+		}
+		gotUser, gotPass, ok := strings.Cut(string(decoded), ":")
+		return ok && gotUser == user && gotPass == pass
+	})
+}
+
+// WithBearerToken will expect the request to have an Authorization header with the given
+// bearer token. The Authorization header is then not compared with WithHeaders.
+func (r *MockRequest) WithBearerToken(token string) *MockRequest {
+	return r.withHeaderMatcher("Authorization", func(values []string) bool {
+		scheme, credentials, ok := authorization(values)
+		return ok && strings.EqualFold(scheme, "Bearer") && credentials == token
+	})
+}
+
+// WithAnyBearerToken will expect the request to have an Authorization header with a
+// non-empty bearer token without caring about its value. The Authorization header is then
+// not compared with WithHeaders.
+func (r *MockRequest) WithAnyBearerToken() *MockRequest {
+	return r.withHeaderMatcher("Authorization", func(values []string) bool {
+		scheme, credentials, ok := authorization(values)
+		return ok && strings.EqualFold(scheme, "Bearer") && credentials != ""
+	})
+}
@@ -0,0 +1,61 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAuthorization(t *testing.T) {
+	rt := &recordingT{}
+	m := NewMockAPI(rt)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/basic").WithBasicAuth("admin", "secret"), 200).Maybe()
+	m.WithNoResponseBody(NewMockRequest("GET", "/bearer").WithBearerToken("abc123"), 200).Maybe()
+	m.WithNoResponseBody(NewMockRequest("GET", "/any").
+		WithHeaders(map[string]string{"X-Tenant": "acme"}).
+		WithAnyBearerToken(), 200).Maybe()
+
+	for _, tc := range []struct {
+		path     string
+		auth     string
+		headers  map[string]string
+		expected int
+	}{
+		{path: "/basic", auth: "Basic YWRtaW46c2VjcmV0", expected: 200},
+		{path: "/basic", auth: "basic YWRtaW46c2VjcmV0", expected: 200},
+		{path: "/basic", auth: "Basic YWRtaW46b3RoZXI=", expected: 404},
+		{path: "/basic", expected: 404},
+		{path: "/bearer", auth: "Bearer abc123", expected: 200},
+		{path: "/bearer", auth: "Bearer xyz", expected: 404},
+		{path: "/any", auth: "Bearer xyz", headers: map[string]string{"X-Tenant": "acme"}, expected: 200},
+		{path: "/any", auth: "Bearer xyz", expected: 404},
+		{path: "/any", auth: "Basic YWRtaW46c2VjcmV0", headers: map[string]string{"X-Tenant": "acme"}, expected: 404},
+	} {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s%s", m.URL(), tc.path), nil)
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		if tc.auth != "" {
+			req.Header.Set("Authorization", tc.auth)
+		}
+		for hdr, value := range tc.headers {
+			req.Header.Set(hdr, value)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", tc.path, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.expected {
+			t.Fatalf("Expected a %d status code for %s with %q but got %d", tc.expected, tc.path, tc.auth, resp.StatusCode)
+		}
+	}
+	m.Close()
+}
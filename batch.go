@@ -0,0 +1,149 @@
+package mockapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+)
+
+// BatchOperation is a single sub-operation of a batch request.
+type BatchOperation struct {
+	// ID identifies the operation within the batch so that its result
+	// can be correlated with it.
+	ID      string
+	Request *http.Request
+}
+
+// BatchResult is the outcome of dispatching a single BatchOperation.
+type BatchResult struct {
+	ID     string
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// BatchCodec converts between a batch request/response and its individual
+// sub-operations and their results.
+type BatchCodec interface {
+	// DecodeBatch splits the batch request into its sub-operations.
+	DecodeBatch(r *http.Request) ([]BatchOperation, error)
+	// EncodeBatch writes the combined response for all the sub-operation results.
+	EncodeBatch(w http.ResponseWriter, status int, results []BatchResult) error
+}
+
+// WithBatchReply will setup an expectation for a batch API call to be made. The codec splits
+// the request body into sub-operations, each of which is then matched against the other
+// expectations registered with the MockAPI exactly as if it had been sent on its own. The
+// results of all the sub-operations are then assembled by the codec into the reply using
+// the supplied status code. A request which the codec fails to decode is replied to with
+// a 400 status code.
+func (m *MockAPI) WithBatchReply(req *MockRequest, status int, codec BatchCodec) *MockAPICall {
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		ops, err := codec.DecodeBatch(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		results := make([]BatchResult, 0, len(ops))
+		for _, op := range ops {
+			rec := httptest.NewRecorder()
+			m.handle(rec, m.withRequestID(rec, op.Request.WithContext(r.Context())))
+			results = append(results, BatchResult{
+				ID:     op.ID,
+				Status: rec.Code,
+				Header: rec.Header(),
+				Body:   rec.Body.Bytes(),
+			})
+		}
+
+		checkError(m.t, codec.EncodeBatch(w, status, results))
+	})
+}
+
+// JSONBatchCodec is a BatchCodec for JSON batch requests of the form:
+//
+//	{"requests": [{"id": "1", "method": "GET", "url": "/users/1", "headers": {...}, "body": ...}]}
+//
+// The combined response has the form:
+//
+//	{"responses": [{"id": "1", "status": 200, "headers": {...}, "body": ...}]}
+//
+// Sub-operation bodies are sent as their JSON encoding. Response bodies which are valid
+// JSON are embedded as is and any other body is embedded as a string.
+type JSONBatchCodec struct{}
+
+type jsonBatchRequest struct {
+	Requests []struct {
+		ID      string            `json:"id"`
+		Method  string            `json:"method"`
+		URL     string            `json:"url"`
+		Headers map[string]string `json:"headers,omitempty"`
+		Body    json.RawMessage   `json:"body,omitempty"`
+	} `json:"requests"`
+}
+
+type jsonBatchResult struct {
+	ID      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// DecodeBatch implements BatchCodec.
+func (JSONBatchCodec) DecodeBatch(r *http.Request) ([]BatchOperation, error) {
+	var batch jsonBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		return nil, fmt.Errorf("failed to decode batch request: %w", err)
+	}
+
+	ops := make([]BatchOperation, 0, len(batch.Requests))
+	for _, sub := range batch.Requests {
+		var body io.Reader
+		if len(sub.Body) > 0 {
+			body = bytes.NewReader(sub.Body)
+		}
+
+		subReq, err := http.NewRequest(sub.Method, sub.URL, body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid batch operation %q: %w", sub.ID, err)
+		}
+		for hdr, value := range sub.Headers {
+			subReq.Header.Set(hdr, value)
+		}
+
+		ops = append(ops, BatchOperation{ID: sub.ID, Request: subReq})
+	}
+	return ops, nil
+}
+
+// EncodeBatch implements BatchCodec.
+func (JSONBatchCodec) EncodeBatch(w http.ResponseWriter, status int, results []BatchResult) error {
+	encoded := make([]jsonBatchResult, 0, len(results))
+	for _, result := range results {
+		res := jsonBatchResult{ID: result.ID, Status: result.Status}
+
+		for hdr := range result.Header {
+			if res.Headers == nil {
+				res.Headers = make(map[string]string)
+			}
+			res.Headers[hdr] = result.Header.Get(hdr)
+		}
+
+		if len(result.Body) > 0 {
+			if json.Valid(result.Body) {
+				res.Body = result.Body
+			} else {
+				str, _ := json.Marshal(string(result.Body))
+				res.Body = str
+			}
+		}
+
+		encoded = append(encoded, res)
+	}
+
+	return writeJSON(w, status, "application/json", map[string]interface{}{"responses": encoded})
+}
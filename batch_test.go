@@ -0,0 +1,57 @@
+package mockapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWithBatchReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Content-Length",
+		"Content-Type",
+		"User-Agent",
+	})
+
+	m.WithBatchReply(NewMockRequest("POST", "/batch").WithBody(mock.Anything), 200, JSONBatchCodec{}).Once()
+	m.WithJSONReply(NewMockRequest("GET", "/users/1"), 200, map[string]interface{}{"name": "alice"}).Once()
+	m.WithNoResponseBody(NewMockRequest("DELETE", "/users/2").WithHeaders(map[string]string{"If-Match": "v1"}), 204).Once()
+
+	batch := []byte(`{"requests": [
+		{"id": "a", "method": "GET", "url": "/users/1"},
+		{"id": "b", "method": "DELETE", "url": "/users/2", "headers": {"If-Match": "v1"}}
+	]}`)
+
+	resp, err := http.Post(fmt.Sprintf("%s/batch", m.URL()), "application/json", bytes.NewReader(batch))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /batch: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Responses []struct {
+			ID     string
+			Status int
+			Body   json.RawMessage
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+
+	if len(out.Responses) != 2 {
+		t.Fatalf("Expected 2 responses but got %d", len(out.Responses))
+	}
+	if r := out.Responses[0]; r.ID != "a" || r.Status != 200 || string(bytes.TrimSpace(r.Body)) != `{"name":"alice"}` {
+		t.Fatalf("Unexpected first response: %+v", r)
+	}
+	if r := out.Responses[1]; r.ID != "b" || r.Status != 204 || len(r.Body) != 0 {
+		t.Fatalf("Unexpected second response: %+v", r)
+	}
+}
@@ -0,0 +1,125 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// CapturedRequest is a request which matched an expectation. It provides typed
+// access to the parts of the request which were used for matching.
+type CapturedRequest struct {
+	id      string
+	time    time.Time
+	method  string
+	path    string
+	header  http.Header
+	query   url.Values
+	body    []byte
+	request *http.Request
+}
+
+func newCapturedRequest(r *http.Request, body []byte, at time.Time) *CapturedRequest {
+	return &CapturedRequest{
+		id:      RequestID(r),
+		time:    at,
+		method:  r.Method,
+		path:    r.URL.Path,
+		header:  r.Header.Clone(),
+		query:   r.URL.Query(),
+		body:    body,
+		request: r,
+	}
+}
+
+// ID returns the correlation ID of the request. See RequestID.
+func (c *CapturedRequest) ID() string {
+	return c.id
+}
+
+// Time returns when the request was matched.
+func (c *CapturedRequest) Time() time.Time {
+	return c.time
+}
+
+// Method returns the HTTP method of the request.
+func (c *CapturedRequest) Method() string {
+	return c.method
+}
+
+// Path returns the path of the request.
+func (c *CapturedRequest) Path() string {
+	return c.path
+}
+
+// PathParams returns the values of the path parameters when the request matched
+// an expectation with a path template.
+func (c *CapturedRequest) PathParams() map[string]string {
+	return PathParams(c.request)
+}
+
+// Header returns all of the request's headers, including any which are
+// filtered out from matching.
+func (c *CapturedRequest) Header() http.Header {
+	return c.header
+}
+
+// Query returns all of the request's query params, including any which are
+// filtered out from matching.
+func (c *CapturedRequest) Query() url.Values {
+	return c.query
+}
+
+// Body returns the raw request body.
+func (c *CapturedRequest) Body() []byte {
+	return c.body
+}
+
+// JSONBody decodes the JSON request body into the value pointed to by into.
+func (c *CapturedRequest) JSONBody(into interface{}) error {
+	return json.Unmarshal(c.body, into)
+}
+
+// MultipartBody parses the multipart/form-data request body.
+func (c *CapturedRequest) MultipartBody() (*MultipartBody, error) {
+	return parseMultipartBody(c.header.Get("Content-Type"), c.body)
+}
+
+// Request returns the underlying *http.Request. Its body has already been read
+// and should be accessed with Body instead.
+func (c *CapturedRequest) Request() *http.Request {
+	return c.request
+}
+
+// capture records a request which matched this call and runs the Run hooks.
+func (m *MockAPICall) capture(req *CapturedRequest) {
+	m.mu.Lock()
+	m.captured = append(m.captured, req)
+	hooks := m.hooks
+	m.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(req)
+	}
+}
+
+// Run sets a hook to be called with each request matching this call before
+// the response is sent. It may be called multiple times to add several hooks.
+func (m *MockAPICall) Run(hook func(*CapturedRequest)) *MockAPICall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+	return m
+}
+
+// Requests returns all the requests which have matched this call in the order
+// they were received.
+func (m *MockAPICall) Requests() []*CapturedRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requests := make([]*CapturedRequest, len(m.captured))
+	copy(requests, m.captured)
+	return requests
+}
@@ -0,0 +1,59 @@
+package mockapi
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCapturedRequest(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Content-Length",
+		"Content-Type",
+		"User-Agent",
+	})
+	m.SetFilteredQueryParams([]string{"trace"})
+
+	var hooked []string
+	call := m.WithNoResponseBody(NewMockRequest("POST", "/users").WithBody(mock.Anything), 201).
+		Twice().
+		Run(func(req *CapturedRequest) {
+			hooked = append(hooked, req.Query().Get("trace"))
+		})
+
+	for _, name := range []string{"alice", "bob"} {
+		body := []byte(fmt.Sprintf(`{"name": %q}`, name))
+		resp, err := http.Post(fmt.Sprintf("%s/users?trace=%s", m.URL(), name), "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Error issuing POST of /users: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	requests := call.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 captured requests but got %d", len(requests))
+	}
+
+	req := requests[1]
+	if req.Method() != "POST" || req.Path() != "/users" || req.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Unexpected captured request: %s %s %v", req.Method(), req.Path(), req.Header())
+	}
+
+	var user struct{ Name string }
+	if err := req.JSONBody(&user); err != nil {
+		t.Fatalf("Error decoding captured body: %v", err)
+	}
+	if user.Name != "bob" {
+		t.Fatalf("Expected the captured body to be for bob but got %q", user.Name)
+	}
+
+	if len(hooked) != 2 || hooked[0] != "alice" || hooked[1] != "bob" {
+		t.Fatalf("Unexpected values seen by the Run hook: %v", hooked)
+	}
+}
@@ -0,0 +1,170 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Cassette is a recording of real HTTP interactions. The format is compatible with
+// the cassettes recorded by go-vcr and may be stored as either YAML or JSON. An example
+// cassette looks like:
+//
+//	version: 1
+//	interactions:
+//	- request:
+//	    method: GET
+//	    url: https://api.example.com/v1/nodes?region=east
+//	    headers:
+//	      Accept: [application/json]
+//	  response:
+//	    code: 200
+//	    headers:
+//	      Content-Type: [application/json]
+//	    body: '[{"id": "node-1"}]'
+type Cassette struct {
+	Version      int                   `yaml:"version" json:"version"`
+	Interactions []CassetteInteraction `yaml:"interactions" json:"interactions"`
+}
+
+// CassetteInteraction is a single recorded request and the response it received.
+type CassetteInteraction struct {
+	Request  CassetteRequest  `yaml:"request" json:"request"`
+	Response CassetteResponse `yaml:"response" json:"response"`
+}
+
+// CassetteRequest is a recorded request.
+type CassetteRequest struct {
+	Method  string              `yaml:"method" json:"method"`
+	URL     string              `yaml:"url" json:"url"`
+	Headers map[string][]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Body    string              `yaml:"body,omitempty" json:"body,omitempty"`
+}
+
+// CassetteResponse is a recorded response.
+type CassetteResponse struct {
+	Code    int                 `yaml:"code" json:"code"`
+	Headers map[string][]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Body    string              `yaml:"body,omitempty" json:"body,omitempty"`
+}
+
+// ParseCassette decodes a YAML or JSON encoded Cassette.
+func ParseCassette(data []byte) (*Cassette, error) {
+	// JSON is a subset of YAML so the one decoder handles both
+	var cassette Cassette
+	if err := yaml.Unmarshal(data, &cassette); err != nil {
+		return nil, err
+	}
+	return &cassette, nil
+}
+
+// LoadCassette reads and decodes the Cassette stored in the file at path.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cassette, err := ParseCassette(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cassette file %q: %w", path, err)
+	}
+	return cassette, nil
+}
+
+// Fixture converts the recorded interactions into a Fixture where each interaction
+// is expected to occur exactly once. Only the first value of multi-value headers and
+// query params is used. The Date and Content-Length response headers are dropped as
+// the mock HTTP server will generate its own.
+func (c *Cassette) Fixture() (*Fixture, error) {
+	fixture := &Fixture{}
+	for i, interaction := range c.Interactions {
+		u, err := url.Parse(interaction.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("interaction %d has an invalid URL: %w", i, err)
+		}
+
+		req := FixtureRequest{
+			Method:      interaction.Request.Method,
+			Path:        u.Path,
+			Headers:     firstValues(interaction.Request.Headers, nil),
+			QueryParams: firstValues(u.Query(), nil),
+		}
+		req.Body = recordedRequestBody(http.Header(interaction.Request.Headers).Get("Content-Type"), interaction.Request.Body)
+
+		resp := FixtureResponse{
+			Status:  interaction.Response.Code,
+			Headers: firstValues(interaction.Response.Headers, []string{"Date", "Content-Length"}),
+		}
+		resp.setRecordedBody(interaction.Response.Body)
+
+		fixture.Expectations = append(fixture.Expectations, FixtureExpectation{
+			Request:  req,
+			Response: resp,
+			Times:    1,
+		})
+	}
+	return fixture, nil
+}
+
+// firstValues flattens multi-value maps by taking the first of the values
+// while skipping the excluded keys. Empty results are returned as nil.
+func firstValues(values map[string][]string, exclude []string) map[string]string {
+	var flat map[string]string
+	for key, vals := range values {
+		if len(vals) == 0 || contains(exclude, key) {
+			continue
+		}
+		if flat == nil {
+			flat = make(map[string]string)
+		}
+		flat[key] = vals[0]
+	}
+	return flat
+}
+
+// recordedRequestBody converts a recorded request body into the body of a
+// FixtureRequest.
+func recordedRequestBody(contentType, body string) interface{} {
+	if body == "" {
+		return nil
+	}
+
+	if isFormContentType(contentType) {
+		if values, err := url.ParseQuery(body); err == nil {
+			return values
+		}
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &obj); err == nil {
+		return obj
+	}
+	return body
+}
+
+// setRecordedBody sets the body of the FixtureResponse to the recorded body.
+func (f *FixtureResponse) setRecordedBody(body string) {
+	if body == "" {
+		return
+	}
+
+	if json.Valid([]byte(body)) {
+		f.JSON = json.RawMessage(body)
+	} else {
+		f.Text = body
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
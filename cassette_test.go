@@ -0,0 +1,54 @@
+package mockapi
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestCassetteFixture(t *testing.T) {
+	cassette, err := LoadCassette("testdata/cassette.yaml")
+	if err != nil {
+		t.Fatalf("Error loading cassette: %v", err)
+	}
+
+	fixture, err := cassette.Fixture()
+	if err != nil {
+		t.Fatalf("Error converting cassette: %v", err)
+	}
+
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Content-Length",
+		"User-Agent",
+	})
+
+	if err := m.WithFixture(fixture); err != nil {
+		t.Fatalf("Error loading fixture: %v", err)
+	}
+
+	resp, err := http.Post(fmt.Sprintf("%s/v1/nodes?region=east", m.URL()), "application/json", bytes.NewReader([]byte(`{"name":"node-1"}`)))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /v1/nodes: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != 201 || string(body) != `{"id": "node-1"}` || resp.Header.Get("Date") == "Mon, 01 Jan 2024 00:00:00 GMT" {
+		t.Fatalf("Unexpected response: %d %s %v", resp.StatusCode, body, resp.Header)
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/v1/health", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /v1/health: %v", err)
+	}
+	body, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 || string(body) != "ok" {
+		t.Fatalf("Unexpected response: %d %s", resp.StatusCode, body)
+	}
+}
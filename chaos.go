@@ -0,0 +1,68 @@
+package mockapi
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// WithRandomSeed sets the seed used for all randomized behavior of the MockAPI (such
+// as which responses are replaced when using Chaos) so that it is reproducible.
+func WithRandomSeed(seed int64) Option {
+	return func(m *MockAPI) error {
+		m.rand = rand.New(rand.NewSource(seed))
+		return nil
+	}
+}
+
+// chaos is the configuration for randomly injecting faults.
+type chaos struct {
+	rate   float64
+	faults []Fault
+}
+
+// Chaos configures the MockAPI to replace a random portion of the responses to requests
+// which matched an expectation with one of the given faults. The rate is the fraction of
+// responses which should be replaced and must be within [0, 1]. The fault to use is also
+// chosen randomly. Use WithRandomSeed to make the choices reproducible. Calling Chaos with
+// a rate of 0 or no faults disables chaos mode. A rate outside of [0, 1] will fail the test
+// object passed into the NewMockAPI constructor if that was non-nil and if it was nil, will
+// panic. The chaos configuration is left unchanged.
+func (m *MockAPI) Chaos(rate float64, faults ...Fault) {
+	if math.IsNaN(rate) || rate < 0 || rate > 1 {
+		checkError(m.t, fmt.Errorf("chaos rate %v is not within [0, 1]", rate))
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rate <= 0 || len(faults) == 0 {
+		m.chaos = nil
+		return
+	}
+
+	m.chaos = &chaos{rate: rate, faults: faults}
+}
+
+// applyChaos returns the responder that should be used in place of resp.
+func (m *MockAPI) applyChaos(resp MockResponse) MockResponse {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.chaos == nil || m.random().Float64() >= m.chaos.rate {
+		return resp
+	}
+
+	fault := m.chaos.faults[m.random().Intn(len(m.chaos.faults))]
+	return fault(resp)
+}
+
+// random returns the source of randomness for the MockAPI. The mu lock must be held.
+func (m *MockAPI) random() *rand.Rand {
+	if m.rand == nil {
+		m.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return m.rand
+}
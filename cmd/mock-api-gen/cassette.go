@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	mockapi "github.com/mkeeler/mock-http-api"
+)
+
+const tplCassetteFile = `
+{{- template "build-tags" .BuildTags -}}
+{{ template "header" .CLIArgs }}
+
+{{ template "package" .Package }}
+
+import (
+	{{ range .Imports -}}
+	{{ . }}
+	{{ end }}
+)
+
+// {{ .Func }} sets up the expectations for the interactions recorded in {{ .Source }}.
+func {{ .Func }}(m *mockapi.MockAPI) {
+{{- range .Expectations }}
+	{{ . }}
+{{- end }}
+}
+`
+
+type cassetteTplArgs struct {
+	CLIArgs      string
+	Package      string
+	BuildTags    []string
+	Imports      []string
+	Func         string
+	Source       string
+	Expectations []string
+}
+
+// generateFromCassette renders Go code setting up the expectations for all
+// the interactions recorded in the cassette file.
+func generateFromCassette(cfg config) ([]byte, error) {
+	cassette, err := mockapi.LoadCassette(cfg.cassette)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture, err := cassette.Fixture()
+	if err != nil {
+		return nil, err
+	}
+	return generateFromFixture(cfg, cfg.cassette, fixture)
+}
+
+// generateFromHAR renders Go code setting up the expectations for all the
+// entries of the HAR file, optionally only those for a single host.
+func generateFromHAR(cfg config) ([]byte, error) {
+	har, err := mockapi.LoadHARFile(cfg.har)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.host != "" {
+		har = har.ForHost(cfg.host)
+	}
+
+	fixture, err := har.Fixture()
+	if err != nil {
+		return nil, err
+	}
+	return generateFromFixture(cfg, cfg.har, fixture)
+}
+
+// generateFromPostman renders Go code setting up the expectations for all the
+// example responses of the Postman collection.
+func generateFromPostman(cfg config) ([]byte, error) {
+	collection, err := mockapi.LoadPostmanCollection(cfg.postman)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := make(map[string]string)
+	for _, v := range cfg.variables {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid variable %q, expected the form key=value", v)
+		}
+		variables[key] = value
+	}
+
+	fixture, err := collection.Fixture(variables)
+	if err != nil {
+		return nil, err
+	}
+	return generateFromFixture(cfg, cfg.postman, fixture)
+}
+
+// generateFromFixture renders Go code setting up the expectations of the
+// fixture converted from the recording in the source file.
+func generateFromFixture(cfg config, source string, fixture *mockapi.Fixture) ([]byte, error) {
+	args := cassetteTplArgs{
+		CLIArgs:   strings.Join(os.Args[1:], " "),
+		Package:   cfg.pkgName,
+		BuildTags: cfg.tags,
+		Func:      cfg.funcName,
+		Source:    source,
+	}
+
+	imports := map[string]struct{}{`mockapi "github.com/mkeeler/mock-http-api"`: {}}
+	for _, exp := range fixture.Expectations {
+		stmt, stmtImports := expectationSource(&exp, cfg.skipHeaders)
+		args.Expectations = append(args.Expectations, stmt)
+		for _, imp := range stmtImports {
+			imports[imp] = struct{}{}
+		}
+	}
+
+	for imp := range imports {
+		args.Imports = append(args.Imports, imp)
+	}
+	sort.Strings(args.Imports)
+
+	tpl := template.New("mock-api-cassette")
+	template.Must(tpl.Parse(tplCassetteFile))
+	template.Must(tpl.Parse(tplPackage))
+	template.Must(tpl.Parse(tplHeader))
+	template.Must(tpl.Parse(tplBuildTags))
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, args); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// expectationSource renders the Go statement setting up the expectation along
+// with the imports it requires.
+func expectationSource(exp *mockapi.FixtureExpectation, skipHeaders []string) (string, []string) {
+	var req strings.Builder
+	fmt.Fprintf(&req, "mockapi.NewMockRequest(%q, %q)", exp.Request.Method, exp.Request.Path)
+
+	headers := make(map[string]string)
+	for hdr, value := range exp.Request.Headers {
+		if !containsString(skipHeaders, hdr) {
+			headers[hdr] = value
+		}
+	}
+	if len(headers) > 0 {
+		fmt.Fprintf(&req, ".\n\t\tWithHeaders(%s)", stringMapLiteral(headers))
+	}
+	if len(exp.Request.QueryParams) > 0 {
+		fmt.Fprintf(&req, ".\n\t\tWithQueryParams(%s)", stringMapLiteral(exp.Request.QueryParams))
+	}
+
+	var imports []string
+	switch body := exp.Request.Body.(type) {
+	case map[string]interface{}:
+		fmt.Fprintf(&req, ".\n\t\tWithBody(%s)", goLiteral(body))
+	case url.Values:
+		fmt.Fprintf(&req, ".\n\t\tWithFormBody(%s)", valuesLiteral(body))
+		imports = append(imports, `"net/url"`)
+	case string:
+		fmt.Fprintf(&req, ".\n\t\tWithBody([]byte(%s))", stringLiteral(body))
+	}
+
+	status := exp.Response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if len(exp.Response.Headers) == 0 {
+		switch {
+		case len(exp.Response.JSON) > 0:
+			return fmt.Sprintf("m.WithJSONReply(%s, %d, json.RawMessage(%s))%s", req.String(), status, stringLiteral(string(exp.Response.JSON)), repeatability(exp)),
+				append(imports, `"encoding/json"`)
+		case exp.Response.Text != "":
+			return fmt.Sprintf("m.WithTextReply(%s, %d, %s)%s", req.String(), status, stringLiteral(exp.Response.Text), repeatability(exp)), imports
+		default:
+			return fmt.Sprintf("m.WithNoResponseBody(%s, %d)%s", req.String(), status, repeatability(exp)), imports
+		}
+	}
+
+	var resp strings.Builder
+	resp.WriteString("func(w http.ResponseWriter, r *http.Request) {\n")
+	for _, hdr := range sortedKeys(exp.Response.Headers) {
+		fmt.Fprintf(&resp, "w.Header().Set(%q, %q)\n", hdr, exp.Response.Headers[hdr])
+	}
+	fmt.Fprintf(&resp, "w.WriteHeader(%d)\n", status)
+	if len(exp.Response.JSON) > 0 {
+		fmt.Fprintf(&resp, "w.Write([]byte(%s))\n", stringLiteral(string(exp.Response.JSON)))
+	} else if exp.Response.Text != "" {
+		fmt.Fprintf(&resp, "w.Write([]byte(%s))\n", stringLiteral(exp.Response.Text))
+	}
+	resp.WriteString("}")
+
+	return fmt.Sprintf("m.WithRequest(%s, %s)%s", req.String(), resp.String(), repeatability(exp)), append(imports, `"net/http"`)
+}
+
+// repeatability returns the Go source for the calls restricting how often the
+// expectation may be matched.
+func repeatability(exp *mockapi.FixtureExpectation) string {
+	switch {
+	case exp.Optional:
+		return ".Maybe()"
+	case exp.Times == 1:
+		return ".Once()"
+	case exp.Times > 1:
+		return fmt.Sprintf(".Times(%d)", exp.Times)
+	default:
+		return ""
+	}
+}
+
+// stringLiteral returns a Go string literal for s, preferring raw strings.
+func stringLiteral(s string) string {
+	if !strings.Contains(s, "`") && strconv.CanBackquote(strings.ReplaceAll(s, "\n", "")) {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+func stringMapLiteral(m map[string]string) string {
+	var b strings.Builder
+	b.WriteString("map[string]string{")
+	for _, key := range sortedKeys(m) {
+		fmt.Fprintf(&b, "%q: %q, ", key, m[key])
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// valuesLiteral returns the Go source for url.Values.
+func valuesLiteral(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("url.Values{")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%q: {", key)
+		for i, value := range values[key] {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%q", value)
+		}
+		b.WriteString("}, ")
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// goLiteral returns the Go source for a value decoded from JSON.
+func goLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return "float64(" + strconv.FormatFloat(v, 'g', -1, 64) + ")"
+	case string:
+		return strconv.Quote(v)
+	case []interface{}:
+		var b strings.Builder
+		b.WriteString("[]interface{}{")
+		for _, elem := range v {
+			b.WriteString(goLiteral(elem))
+			b.WriteString(", ")
+		}
+		b.WriteString("}")
+		return b.String()
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var b strings.Builder
+		b.WriteString("map[string]interface{}{")
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%q: %s, ", key, goLiteral(v[key]))
+		}
+		b.WriteString("}")
+		return b.String()
+	default:
+		return fmt.Sprintf("%#v", v)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -42,7 +43,6 @@ package {{ . }}
 	tplImports = `
 {{- define "imports" -}}
 import (
-	 "fmt"
 	 mockapi "github.com/mkeeler/mock-http-api"
 
 	 {{ range . -}}
@@ -54,27 +54,28 @@ import (
 
 	tplPathParameters = `
 {{- define "path-parameters" -}}
-{{- range . -}}{{ . }} string,{{- end -}}
+{{- range $index, $param := .PathParameters -}}{{ $param }} {{ pathParameterType $ $index }},{{- end -}}
 {{- end -}}
 `
 
 	tplReply = `
 {{- define "reply" -}}
-{{- if eq .ResponseFormat "json" -}}
-	{{- if .ResponseType -}}
-status int, reply {{ .ResponseType }}
-	{{- else -}}
-status int, reply interface{}
-  {{- end -}}
-{{- else if eq .ResponseFormat "string" -}}
-status int, reply string
-{{- else if eq .ResponseFormat "stream" -}}
-status int, reply io.Reader
-{{- else if eq .ResponseFormat "func" -}}
+{{- if eq .ResponseFormat "func" -}}
 reply mockapi.MockResponse
 {{- else -}}
-status int
+	{{- if not .DefaultStatus }}status int,{{ end -}}
+	{{- if eq .ResponseFormat "json" -}}
+		{{- if .ResponseType }} reply {{ .ResponseType }}{{ else }} reply interface{}{{ end -}}
+	{{- else if eq .ResponseFormat "string" }} reply string
+	{{- else if eq .ResponseFormat "stream" }} reply io.Reader
+	{{- end -}}
+{{- end -}}
 {{- end -}}
+`
+
+	tplStatus = `
+{{- define "status" -}}
+{{- if .DefaultStatus }}{{ .DefaultStatus }}{{ else }}status{{ end -}}
 {{- end -}}
 `
 
@@ -122,16 +123,18 @@ func New{{.}}(t mockapi.TestingT) *{{.}} {
 {{- end -}}
 `
 
-	tplFunc = `
-{{- define "endpoint-func-body" -}}
-   req := mockapi.NewMockRequest("{{.Spec.Method}}", 
+	tplRequest = `
+{{- define "endpoint-request" -}}
+   {{- $typedBody := and (eq .Spec.BodyFormat "json") .Spec.BodyType -}}
+   req := {{ if $typedBody }}mockapi.ExpectJSONBody{{ else }}mockapi.NewMockRequest{{ end }}("{{.Spec.Method}}", 
    {{- if .Spec.PathParameters -}}
    fmt.Sprintf("{{.Spec.Path}}", {{range $index, $param := .Spec.PathParameters }}{{ if $index }},{{ end }}{{ $param }}{{ end }})
    {{- else -}}
    "{{.Spec.Path}}"
    {{- end -}}
+   {{- if $typedBody }}, body{{ end -}}
    )
-   {{- if and (ne .Spec.BodyFormat "none") (ne .Spec.BodyFormat "") -}}
+   {{- if and (not $typedBody) (ne .Spec.BodyFormat "none") (ne .Spec.BodyFormat "") -}}
       .WithBody(body)
    {{- end -}}
    {{- if .Spec.QueryParams -}}
@@ -140,18 +143,181 @@ func New{{.}}(t mockapi.TestingT) *{{.}} {
    {{- if .Spec.Headers -}}
       .WithHeaders(headers)
    {{- end }}
+{{- end -}}
+`
+
+	tplFunc = `
+{{- define "endpoint-func-body" -}}
+{{ template "endpoint-request" . }}
    {{ if eq .Spec.ResponseFormat "json" }}
-   return m.WithJSONReply(req, status, reply)
+   return m.WithJSONReply(req, {{ template "status" .Spec }}, reply)
    {{- else if eq .Spec.ResponseFormat "string" }}
-   return m.WithTextReply(req, status, reply)
+   return m.WithTextReply(req, {{ template "status" .Spec }}, reply)
    {{- else if eq .Spec.ResponseFormat "stream" }}
-   return m.WithStreamingReply(req, status, reply)
+   return m.WithStreamingReply(req, {{ template "status" .Spec }}, reply)
    {{- else if eq .Spec.ResponseFormat "func" }}
    return m.WithRequest(req, reply)
    {{- else if or (eq .Spec.ResponseFormat "none") (eq .Spec.ResponseFormat "") }}
-   return m.WithNoResponseBody(req, status)
+   return m.WithNoResponseBody(req, {{ template "status" .Spec }})
    {{- end}}
 {{- end -}}
+`
+
+	tplResponderBody = `
+{{- define "responder-body" -}}
+{{- if eq .BodyFormat "json" -}}
+	{{- if .BodyType -}}
+body {{ .BodyType }}
+	{{- else -}}
+body map[string]interface{}
+	{{- end -}}
+{{- else if or (eq .BodyFormat "string") (eq .BodyFormat "stream") -}}
+body []byte
+{{- end -}}
+{{- end -}}
+`
+
+	tplResponderResult = `
+{{- define "responder-result" -}}
+{{- if eq .ResponseFormat "json" -}}
+	{{- if .ResponseType -}}
+(int, {{ .ResponseType }})
+	{{- else -}}
+(int, interface{})
+	{{- end -}}
+{{- else if eq .ResponseFormat "string" -}}
+(int, string)
+{{- else if eq .ResponseFormat "stream" -}}
+(int, io.Reader)
+{{- else if eq .ResponseFormat "func" -}}
+{{- else -}}
+int
+{{- end -}}
+{{- end -}}
+`
+
+	tplResponder = `
+{{- define "responder" -}}
+// {{.Name}}Responder implements the behavior of the {{.Name}} endpoint.
+type {{.Name}}Responder interface {
+	{{.Name}}(
+	{{- if eq .Spec.ResponseFormat "func" -}}w http.ResponseWriter, {{ end -}}
+	r *http.Request,
+	{{- template "path-parameters" .Spec -}}
+	{{- template "responder-body" .Spec -}}
+	) {{ template "responder-result" .Spec }}
+}
+{{- end -}}
+`
+
+	tplResponderAdapter = `
+{{- define "responder-adapter" -}}
+   req := mockapi.NewMockRequest("{{.Spec.Method}}",
+   {{- if .Spec.PathParameters -}}
+   fmt.Sprintf("{{.Spec.Path}}", {{range $index, $param := .Spec.PathParameters }}{{ if $index }},{{ end }}{{ $param }}{{ end }})
+   {{- else -}}
+   "{{.Spec.Path}}"
+   {{- end -}}
+   )
+   {{- if and (ne .Spec.BodyFormat "none") (ne .Spec.BodyFormat "") -}}
+      .WithBody(mock.Anything)
+   {{- end }}
+
+   return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+   {{- if eq .Spec.BodyFormat "json" }}
+      var body {{ if .Spec.BodyType }}{{ .Spec.BodyType }}{{ else }}map[string]interface{}{{ end }}
+      if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+         http.Error(w, err.Error(), http.StatusBadRequest)
+         return
+      }
+   {{- else if or (eq .Spec.BodyFormat "string") (eq .Spec.BodyFormat "stream") }}
+      body, err := ioutil.ReadAll(r.Body)
+      if err != nil {
+         http.Error(w, err.Error(), http.StatusBadRequest)
+         return
+      }
+   {{- end }}
+   {{- if eq .Spec.ResponseFormat "func" }}
+      impl.{{.Name}}(w, r, {{ range .Spec.PathParameters }}{{ . }},{{ end }}{{ if and (ne .Spec.BodyFormat "none") (ne .Spec.BodyFormat "") }}body{{ end }})
+   {{- else if or (eq .Spec.ResponseFormat "none") (eq .Spec.ResponseFormat "") }}
+      w.WriteHeader(impl.{{.Name}}(r, {{ range .Spec.PathParameters }}{{ . }},{{ end }}{{ if and (ne .Spec.BodyFormat "none") (ne .Spec.BodyFormat "") }}body{{ end }}))
+   {{- else }}
+      status, reply := impl.{{.Name}}(r, {{ range .Spec.PathParameters }}{{ . }},{{ end }}{{ if and (ne .Spec.BodyFormat "none") (ne .Spec.BodyFormat "") }}body{{ end }})
+      {{- if eq .Spec.ResponseFormat "json" }}
+      resp, err := mockapi.JSONResponse(status, reply)
+      if err != nil {
+         http.Error(w, err.Error(), http.StatusInternalServerError)
+         return
+      }
+      resp(w, r)
+      {{- else if eq .Spec.ResponseFormat "string" }}
+      w.WriteHeader(status)
+      w.Write([]byte(reply))
+      {{- else if eq .Spec.ResponseFormat "stream" }}
+      w.WriteHeader(status)
+      if reply != nil {
+         io.Copy(w, reply)
+      }
+      {{- end }}
+   {{- end }}
+   })
+{{- end -}}
+`
+
+	tplExample = `
+{{- define "example" -}}
+// {{.ConstName}} is the example response of {{.Endpoint.Name}} for the {{.Status}} status code.
+const {{.ConstName}} = {{.Literal}}
+
+// {{.FuncName}} will setup an expectation for the {{.Endpoint.Name}} endpoint which is
+// replied to with the {{.Status}} status code and the {{.ConstName}} example.
+func (m *{{.Receiver}}) {{.FuncName}}(
+	{{- template "path-parameters" .Endpoint.Spec -}}
+	{{- template "request-headers" .Endpoint.Spec.Headers -}}
+	{{- template "query-params" .Endpoint.Spec.QueryParams -}}
+	{{- template "body" .Endpoint.Spec }}) *mockapi.MockAPICall {
+{{ template "endpoint-request" .Endpoint }}
+
+   return m.WithJSONReply(req, {{.Status}}, json.RawMessage({{.ConstName}}))
+}
+{{- end -}}
+`
+
+	tplStatusHelper = `
+{{- define "status-helper" -}}
+// {{.FuncName}} will setup an expectation for the {{.Endpoint.Name}} endpoint which is
+// replied to with the {{.Status}} status code.
+func (m *{{.Receiver}}) {{.FuncName}}(
+	{{- template "path-parameters" .Endpoint.Spec -}}
+	{{- template "request-headers" .Endpoint.Spec.Headers -}}
+	{{- template "query-params" .Endpoint.Spec.QueryParams -}}
+	{{- template "body" .Endpoint.Spec -}}
+	{{- if eq .Response.Format "json" }} reply {{ if .Response.Type }}{{ .Response.Type }}{{ else }}interface{}{{ end }}
+	{{- else if eq .Response.Format "string" }} reply string
+	{{- else if eq .Response.Format "stream" }} reply io.Reader
+	{{- end }}) *mockapi.MockAPICall {
+{{ template "endpoint-request" .Endpoint }}
+   {{ if eq .Response.Format "json" }}
+   return m.WithJSONReply(req, {{.Status}}, reply)
+   {{- else if eq .Response.Format "string" }}
+   return m.WithTextReply(req, {{.Status}}, reply)
+   {{- else if eq .Response.Format "stream" }}
+   return m.WithStreamingReply(req, {{.Status}}, reply)
+   {{- else }}
+   return m.WithNoResponseBody(req, {{.Status}})
+   {{- end }}
+}
+{{- end -}}
+`
+
+	tplTypes = `
+{{- define "types" -}}
+{{- range . }}
+
+// {{.Name}} is a body type of the API.
+type {{.Name}} {{.Definition}}
+{{- end -}}
+{{- end -}}
 `
 
 	tplFile = `
@@ -164,16 +330,37 @@ func New{{.}}(t mockapi.TestingT) *{{.}} {
 
 {{ $receiver := .Receiver }}
 {{ template "mock-type" $receiver }}
+{{- template "types" .Types }}
 {{ range .Endpoints }}
 
 func (m *{{ $receiver }}) {{.Name}}(
-	{{- template "path-parameters" .Spec.PathParameters -}}
+	{{- template "path-parameters" .Spec -}}
 	{{- template "request-headers" .Spec.Headers -}}
 	{{- template "query-params" .Spec.QueryParams -}}
 	{{- template "body" .Spec }}
 	{{- template "reply" .Spec }}) *mockapi.MockAPICall {
 {{ template "endpoint-func-body" . }}
 }
+{{- range .Statuses }}
+
+{{ template "status-helper" . }}
+{{- end }}
+{{- range .Examples }}
+
+{{ template "example" . }}
+{{- end }}
+{{- if $.Responders }}
+
+{{ template "responder" . }}
+
+// Handle{{.Name}} will setup an expectation for the {{.Name}} endpoint which is
+// replied to by the supplied {{.Name}}Responder.
+func (m *{{ $receiver }}) Handle{{.Name}}(
+	{{- template "path-parameters" .Spec -}}
+	impl {{.Name}}Responder) *mockapi.MockAPICall {
+{{ template "responder-adapter" . }}
+}
+{{- end -}}
 {{- end -}}
 `
 )
@@ -181,11 +368,40 @@ func (m *{{ $receiver }}) {{.Name}}(
 type inputData struct {
 	Imports   map[string]string           `json:"imports"`
 	Endpoints map[string]mockapi.Endpoint `json:"endpoints"`
+	// Types are the definitions of types to generate keyed by type name
+	Types map[string]string `json:"types"`
 }
 
 type tplEndpoint struct {
-	Name string
-	Spec mockapi.Endpoint
+	Name     string
+	Spec     mockapi.Endpoint
+	Examples []tplEndpointExample
+	Statuses []tplEndpointStatus
+}
+
+// tplEndpointStatus is a documented response to generate a reply helper for.
+type tplEndpointStatus struct {
+	Endpoint *tplEndpoint
+	Receiver string
+	Status   string
+	FuncName string
+	Response mockapi.EndpointResponse
+}
+
+// tplType is a type to generate.
+type tplType struct {
+	Name       string
+	Definition string
+}
+
+// tplEndpointExample is an example response to generate a constant and reply helper for.
+type tplEndpointExample struct {
+	Endpoint  *tplEndpoint
+	Receiver  string
+	Status    string
+	ConstName string
+	FuncName  string
+	Literal   string
 }
 
 type tplArgs struct {
@@ -194,20 +410,35 @@ type tplArgs struct {
 	BuildTags []string
 	Receiver  string
 	Imports   []string
+	Types     []tplType
 	Endpoints []tplEndpoint
+
+	// Responders enables generating a responder interface and adapter per endpoint
+	Responders bool
 }
 
 func parseTemplate() *template.Template {
-	tpl := template.New("mock-api-helpers")
+	tpl := template.New("mock-api-helpers").Funcs(template.FuncMap{
+		"pathParameterType": pathParameterType,
+	})
 
 	template.Must(tpl.Parse(tplFile))
 	template.Must(tpl.Parse(tplMockType))
+	template.Must(tpl.Parse(tplRequest))
 	template.Must(tpl.Parse(tplFunc))
+	template.Must(tpl.Parse(tplExample))
+	template.Must(tpl.Parse(tplStatusHelper))
+	template.Must(tpl.Parse(tplTypes))
+	template.Must(tpl.Parse(tplResponder))
+	template.Must(tpl.Parse(tplResponderAdapter))
+	template.Must(tpl.Parse(tplResponderBody))
+	template.Must(tpl.Parse(tplResponderResult))
 	template.Must(tpl.Parse(tplBody))
 	template.Must(tpl.Parse(tplRequestHeaders))
 	template.Must(tpl.Parse(tplQueryParams))
 	template.Must(tpl.Parse(tplPathParameters))
 	template.Must(tpl.Parse(tplReply))
+	template.Must(tpl.Parse(tplStatus))
 	template.Must(tpl.Parse(tplImports))
 	template.Must(tpl.Parse(tplPackage))
 	template.Must(tpl.Parse(tplHeader))
@@ -220,16 +451,29 @@ func parseTemplate() *template.Template {
 func Usage() {
 	fmt.Fprintf(os.Stderr, "Usage of mock-api-gen:\n")
 	fmt.Fprintf(os.Stderr, "\tmock-api-gen [flags] -type <type name> -endpoints <var name> [package]\n")
+	fmt.Fprintf(os.Stderr, "\tmock-api-gen [flags] -cassette <file> [package]\n")
+	fmt.Fprintf(os.Stderr, "\tmock-api-gen [flags] -har <file> [-host <host>] [package]\n")
+	fmt.Fprintf(os.Stderr, "\tmock-api-gen [flags] -postman <file> [-var <key>=<value> ...] [package]\n")
 	fmt.Fprintf(os.Stderr, "Flags:\n")
 	flag.PrintDefaults()
 }
 
 type config struct {
-	input    string
-	receiver string
-	output   string
-	pkgName  string
-	tags     []string
+	input      string
+	receiver   string
+	output     string
+	pkgName    string
+	tags       []string
+	responders bool
+	openapi    string
+
+	cassette    string
+	har         string
+	host        string
+	postman     string
+	variables   []string
+	funcName    string
+	skipHeaders []string
 }
 
 type stringSliceValue []string
@@ -254,7 +498,16 @@ func parseCLIFlags() config {
 	flag.StringVar(&cfg.input, "endpoints", "endpoints", "File holding the endpoint configuration.")
 	flag.StringVar(&cfg.receiver, "type", "", "Method receiver type the mock API helpers should be generated for")
 	flag.StringVar(&cfg.pkgName, "pkg", "", "Name of the package to generate methods in")
+	flag.BoolVar(&cfg.responders, "responders", false, "Also generate a responder interface per endpoint along with a Handle<Endpoint> method wiring implementations into the mock API.")
 	flag.Var(newStringSliceValue(&cfg.tags), "tag", "Build tags the generated file should have. This may be specified multiple times.")
+	flag.StringVar(&cfg.openapi, "openapi", "", "OpenAPI 3 spec (YAML or JSON) to generate helpers for every operation of instead of an endpoints file.")
+	flag.StringVar(&cfg.cassette, "cassette", "", "Recorded cassette file to generate expectations from instead of an endpoints file.")
+	flag.StringVar(&cfg.har, "har", "", "HAR file to generate expectations from instead of an endpoints file.")
+	flag.StringVar(&cfg.host, "host", "", "Only generate expectations for the entries of the HAR file for requests made to this host.")
+	flag.StringVar(&cfg.postman, "postman", "", "Postman collection to generate expectations from the example responses of instead of an endpoints file.")
+	flag.Var(newStringSliceValue(&cfg.variables), "var", "Value of a Postman collection variable in the form key=value. This may be specified multiple times.")
+	flag.StringVar(&cfg.funcName, "func", "ExpectRecordedInteractions", "Name of the function generated from a cassette, HAR file or Postman collection.")
+	flag.Var(newStringSliceValue(&cfg.skipHeaders), "skip-header", "Recorded request header to leave out of the expectations generated from a cassette. This may be specified multiple times.")
 
 	flag.Usage = Usage
 	flag.Parse()
@@ -265,7 +518,7 @@ func parseCLIFlags() config {
 		os.Exit(1)
 	}
 
-	if cfg.receiver == "" {
+	if cfg.receiver == "" && cfg.cassette == "" && cfg.har == "" && cfg.postman == "" {
 		fmt.Fprintf(os.Stderr, "-type is a required option\n\n")
 		flag.Usage()
 		os.Exit(1)
@@ -286,28 +539,159 @@ func parseCLIFlags() config {
 	return cfg
 }
 
+// examples returns the example responses of the endpoint which helpers
+// should be generated for. Only examples for specific status codes are used.
+func examples(endpoint *tplEndpoint, receiver string) []tplEndpointExample {
+	var examples []tplEndpointExample
+	for status, example := range endpoint.Spec.ResponseExamples {
+		if _, err := strconv.Atoi(status); err != nil {
+			continue
+		}
+
+		examples = append(examples, tplEndpointExample{
+			Endpoint:  endpoint,
+			Receiver:  receiver,
+			Status:    status,
+			ConstName: fmt.Sprintf("%s%sExample", endpoint.Name, status),
+			FuncName:  fmt.Sprintf("ReplyWithExample_%s_%s", endpoint.Name, status),
+			Literal:   stringLiteral(string(example)),
+		})
+	}
+
+	sort.Slice(examples, func(i, j int) bool {
+		return examples[i].Status < examples[j].Status
+	})
+	return examples
+}
+
+// statuses returns the documented responses of the endpoint which reply helpers
+// should be generated for. Only responses for specific status codes are used.
+func statuses(endpoint *tplEndpoint, receiver string) []tplEndpointStatus {
+	var statuses []tplEndpointStatus
+	for status, resp := range endpoint.Spec.Responses {
+		if _, err := strconv.Atoi(status); err != nil || resp.Format == mockapi.ResponseFormatFunc {
+			continue
+		}
+
+		statuses = append(statuses, tplEndpointStatus{
+			Endpoint: endpoint,
+			Receiver: receiver,
+			Status:   status,
+			FuncName: endpoint.Name + status,
+			Response: resp,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Status < statuses[j].Status
+	})
+	return statuses
+}
+
+// pathParameterType returns the Go type of the path parameter at index.
+func pathParameterType(spec mockapi.Endpoint, index int) string {
+	if index < len(spec.PathParameterTypes) && spec.PathParameterTypes[index] != "" {
+		return spec.PathParameterTypes[index]
+	}
+	return "string"
+}
+
+// responderImports returns the imports needed by the responder adapters
+// of the endpoints.
+func responderImports(endpoints []tplEndpoint) []string {
+	imports := []string{`"net/http"`}
+	for _, endpoint := range endpoints {
+		switch endpoint.Spec.BodyFormat {
+		case mockapi.BodyFormatJSON:
+			imports = append(imports, `"encoding/json"`, `"github.com/stretchr/testify/mock"`)
+		case mockapi.BodyFormatString, mockapi.BodyFormatStream:
+			imports = append(imports, `"io/ioutil"`, `"github.com/stretchr/testify/mock"`)
+		}
+
+		if endpoint.Spec.ResponseFormat == mockapi.ResponseFormatStream {
+			imports = append(imports, `"io"`)
+		}
+	}
+	return imports
+}
+
 func main() {
 	cfg := parseCLIFlags()
 
-	var input inputData
+	if cfg.cassette != "" {
+		fmt.Printf("Generating expectations for %s\n", cfg.cassette)
+		src, err := generateFromCassette(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate expectations from cassette %q: %v\n", cfg.cassette, err)
+			os.Exit(1)
+		}
+		writeSource(cfg.output, src)
+		return
+	}
 
-	data, err := ioutil.ReadFile(cfg.input)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to load data from input file %q: %v\n", cfg.input, err)
-		os.Exit(1)
+	if cfg.postman != "" {
+		fmt.Printf("Generating expectations for %s\n", cfg.postman)
+		src, err := generateFromPostman(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate expectations from Postman collection %q: %v\n", cfg.postman, err)
+			os.Exit(1)
+		}
+		writeSource(cfg.output, src)
+		return
 	}
 
-	err = json.Unmarshal(data, &input)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to load JSON from input data file %q: %v\n", cfg.input, err)
-		os.Exit(1)
+	if cfg.har != "" {
+		fmt.Printf("Generating expectations for %s\n", cfg.har)
+		src, err := generateFromHAR(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate expectations from HAR file %q: %v\n", cfg.har, err)
+			os.Exit(1)
+		}
+		writeSource(cfg.output, src)
+		return
+	}
+
+	var input inputData
+
+	if cfg.openapi != "" {
+		cfg.input = cfg.openapi
+		spec, err := mockapi.LoadOpenAPISpec(cfg.openapi)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load OpenAPI spec: %v\n", err)
+			os.Exit(1)
+		}
+
+		input.Endpoints, err = spec.Endpoints()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to convert OpenAPI spec %q into endpoints: %v\n", cfg.openapi, err)
+			os.Exit(1)
+		}
+
+		input.Types, err = spec.GoTypes()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to convert the schemas of OpenAPI spec %q into types: %v\n", cfg.openapi, err)
+			os.Exit(1)
+		}
+	} else {
+		data, err := ioutil.ReadFile(cfg.input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load data from input file %q: %v\n", cfg.input, err)
+			os.Exit(1)
+		}
+
+		err = json.Unmarshal(data, &input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load JSON from input data file %q: %v\n", cfg.input, err)
+			os.Exit(1)
+		}
 	}
 
 	args := tplArgs{
-		CLIArgs:   strings.Join(os.Args[1:], " "),
-		BuildTags: cfg.tags,
-		Package:   cfg.pkgName,
-		Receiver:  cfg.receiver,
+		CLIArgs:    strings.Join(os.Args[1:], " "),
+		BuildTags:  cfg.tags,
+		Package:    cfg.pkgName,
+		Receiver:   cfg.receiver,
+		Responders: cfg.responders,
 	}
 
 	for name, spec := range input.Endpoints {
@@ -322,6 +706,31 @@ func main() {
 		return args.Endpoints[i].Name < args.Endpoints[j].Name
 	})
 
+	for name, def := range input.Types {
+		args.Types = append(args.Types, tplType{Name: name, Definition: def})
+	}
+	sort.Slice(args.Types, func(i, j int) bool {
+		return args.Types[i].Name < args.Types[j].Name
+	})
+
+	imports := make(map[string]struct{})
+	for i := range args.Endpoints {
+		endpoint := &args.Endpoints[i]
+		if len(endpoint.Spec.PathParameters) > 0 {
+			imports[`"fmt"`] = struct{}{}
+		}
+		endpoint.Examples = examples(endpoint, cfg.receiver)
+		if len(endpoint.Examples) > 0 {
+			imports[`"encoding/json"`] = struct{}{}
+		}
+		endpoint.Statuses = statuses(endpoint, cfg.receiver)
+		for _, status := range endpoint.Statuses {
+			if status.Response.Format == mockapi.ResponseFormatStream {
+				imports[`"io"`] = struct{}{}
+			}
+		}
+	}
+
 	for pkgName, path := range input.Imports {
 		var importPath string
 		if strings.HasSuffix(path, "/"+pkgName) {
@@ -329,6 +738,16 @@ func main() {
 		} else {
 			importPath = fmt.Sprintf(`%s "%s"`, pkgName, path)
 		}
+		imports[importPath] = struct{}{}
+	}
+
+	if cfg.responders {
+		for _, importPath := range responderImports(args.Endpoints) {
+			imports[importPath] = struct{}{}
+		}
+	}
+
+	for importPath := range imports {
 		args.Imports = append(args.Imports, importPath)
 	}
 	sort.Strings(args.Imports)
@@ -342,16 +761,20 @@ func main() {
 		os.Exit(1)
 	}
 
-	src := buf.Bytes()
+	writeSource(cfg.output, buf.Bytes())
+}
+
+// writeSource formats the generated source and writes it to the output file.
+func writeSource(output string, src []byte) {
 	formatted, err := format.Source(src)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to format rendered source: %v\n", err)
 		os.Exit(1)
 	}
 
-	if err := ioutil.WriteFile(cfg.output, formatted, 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to write generated source to file %s: %v\n", cfg.output, err)
+	if err := ioutil.WriteFile(output, formatted, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write generated source to file %s: %v\n", output, err)
 		os.Exit(1)
 	}
-	fmt.Printf("Successfully generated source in %s\n", cfg.output)
+	fmt.Printf("Successfully generated source in %s\n", output)
 }
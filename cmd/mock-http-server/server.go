@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	mockapi "github.com/mkeeler/mock-http-api"
+)
+
+// Usage is a replacement usage function for the flags package.
+func Usage() {
+	fmt.Fprintf(os.Stderr, "Usage of mock-http-server:\n")
+	fmt.Fprintf(os.Stderr, "\tmock-http-server [flags] -fixture <file> [-fixture <file> ...]\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+type config struct {
+	addr            string
+	fixtures        []string
+	filteredHeaders []string
+	admin           bool
+	metricsPath     string
+}
+
+type stringSliceValue []string
+
+func (v *stringSliceValue) String() string {
+	return strings.Join(*v, ",")
+}
+
+func (v *stringSliceValue) Set(s string) error {
+	*v = append(*v, s)
+	return nil
+}
+
+func newStringSliceValue(p *[]string) *stringSliceValue {
+	return (*stringSliceValue)(p)
+}
+
+func parseCLIFlags() config {
+	cfg := config{}
+
+	flag.StringVar(&cfg.addr, "addr", "127.0.0.1:8080", "Address the mock HTTP server should listen on.")
+	flag.Var(newStringSliceValue(&cfg.fixtures), "fixture", "File, or directory of files, holding expectations to serve. This may be specified multiple times.")
+	flag.BoolVar(&cfg.admin, "admin", false, "Enable the /__admin/ API for managing expectations at runtime.")
+	flag.StringVar(&cfg.metricsPath, "metrics-path", "", "Path to serve Prometheus metrics under. Metrics are disabled when empty.")
+	flag.Var(newStringSliceValue(&cfg.filteredHeaders), "filter-header", "Request header to ignore when matching expectations. This may be specified multiple times.")
+
+	flag.Usage = Usage
+	flag.Parse()
+
+	if len(cfg.fixtures) == 0 && !cfg.admin {
+		fmt.Fprintf(os.Stderr, "-fixture is a required option unless -admin is specified\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	return cfg
+}
+
+func main() {
+	cfg := parseCLIFlags()
+
+	opts := []mockapi.Option{mockapi.WithListenAddress(cfg.addr)}
+	if cfg.admin {
+		opts = append(opts, mockapi.WithAdminAPI())
+	}
+	if cfg.metricsPath != "" {
+		opts = append(opts, mockapi.WithMetrics(cfg.metricsPath))
+	}
+
+	m, err := mockapi.New(opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to start the mock HTTP server: %v\n", err)
+		os.Exit(1)
+	}
+	defer m.Close()
+
+	m.SetFilteredHeaders(cfg.filteredHeaders)
+
+	for _, path := range cfg.fixtures {
+		load := m.LoadFixtureFile
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			load = m.LoadFixtures
+		}
+
+		if err := load(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load fixture file %q: %v\n", path, err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("Serving mock HTTP API at %s\n", m.URL())
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	<-sigCh
+
+	fmt.Printf("Shutting down\n")
+}
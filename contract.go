@@ -0,0 +1,589 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// WithOpenAPIValidation validates every request received and every response sent for an
+// expectation against the OpenAPI 3 spec, failing the test for any violation of it. This
+// catches expectations which have drifted from the shape of the real API. Requests must be
+// for a documented path and method with the documented parameters and request body while
+// responses must have a documented status code and body. Bodies are only validated against
+// their schema when they are JSON, which bodies without a Content-Type are assumed to be.
+//
+// Paths are matched both as is and with the path of any of the servers of the spec removed.
+// Violations do not change how requests are matched or replied to.
+func WithOpenAPIValidation(spec *OpenAPISpec) Option {
+	return func(m *MockAPI) error {
+		if spec == nil {
+			return fmt.Errorf("nil OpenAPI spec")
+		}
+		m.contract = newContractValidator(spec)
+		return nil
+	}
+}
+
+// WithOpenAPIValidationFile validates requests and responses against the OpenAPI 3 spec
+// stored in the file at path as described for WithOpenAPIValidation.
+func WithOpenAPIValidationFile(path string) Option {
+	return func(m *MockAPI) error {
+		spec, err := LoadOpenAPISpec(path)
+		if err != nil {
+			return err
+		}
+		return WithOpenAPIValidation(spec)(m)
+	}
+}
+
+// contractValidator validates requests and responses against an OpenAPI spec.
+type contractValidator struct {
+	spec     *OpenAPISpec
+	prefixes []string
+	routes   []*contractRoute
+}
+
+// contractRoute matches the paths of requests against a path of the spec.
+type contractRoute struct {
+	path    string
+	pattern *regexp.Regexp
+	params  []string
+	item    *OpenAPIPathItem
+}
+
+func newContractValidator(spec *OpenAPISpec) *contractValidator {
+	v := &contractValidator{spec: spec}
+
+	for _, server := range spec.Servers {
+		if server == nil {
+			continue
+		}
+		// the URL may hold server variables and so is not parsed as a URL
+		prefix := server.URL
+		if i := strings.Index(prefix, "://"); i >= 0 {
+			prefix = prefix[i+3:]
+			if j := strings.Index(prefix, "/"); j >= 0 {
+				prefix = prefix[j:]
+			} else {
+				prefix = ""
+			}
+		}
+		prefix = strings.TrimSuffix(prefix, "/")
+		if strings.HasPrefix(prefix, "/") && !strings.Contains(prefix, "{") {
+			v.prefixes = append(v.prefixes, prefix)
+		}
+	}
+
+	for path, item := range spec.Paths {
+		if item == nil {
+			continue
+		}
+
+		route := &contractRoute{path: path, item: item}
+		var sb strings.Builder
+		sb.WriteString("^")
+		rest := path
+		for _, loc := range openAPIPathParam.FindAllStringSubmatchIndex(path, -1) {
+			offset := len(path) - len(rest)
+			sb.WriteString(regexp.QuoteMeta(rest[:loc[0]-offset]))
+			sb.WriteString("([^/]+)")
+			route.params = append(route.params, path[loc[2]:loc[3]])
+			rest = path[loc[1]:]
+		}
+		sb.WriteString(regexp.QuoteMeta(rest))
+		sb.WriteString("$")
+		route.pattern = regexp.MustCompile(sb.String())
+		v.routes = append(v.routes, route)
+	}
+
+	// concrete paths take precedence over templated ones matching the same path
+	sort.Slice(v.routes, func(i, j int) bool {
+		a, b := v.routes[i], v.routes[j]
+		if len(a.params) != len(b.params) {
+			return len(a.params) < len(b.params)
+		}
+		return a.path < b.path
+	})
+	return v
+}
+
+// route returns the route of the spec matching the path along with the values of
+// its path params.
+func (v *contractValidator) route(path string) (*contractRoute, map[string]string) {
+	candidates := []string{path}
+	for _, prefix := range v.prefixes {
+		if strings.HasPrefix(path, prefix+"/") {
+			candidates = append(candidates, strings.TrimPrefix(path, prefix))
+		}
+	}
+
+	for _, candidate := range candidates {
+		for _, route := range v.routes {
+			match := route.pattern.FindStringSubmatch(candidate)
+			if match == nil {
+				continue
+			}
+
+			params := make(map[string]string)
+			for i, name := range route.params {
+				params[name] = match[i+1]
+			}
+			return route, params
+		}
+	}
+	return nil, nil
+}
+
+// operation returns the operation of the spec for the request.
+func (v *contractValidator) operation(r *http.Request) (*contractRoute, *OpenAPIOperation, map[string]string) {
+	route, params := v.route(r.URL.Path)
+	if route == nil {
+		return nil, nil, nil
+	}
+	return route, route.item.operations()[r.Method], params
+}
+
+// checkRequest reports any violations of the spec by the request.
+func (v *contractValidator) checkRequest(m *MockAPI, r *http.Request, body []byte) {
+	if problems := v.validateRequest(r, body); len(problems) > 0 {
+		m.errorf("mockapi: request %s %s violates the OpenAPI spec:\n\t%s", r.Method, r.URL, strings.Join(problems, "\n\t"))
+	}
+}
+
+// checkResponse reports any violations of the spec by the response recorded by w.
+func (v *contractValidator) checkResponse(m *MockAPI, r *http.Request, w *harWriter) {
+	if w.hijacked {
+		return
+	}
+
+	status, header := w.status, w.header
+	if status == 0 {
+		status, header = http.StatusOK, w.Header()
+	}
+	if problems := v.validateResponse(r, status, header, w.body.Bytes()); len(problems) > 0 {
+		m.errorf("mockapi: response %d to %s %s violates the OpenAPI spec:\n\t%s", status, r.Method, r.URL, strings.Join(problems, "\n\t"))
+	}
+}
+
+func (v *contractValidator) validateRequest(r *http.Request, body []byte) []string {
+	route, op, pathParams := v.operation(r)
+	if route == nil {
+		return []string{fmt.Sprintf("path %s is not described by the spec", r.URL.Path)}
+	}
+	if op == nil {
+		return []string{fmt.Sprintf("method %s is not allowed for path %s", r.Method, route.path)}
+	}
+
+	var problems []string
+	for _, param := range mergeParameters(route.item.Parameters, op.Parameters) {
+		var values []string
+		switch param.In {
+		case "path":
+			values = []string{pathParams[param.Name]}
+		case "query":
+			values = r.URL.Query()[param.Name]
+		case "header":
+			for _, value := range r.Header.Values(param.Name) {
+				values = append(values, strings.Split(value, ",")...)
+			}
+		case "cookie":
+			if cookie, err := r.Cookie(param.Name); err == nil {
+				values = []string{cookie.Value}
+			}
+		default:
+			continue
+		}
+
+		at := fmt.Sprintf("%s param %q", param.In, param.Name)
+		if len(values) == 0 {
+			if param.Required {
+				problems = append(problems, fmt.Sprintf("%s is required", at))
+			}
+			continue
+		}
+		problems = append(problems, v.validateParam(param.Schema, values, at)...)
+	}
+
+	rb := op.RequestBody
+	switch {
+	case len(body) == 0:
+		if rb != nil && rb.Required {
+			problems = append(problems, "request body is required")
+		}
+	case rb == nil || len(rb.Content) == 0:
+		problems = append(problems, "request body is not described by the spec")
+	default:
+		problems = append(problems, v.validateBody(rb.Content, r.Header.Get("Content-Type"), body, "request body", false)...)
+	}
+	return problems
+}
+
+func (v *contractValidator) validateResponse(r *http.Request, status int, header http.Header, body []byte) []string {
+	route, op, _ := v.operation(r)
+	if route == nil || op == nil {
+		// already reported for the request
+		return nil
+	}
+
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		resp, ok = op.Responses[fmt.Sprintf("%dXX", status/100)]
+	}
+	if !ok {
+		resp, ok = op.Responses["default"]
+	}
+	if !ok {
+		return []string{fmt.Sprintf("status %d is not documented for %s %s", status, r.Method, route.path)}
+	}
+
+	if len(body) == 0 {
+		return nil
+	}
+	if resp == nil || len(resp.Content) == 0 {
+		return []string{fmt.Sprintf("response body is not documented for status %d", status)}
+	}
+	return v.validateBody(resp.Content, header.Get("Content-Type"), body, "response body", true)
+}
+
+// mergeParameters returns the parameters of the operation along with those of its path
+// which the operation does not override.
+func mergeParameters(pathParams, opParams []*OpenAPIParameter) []*OpenAPIParameter {
+	params := append([]*OpenAPIParameter(nil), opParams...)
+	for _, param := range pathParams {
+		overridden := false
+		for _, opParam := range opParams {
+			if opParam.Name == param.Name && opParam.In == param.In {
+				overridden = true
+			}
+		}
+		if !overridden {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// validateParam validates the raw values of a parameter. Only array parameters may
+// have more than one value.
+func (v *contractValidator) validateParam(schema *OpenAPISchema, values []string, at string) []string {
+	schema = v.deref(schema)
+	if schema != nil && schema.Type == "array" {
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		items := make([]interface{}, len(values))
+		for i, value := range values {
+			items[i] = paramValue(v.deref(schema.Items), value)
+		}
+		return v.validateValue(schema, items, at, false)
+	}
+
+	if len(values) > 1 {
+		return []string{fmt.Sprintf("%s: expected a single value but got %d", at, len(values))}
+	}
+	return v.validateValue(schema, paramValue(schema, values[0]), at, false)
+}
+
+// paramValue converts the raw value of a parameter into the JSON value its schema
+// describes. Values which cannot be converted are kept as strings so that they are
+// reported as having the wrong type.
+func paramValue(schema *OpenAPISchema, raw string) interface{} {
+	if schema == nil {
+		return raw
+	}
+	switch schema.Type {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(raw, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
+
+// validateBody validates a request or response body against the content of the spec
+// for its Content-Type.
+func (v *contractValidator) validateBody(content map[string]*OpenAPIMediaType, contentType string, body []byte, at string, response bool) []string {
+	if contentType == "" {
+		// mocked replies such as those of WithJSONReply often do not set a Content-Type
+		return v.validateJSON(jsonMediaType(content), body, at, response)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = ""
+	}
+
+	media, ok := contentFor(content, mediaType)
+	if !ok {
+		documented := make([]string, 0, len(content))
+		for key := range content {
+			documented = append(documented, key)
+		}
+		sort.Strings(documented)
+		return []string{fmt.Sprintf("%s has Content-Type %q instead of one of %s", at, contentType, strings.Join(documented, ", "))}
+	}
+
+	if !isJSONMediaType(mediaType) {
+		return nil
+	}
+	return v.validateJSON(media, body, at, response)
+}
+
+// validateJSON validates a JSON body against the schema of the media type.
+func (v *contractValidator) validateJSON(media *OpenAPIMediaType, body []byte, at string, response bool) []string {
+	if media == nil || media.Schema == nil {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []string{fmt.Sprintf("%s is not valid JSON: %v", at, err)}
+	}
+	return v.validateValue(media.Schema, value, at, response)
+}
+
+// contentFor returns the content of the spec for the media type, preferring exact
+// matches over ranges such as application/* and */*.
+func contentFor(content map[string]*OpenAPIMediaType, mediaType string) (*OpenAPIMediaType, bool) {
+	ranges := map[string]*OpenAPIMediaType{}
+	for key, media := range content {
+		parsed, _, err := mime.ParseMediaType(key)
+		if err != nil {
+			parsed = strings.ToLower(strings.TrimSpace(key))
+		}
+		if parsed == mediaType {
+			return media, true
+		}
+		ranges[parsed] = media
+	}
+
+	if i := strings.Index(mediaType, "/"); i >= 0 {
+		if media, ok := ranges[mediaType[:i]+"/*"]; ok {
+			return media, true
+		}
+	}
+	media, ok := ranges["*/*"]
+	return media, ok
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// deref returns the schema a $ref refers to, or the schema itself when it is not one.
+// References which cannot be resolved are reported by validateValue.
+func (v *contractValidator) deref(schema *OpenAPISchema) *OpenAPISchema {
+	for i := 0; schema != nil && schema.Ref != "" && i < 32; i++ {
+		_, target, err := v.spec.resolve(schema.Ref)
+		if err != nil {
+			return schema
+		}
+		schema = target
+	}
+	return schema
+}
+
+// validateValue validates a decoded JSON value against the schema. Read only properties
+// are not required in requests and write only properties are not required in responses.
+func (v *contractValidator) validateValue(schema *OpenAPISchema, value interface{}, at string, response bool) []string {
+	if schema == nil {
+		return nil
+	}
+
+	if schema.Ref != "" {
+		_, target, err := v.spec.resolve(schema.Ref)
+		if err != nil {
+			return []string{fmt.Sprintf("%s: %v", at, err)}
+		}
+		return v.validateValue(target, value, at, response)
+	}
+
+	if value == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: expected %s but got null", at, schema.Type)}
+	}
+
+	var problems []string
+	for _, sub := range schema.AllOf {
+		problems = append(problems, v.validateValue(sub, value, at, response)...)
+	}
+
+	if len(schema.AnyOf) > 0 {
+		matched := false
+		for _, sub := range schema.AnyOf {
+			if len(v.validateValue(sub, value, at, response)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			problems = append(problems, fmt.Sprintf("%s: does not match any of the anyOf schemas", at))
+		}
+	}
+
+	if len(schema.OneOf) > 0 {
+		matched := 0
+		for _, sub := range schema.OneOf {
+			if len(v.validateValue(sub, value, at, response)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			problems = append(problems, fmt.Sprintf("%s: matches %d of the oneOf schemas instead of exactly one", at, matched))
+		}
+	}
+
+	if len(schema.Enum) > 0 && !enumContains(schema.Enum, value) {
+		problems = append(problems, fmt.Sprintf("%s: %s is not one of the allowed values", at, describeJSON(value)))
+	}
+
+	switch schema.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return append(problems, typeMismatch(at, schema.Type, value))
+		}
+		length := utf8.RuneCountInString(s)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			problems = append(problems, fmt.Sprintf("%s: length %d is less than the minimum of %d", at, length, *schema.MinLength))
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			problems = append(problems, fmt.Sprintf("%s: length %d is more than the maximum of %d", at, length, *schema.MaxLength))
+		}
+		if schema.Pattern != "" {
+			pattern, err := regexp.Compile(schema.Pattern)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid pattern %q: %v", at, schema.Pattern, err))
+			} else if !pattern.MatchString(s) {
+				problems = append(problems, fmt.Sprintf("%s: %q does not match the pattern %q", at, s, schema.Pattern))
+			}
+		}
+
+	case "integer", "number":
+		f, ok := value.(float64)
+		if !ok || (schema.Type == "integer" && f != math.Trunc(f)) {
+			return append(problems, typeMismatch(at, schema.Type, value))
+		}
+		if schema.Minimum != nil && (f < *schema.Minimum || schema.ExclusiveMinimum && f == *schema.Minimum) {
+			problems = append(problems, fmt.Sprintf("%s: %v is less than the minimum of %v", at, f, *schema.Minimum))
+		}
+		if schema.Maximum != nil && (f > *schema.Maximum || schema.ExclusiveMaximum && f == *schema.Maximum) {
+			problems = append(problems, fmt.Sprintf("%s: %v is more than the maximum of %v", at, f, *schema.Maximum))
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return append(problems, typeMismatch(at, schema.Type, value))
+		}
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return append(problems, typeMismatch(at, schema.Type, value))
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			problems = append(problems, fmt.Sprintf("%s: %d items are less than the minimum of %d", at, len(items), *schema.MinItems))
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			problems = append(problems, fmt.Sprintf("%s: %d items are more than the maximum of %d", at, len(items), *schema.MaxItems))
+		}
+		for i, item := range items {
+			problems = append(problems, v.validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", at, i), response)...)
+		}
+
+	case "object", "":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			if schema.Type == "object" {
+				return append(problems, typeMismatch(at, schema.Type, value))
+			}
+			break
+		}
+
+		for _, name := range schema.Required {
+			if _, ok := object[name]; ok {
+				continue
+			}
+			if prop := v.deref(schema.Properties[name]); prop != nil && (prop.ReadOnly && !response || prop.WriteOnly && response) {
+				continue
+			}
+			problems = append(problems, fmt.Sprintf("%s: missing required property %q", at, name))
+		}
+
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			propAt := at + "." + name
+			if prop, ok := schema.Properties[name]; ok {
+				problems = append(problems, v.validateValue(prop, object[name], propAt, response)...)
+			} else if schema.AdditionalProperties != nil {
+				problems = append(problems, v.validateValue(schema.AdditionalProperties, object[name], propAt, response)...)
+			} else if schema.NoAdditionalProperties {
+				problems = append(problems, fmt.Sprintf("%s: unexpected property %q", at, name))
+			}
+		}
+
+	default:
+		problems = append(problems, fmt.Sprintf("%s: unsupported schema type %q", at, schema.Type))
+	}
+	return problems
+}
+
+// enumContains returns whether the decoded JSON value is one of the values of the enum.
+// The enum values are converted to JSON values first as YAML decodes numbers as ints.
+func enumContains(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		data, err := json.Marshal(allowed)
+		if err != nil {
+			continue
+		}
+		var decoded interface{}
+		json.Unmarshal(data, &decoded)
+		if reflect.DeepEqual(decoded, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func typeMismatch(at, expected string, value interface{}) string {
+	return fmt.Sprintf("%s: expected %s but got %s", at, expected, describeJSON(value))
+}
+
+// describeJSON describes a decoded JSON value for reporting.
+func describeJSON(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case float64:
+		return fmt.Sprintf("number %v", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,79 @@
+package mockapi
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWithOpenAPIValidation(t *testing.T) {
+	rt := &recordingT{}
+	m := NewMockAPI(rt, WithOpenAPIValidationFile("testdata/openapi-schemas.yaml"))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	m.WithJSONReply(NewMockRequest("GET", "/api/v1/nodes/42"), 200, map[string]interface{}{
+		"id":     42,
+		"status": "ready",
+		"labels": map[string]string{"zone": "a"},
+	})
+	m.WithJSONReply(NewMockRequest("GET", "/api/v1/nodes/{id}"), 200, map[string]interface{}{
+		"id":     1.5,
+		"status": "gone",
+	})
+	m.WithJSONReply(NewMockRequest("POST", "/api/v1/nodes").WithBody(mock.Anything), 418, map[string]int{"node-1": 1})
+	m.WithNoResponseBody(NewMockRequest("DELETE", "/api/v1/nodes/1"), 204)
+
+	do := func(method, path, body string) {
+		req, err := http.NewRequest(method, fmt.Sprintf("%s%s", m.URL(), path), bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Error issuing %s of %s: %v", method, path, err)
+		}
+		resp.Body.Close()
+	}
+
+	do("GET", "/api/v1/nodes/42", "")
+	if errs := rt.Errors(); len(errs) != 0 {
+		t.Fatalf("Expected no violations of the spec but got %v", errs)
+	}
+
+	do("GET", "/api/v1/nodes/abc", "")
+	do("POST", "/api/v1/nodes", `[{"id": 1}]`)
+	do("DELETE", "/api/v1/nodes/1", "")
+	m.Close()
+
+	expected := []string{
+		"request GET /api/v1/nodes/abc violates the OpenAPI spec:\n\tpath param \"id\": expected integer but got string \"abc\"",
+		"response 200 to GET /api/v1/nodes/abc violates the OpenAPI spec:\n" +
+			"\tresponse body.id: expected integer but got number 1.5\n" +
+			"\tresponse body.status: string \"gone\" is not one of the allowed values",
+		"request POST /api/v1/nodes violates the OpenAPI spec:\n\trequest body[0]: missing required property \"status\"",
+		"response 418 to POST /api/v1/nodes violates the OpenAPI spec:\n\tstatus 418 is not documented for POST /v1/nodes",
+		"request DELETE /api/v1/nodes/1 violates the OpenAPI spec:\n\tmethod DELETE is not allowed for path /v1/nodes/{id}",
+	}
+
+	errs := rt.Errors()
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d violations of the spec but got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, err := range errs {
+		if !strings.Contains(err, expected[i]) {
+			t.Fatalf("Expected violation %q but got %q", expected[i], err)
+		}
+	}
+}
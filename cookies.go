@@ -0,0 +1,21 @@
+package mockapi
+
+import (
+	"net/http"
+)
+
+// WithReplyCookies causes a Set-Cookie header to be added to the response for each of the
+// given cookies, including any attributes such as Path, Expires or HttpOnly. Cookies are
+// set before the responder runs so that they are sent whatever status or body it replies
+// with. Cookies which are invalid are silently dropped the same way http.SetCookie does.
+func (m *MockAPICall) WithReplyCookies(cookies []*http.Cookie) *MockAPICall {
+	m.wrap(func(next MockResponse) MockResponse {
+		return func(w http.ResponseWriter, r *http.Request) {
+			for _, cookie := range cookies {
+				http.SetCookie(w, cookie)
+			}
+			next(w, r)
+		}
+	})
+	return m
+}
@@ -0,0 +1,57 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+	"time"
+)
+
+func TestWithReplyCookies(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/login"), 204).Once().WithReplyCookies([]*http.Cookie{
+		{
+			Name:     "session",
+			Value:    "abc",
+			Path:     "/",
+			Expires:  time.Now().Add(time.Hour),
+			HttpOnly: true,
+		},
+	})
+	m.WithNoResponseBody(NewMockRequest("GET", "/profile").WithHeaders(map[string]string{
+		"Cookie": "session=abc",
+	}), 200).Once()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("Error creating cookie jar: %v", err)
+	}
+	client := &http.Client{Jar: jar}
+
+	resp, err := client.Get(fmt.Sprintf("%s/login", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /login: %v", err)
+	}
+	resp.Body.Close()
+
+	cookies := resp.Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "session" || !cookies[0].HttpOnly || cookies[0].Path != "/" {
+		t.Fatalf("Unexpected cookies: %v", cookies)
+	}
+
+	resp, err = client.Get(fmt.Sprintf("%s/profile", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /profile: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Unexpected status code %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,54 @@
+package mockapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+type requestIDKey struct{}
+
+// RequestID returns the correlation ID the MockAPI assigned to the request. The IDs
+// are of the form "req-<n>" where n counts the requests received by the MockAPI,
+// starting at 1. The ID is also part of the journal, captured requests, recorded
+// calls and failure messages so that a failure can be traced to the exact request.
+// An empty string is returned for requests not received by a MockAPI.
+func RequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithCorrelationHeader configures the MockAPI to echo the correlation ID of every
+// request back in the named response header.
+func WithCorrelationHeader(name string) Option {
+	return func(m *MockAPI) error {
+		m.correlationHeader = http.CanonicalHeaderKey(name)
+		return nil
+	}
+}
+
+// WithRequestLog configures the MockAPI to log every request it receives, along with
+// its correlation ID and the expectation it matched, to the TestingT.
+func WithRequestLog() Option {
+	return func(m *MockAPI) error {
+		m.requestLog = true
+		return nil
+	}
+}
+
+// withRequestID assigns the next correlation ID to the request.
+func (m *MockAPI) withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := "req-" + strconv.FormatUint(atomic.AddUint64(&m.requestCount, 1), 10)
+	if m.correlationHeader != "" {
+		w.Header().Set(m.correlationHeader, id)
+	}
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+}
+
+// logRequest logs the outcome of a request when request logging is enabled.
+func (m *MockAPI) logRequest(r *http.Request, outcome string) {
+	if m.requestLog && m.t != nil {
+		m.t.Logf("mockapi: [%s] %s %s %s", RequestID(r), r.Method, r.URL.RequestURI(), outcome)
+	}
+}
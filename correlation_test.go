@@ -0,0 +1,47 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCorrelationIDs(t *testing.T) {
+	rt := &recordingT{}
+	m := NewMockAPI(rt, WithCorrelationHeader("X-Request-ID"), WithRequestJournal())
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	var seen string
+	call := m.WithRequest(NewMockRequest("GET", "/first"), func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r)
+		w.WriteHeader(200)
+	}).Once()
+
+	var ids []string
+	for _, path := range []string{"/first", "/second"} {
+		resp, err := http.Get(fmt.Sprintf("%s%s", m.URL(), path))
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", path, err)
+		}
+		resp.Body.Close()
+		ids = append(ids, resp.Header.Get("X-Request-ID"))
+	}
+	m.Close()
+
+	if ids[0] != "req-1" || ids[1] != "req-2" || seen != "req-1" {
+		t.Fatalf("Unexpected correlation IDs: %v seen by the responder: %q", ids, seen)
+	}
+	if reqs := call.Requests(); len(reqs) != 1 || reqs[0].ID() != "req-1" {
+		t.Fatalf("Expected the captured request to have the correlation ID")
+	}
+	if journal := m.Journal(); len(journal) != 2 || journal[1].ID != "req-2" {
+		t.Fatalf("Expected the journal to have the correlation IDs: %+v", journal)
+	}
+	if errs := rt.Errors(); len(errs) != 1 || !strings.Contains(errs[0], "unexpected request req-2") {
+		t.Fatalf("Expected the failure to include the correlation ID but got: %v", errs)
+	}
+}
@@ -0,0 +1,340 @@
+package mockapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ParseCurl converts a curl command line, as copied from browser devtools or
+// documentation, into a MockRequest expecting the same method, path, headers,
+// query params and body. Only the options affecting the request are understood
+// with others being ignored. A JSON object body will be matched against a JSON
+// request body while any other body is matched against the raw body.
+func ParseCurl(command string) (*MockRequest, error) {
+	args, err := splitShellWords(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 && (args[0] == "curl" || strings.HasSuffix(args[0], "/curl")) {
+		args = args[1:]
+	}
+
+	var (
+		method  string
+		rawURL  string
+		get     bool
+		data    []string
+		headers = make(map[string]string)
+	)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// split --opt=value into the option and its value
+		var value *string
+		if strings.HasPrefix(arg, "--") {
+			if idx := strings.Index(arg, "="); idx > 0 {
+				v := arg[idx+1:]
+				arg, value = arg[:idx], &v
+			}
+		} else if len(arg) > 2 && arg[0] == '-' && curlOptionsWithValue[arg[:2]] {
+			v := arg[2:]
+			arg, value = arg[:2], &v
+		}
+
+		next := func() (string, error) {
+			if value != nil {
+				return *value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("curl option %s is missing its value", arg)
+			}
+			i++
+			return args[i], nil
+		}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			rawURL = arg
+			continue
+		}
+
+		switch arg {
+		case "-X", "--request":
+			if method, err = next(); err != nil {
+				return nil, err
+			}
+		case "-H", "--header":
+			hdr, err := next()
+			if err != nil {
+				return nil, err
+			}
+			parts := strings.SplitN(hdr, ":", 2)
+			if len(parts) == 2 {
+				headers[http.CanonicalHeaderKey(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1])
+			}
+		case "-d", "--data", "--data-raw", "--data-binary", "--data-ascii", "--data-urlencode":
+			d, err := next()
+			if err != nil {
+				return nil, err
+			}
+			if arg == "--data-urlencode" {
+				d = curlURLEncode(d)
+			}
+			data = append(data, d)
+		case "--json":
+			d, err := next()
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, d)
+			headers["Content-Type"] = "application/json"
+			headers["Accept"] = "application/json"
+		case "-u", "--user":
+			user, err := next()
+			if err != nil {
+				return nil, err
+			}
+			headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(user))
+		case "-A", "--user-agent":
+			if headers["User-Agent"], err = next(); err != nil {
+				return nil, err
+			}
+		case "-e", "--referer":
+			if headers["Referer"], err = next(); err != nil {
+				return nil, err
+			}
+		case "-b", "--cookie":
+			if headers["Cookie"], err = next(); err != nil {
+				return nil, err
+			}
+		case "--url":
+			if rawURL, err = next(); err != nil {
+				return nil, err
+			}
+		case "-G", "--get":
+			get = true
+		case "-I", "--head":
+			method = http.MethodHead
+		default:
+			if curlOptionsWithValue[arg] {
+				if _, err := next(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if rawURL == "" {
+		return nil, fmt.Errorf("curl command has no URL")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+
+	body := strings.Join(data, "&")
+	if get && body != "" {
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += body
+		body = ""
+	}
+
+	if method == "" {
+		method = http.MethodGet
+		if body != "" {
+			method = http.MethodPost
+		}
+	}
+	if body != "" {
+		if _, ok := headers["Content-Type"]; !ok {
+			// curl sends data as a form unless told otherwise
+			headers["Content-Type"] = "application/x-www-form-urlencoded"
+		}
+	}
+
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	req := NewMockRequest(strings.ToUpper(method), path)
+	if len(headers) > 0 {
+		req.WithHeaders(headers)
+	}
+	if query := firstValues(u.Query(), nil); query != nil {
+		req.WithQueryParams(query)
+	}
+	switch b := recordedRequestBody(headers["Content-Type"], body).(type) {
+	case map[string]interface{}:
+		req.WithBody(b)
+	case url.Values:
+		req.WithFormBody(b)
+	case string:
+		req.WithBody([]byte(b))
+	}
+	return req, nil
+}
+
+// curlOptionsWithValue are the curl short and long options which take a value.
+// It is used to skip the values of options which are otherwise ignored.
+var curlOptionsWithValue = map[string]bool{
+	"-X":                true,
+	"--request":         true,
+	"-H":                true,
+	"--header":          true,
+	"-d":                true,
+	"--data":            true,
+	"--data-raw":        true,
+	"--data-binary":     true,
+	"--data-ascii":      true,
+	"--data-urlencode":  true,
+	"--json":            true,
+	"-u":                true,
+	"--user":            true,
+	"-A":                true,
+	"--user-agent":      true,
+	"-e":                true,
+	"--referer":         true,
+	"-b":                true,
+	"--cookie":          true,
+	"--url":             true,
+	"-o":                true,
+	"--output":          true,
+	"-c":                true,
+	"--cookie-jar":      true,
+	"-m":                true,
+	"--max-time":        true,
+	"--connect-timeout": true,
+	"-x":                true,
+	"--proxy":           true,
+	"-w":                true,
+	"--write-out":       true,
+	"--cacert":          true,
+	"--cert":            true,
+	"--key":             true,
+	"-F":                true,
+	"--form":            true,
+	"--retry":           true,
+	"--resolve":         true,
+}
+
+// curlURLEncode encodes a --data-urlencode value the way curl does.
+func curlURLEncode(d string) string {
+	if idx := strings.Index(d, "="); idx >= 0 {
+		return d[:idx+1] + url.QueryEscape(d[idx+1:])
+	}
+	return url.QueryEscape(d)
+}
+
+// splitShellWords splits a command line into its arguments following the
+// POSIX shell quoting rules. Backslash-newline line continuations are removed.
+func splitShellWords(s string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, c := range s {
+		switch {
+		case escaped:
+			if c != '\n' {
+				word.WriteRune(c)
+				inWord = true
+			}
+			escaped = false
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case quote == '"':
+			switch c {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				word.WriteRune(c)
+			}
+		case c == '\\':
+			escaped = true
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in command", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// CurlCommand renders the request as a curl command which can be copied into a
+// shell to reproduce it. The body is passed separately as the request's body
+// has usually already been consumed.
+func CurlCommand(r *http.Request, body []byte) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	u := url.URL{Scheme: scheme, Host: r.Host, Path: r.URL.Path, RawQuery: r.URL.RawQuery}
+
+	var sb strings.Builder
+	sb.WriteString("curl")
+	if r.Method != http.MethodGet || len(body) > 0 {
+		fmt.Fprintf(&sb, " -X %s", r.Method)
+	}
+
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		if name != "Content-Length" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range r.Header[name] {
+			fmt.Fprintf(&sb, " -H %s", shellQuote(name+": "+value))
+		}
+	}
+
+	if len(body) > 0 {
+		fmt.Fprintf(&sb, " --data-binary %s", shellQuote(string(body)))
+	}
+	fmt.Fprintf(&sb, " %s", shellQuote(u.String()))
+	return sb.String()
+}
+
+// shellQuote single quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// Curl renders the request as a curl command which can be copied into a shell
+// to reproduce it.
+func (c *CapturedRequest) Curl() string {
+	return CurlCommand(c.request, c.body)
+}
@@ -0,0 +1,75 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseCurl(t *testing.T) {
+	req, err := ParseCurl(`curl 'https://api.example.com/v1/nodes?region=east' \
+  -X PUT \
+  -H 'Content-Type: application/json' \
+  -H "X-Token: it's \"secret\"" \
+  --data-raw '{"name": "node-1"}' --compressed -s`)
+	if err != nil {
+		t.Fatalf("Error parsing curl command: %v", err)
+	}
+
+	if req.method != "PUT" || req.path != "/v1/nodes" {
+		t.Fatalf("Unexpected request: %s %s", req.method, req.path)
+	}
+	if req.headers.Get("Content-Type") != "application/json" || req.headers.Get("X-Token") != `it's "secret"` {
+		t.Fatalf("Unexpected headers: %v", req.headers)
+	}
+	if req.queryParams.Get("region") != "east" {
+		t.Fatalf("Unexpected query params: %v", req.queryParams)
+	}
+	if body, ok := req.body.(map[string]interface{}); !ok || body["name"] != "node-1" {
+		t.Fatalf("Unexpected body: %#v", req.body)
+	}
+
+	req, err = ParseCurl(`curl -G -d limit=10 -u admin:secret https://api.example.com/v1/nodes`)
+	if err != nil {
+		t.Fatalf("Error parsing curl command: %v", err)
+	}
+	if req.method != "GET" || req.queryParams.Get("limit") != "10" || req.body != nil || req.headers.Get("Authorization") != "Basic YWRtaW46c2VjcmV0" {
+		t.Fatalf("Unexpected request: %s %v %v %v", req.method, req.queryParams, req.headers, req.body)
+	}
+}
+
+func TestCapturedRequestCurl(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Content-Length",
+		"User-Agent",
+	})
+
+	call := m.WithNoResponseBody(NewMockRequest("POST", "/resource").
+		WithHeaders(map[string]string{"Content-Type": "text/plain"}).
+		WithQueryParams(map[string]string{"q": "it's"}).
+		WithBody([]byte("some text")), 200).Once()
+
+	resp, err := http.Post(fmt.Sprintf("%s/resource?q=it%%27s", m.URL()), "text/plain", strings.NewReader("some text"))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /resource: %v", err)
+	}
+	resp.Body.Close()
+
+	reqs := call.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("Expected one captured request but got %d", len(reqs))
+	}
+
+	// the rendered command parses back into the same expectation
+	req, err := ParseCurl(reqs[0].Curl())
+	if err != nil {
+		t.Fatalf("Error parsing rendered curl command %q: %v", reqs[0].Curl(), err)
+	}
+	if req.method != "POST" || req.path != "/resource" || req.queryParams.Get("q") != "it's" ||
+		req.headers.Get("Content-Type") != "text/plain" || string(req.body.([]byte)) != "some text" {
+		t.Fatalf("Unexpected request parsed from %q", reqs[0].Curl())
+	}
+}
@@ -0,0 +1,141 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// defaultRoute is a default handler for requests with the given method, or any
+// method when empty, and path prefix.
+type defaultRoute struct {
+	method string
+	prefix string
+	call   *MockAPICall
+}
+
+// DefaultHandler registers a handler for all requests which do not match any
+// expectation. Such requests are not considered to be unexpected. It is equivalent
+// to DefaultRoute("", "/", response).
+func (m *MockAPI) DefaultHandler(response func(http.ResponseWriter, *http.Request)) *MockAPICall {
+	return m.DefaultRoute("", "/", response)
+}
+
+// DefaultRoute registers a handler for requests with the given method and a path
+// starting with prefix which do not match any expectation. An empty method handles
+// requests of any method. Such requests are not considered to be unexpected.
+//
+// The default route with the longest matching prefix is used, preferring those for
+// the request's method over those for any method. When routes exist for the longest
+// matching prefix but none of them handle the request's method, a 405 Method Not
+// Allowed reply is sent.
+//
+// Default routes may be used any number of times unless restricted through the
+// returned call, for example with Once, after which further requests are treated
+// as unexpected. Default routes are never required to be used.
+func (m *MockAPI) DefaultRoute(method, prefix string, response MockResponse) *MockAPICall {
+	call := newMockAPICall(m, nil, response, func(*MockAPICall) *mock.Call {
+		return m.defaults.On("Default", method, prefix).Maybe()
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaultRoutes = append(m.defaultRoutes, defaultRoute{method: method, prefix: prefix, call: call})
+	return call
+}
+
+// DefaultGET registers a default route for GET requests. See DefaultRoute.
+func (m *MockAPI) DefaultGET(prefix string, response MockResponse) *MockAPICall {
+	return m.DefaultRoute(http.MethodGet, prefix, response)
+}
+
+// DefaultPOST registers a default route for POST requests. See DefaultRoute.
+func (m *MockAPI) DefaultPOST(prefix string, response MockResponse) *MockAPICall {
+	return m.DefaultRoute(http.MethodPost, prefix, response)
+}
+
+// DefaultPUT registers a default route for PUT requests. See DefaultRoute.
+func (m *MockAPI) DefaultPUT(prefix string, response MockResponse) *MockAPICall {
+	return m.DefaultRoute(http.MethodPut, prefix, response)
+}
+
+// DefaultPATCH registers a default route for PATCH requests. See DefaultRoute.
+func (m *MockAPI) DefaultPATCH(prefix string, response MockResponse) *MockAPICall {
+	return m.DefaultRoute(http.MethodPatch, prefix, response)
+}
+
+// DefaultDELETE registers a default route for DELETE requests. See DefaultRoute.
+func (m *MockAPI) DefaultDELETE(prefix string, response MockResponse) *MockAPICall {
+	return m.DefaultRoute(http.MethodDelete, prefix, response)
+}
+
+// serveDefault serves a request which did not match any expectation using the
+// default routes. It returns false when no default route handles the request.
+func (m *MockAPI) serveDefault(w http.ResponseWriter, r *http.Request, bodyBytes []byte) bool {
+	m.mu.Lock()
+	routes := m.defaultRoutes
+	m.mu.Unlock()
+
+	// find the routes with the longest matching prefix
+	var candidates []defaultRoute
+	for _, route := range routes {
+		if !strings.HasPrefix(r.URL.Path, route.prefix) {
+			continue
+		}
+		if len(candidates) > 0 && len(route.prefix) < len(candidates[0].prefix) {
+			continue
+		}
+		if len(candidates) > 0 && len(route.prefix) > len(candidates[0].prefix) {
+			candidates = nil
+		}
+		candidates = append(candidates, route)
+	}
+	if len(candidates) == 0 {
+		return false
+	}
+
+	var route *defaultRoute
+	var allowed []string
+	for i, candidate := range candidates {
+		switch candidate.method {
+		case r.Method:
+			if route == nil || route.method == "" {
+				route = &candidates[i]
+			}
+		case "":
+			if route == nil {
+				route = &candidates[i]
+			}
+		default:
+			allowed = append(allowed, candidate.method)
+		}
+	}
+
+	if route == nil {
+		sort.Strings(allowed)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		http.Error(w, fmt.Sprintf("method %s is not allowed for %s", r.Method, r.URL.Path), http.StatusMethodNotAllowed)
+		return true
+	}
+
+	ret, ok := m.defaultCalled(route.method, route.prefix)
+	if !ok {
+		return false
+	}
+	m.respond(w, r, bodyBytes, ret)
+	return true
+}
+
+// defaultCalled records the use of the default route. It returns false when the
+// route has already been used as many times as it may be.
+func (m *MockAPI) defaultCalled(method, prefix string) (ret mock.Arguments, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+		}
+	}()
+	return m.defaults.MethodCalled("Default", method, prefix), true
+}
@@ -0,0 +1,62 @@
+package mockapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDefaultRoutes(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Content-Length",
+		"Content-Type",
+		"User-Agent",
+	})
+
+	reply := func(status int, body string) MockResponse {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}
+	}
+
+	m.WithTextReply(NewMockRequest("GET", "/v1/nodes/abc"), 200, "node").Once()
+	m.DefaultHandler(reply(404, "global"))
+	m.DefaultGET("/v1/", reply(404, "v1 get"))
+	m.DefaultPOST("/v1/", reply(404, "v1 post")).Once()
+	m.DefaultRoute("", "/v2/", reply(404, "v2"))
+
+	cases := []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{"GET", "/v1/nodes/abc", 200, "node"},
+		{"GET", "/v1/nodes/def", 404, "v1 get"},
+		{"POST", "/v1/nodes", 404, "v1 post"},
+		{"DELETE", "/v1/nodes/abc", 405, "method DELETE is not allowed for /v1/nodes/abc\n"},
+		{"PUT", "/v2/nodes", 404, "v2"},
+		{"GET", "/other", 404, "global"},
+	}
+
+	for _, tcase := range cases {
+		req, _ := http.NewRequest(tcase.method, fmt.Sprintf("%s%s", m.URL(), tcase.path), strings.NewReader(""))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Error issuing %s of %s: %v", tcase.method, tcase.path, err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != tcase.status || string(body) != tcase.body {
+			t.Fatalf("Unexpected response for %s %s: %d %q", tcase.method, tcase.path, resp.StatusCode, body)
+		}
+		if resp.StatusCode == 405 && resp.Header.Get("Allow") != "GET, POST" {
+			t.Fatalf("Unexpected Allow header: %q", resp.Header.Get("Allow"))
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package mockapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/mock"
+)
+
+// Differ renders the difference between an expected and actual request body.
+type Differ interface {
+	Diff(expected, actual interface{}) string
+}
+
+// DifferFunc is a function implementing the Differ interface.
+type DifferFunc func(expected, actual interface{}) string
+
+// Diff calls f(expected, actual).
+func (f DifferFunc) Diff(expected, actual interface{}) string {
+	return f(expected, actual)
+}
+
+// CmpDiffer returns a Differ using go-cmp with the given options. The options can
+// be used to ignore fields, sort slices or compare protobuf messages.
+func CmpDiffer(opts ...cmp.Option) Differ {
+	return DifferFunc(func(expected, actual interface{}) string {
+		return cmp.Diff(expected, actual, opts...)
+	})
+}
+
+// WithBodyDiffer configures the MockAPI to append a diff rendered by d to the failure for
+// an unexpected request, for every expectation with the same method and path but a
+// different body. Without a differ only testify's closest call diff is reported.
+func WithBodyDiffer(d Differ) Option {
+	return func(m *MockAPI) error {
+		m.differ = d
+		return nil
+	}
+}
+
+// bodyDiffs renders the body differences between the received request and every
+// expectation with the same method and path. Expectations which render their own
+// body differences, such as for protobuf messages, are included even without a differ.
+func (m *MockAPI) bodyDiffs(method, path string, body interface{}, bodyBytes []byte) string {
+	m.mu.Lock()
+	calls := make([]*MockAPICall, len(m.calls))
+	copy(calls, m.calls)
+	m.mu.Unlock()
+
+	var sb strings.Builder
+	for _, call := range calls {
+		call.mu.Lock()
+		disabled := call.disabled
+		call.mu.Unlock()
+
+		req := call.req
+		if disabled || req == nil || req.method != method || !req.matchesPath(path) {
+			continue
+		}
+
+		if req.bodyDiff != nil {
+			if diff := req.bodyDiff(bodyBytes); diff != "" {
+				fmt.Fprintf(&sb, "\n\nbody mismatch for %s (-expected +actual):\n%s", call, diff)
+			}
+			continue
+		}
+
+		if m.differ == nil || isArgumentMatcher(req.body) {
+			continue
+		}
+
+		if _, differences := (mock.Arguments{req.body}).Diff([]interface{}{body}); differences == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "\n\nbody mismatch for %s (-expected +actual):\n%s", call, m.differ.Diff(req.body, body))
+	}
+	return sb.String()
+}
+
+// isArgumentMatcher returns whether the expected value is one of testify's
+// argument matchers rather than a concrete value that could be diffed.
+func isArgumentMatcher(v interface{}) bool {
+	if s, ok := v.(string); ok {
+		return s == mock.Anything
+	}
+	typ := strings.TrimPrefix(fmt.Sprintf("%T", v), "*")
+	return strings.HasPrefix(typ, "mock.")
+}
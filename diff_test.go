@@ -0,0 +1,41 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestWithBodyDiffer(t *testing.T) {
+	rt := &recordingT{}
+	m := NewMockAPI(rt, WithBodyDiffer(CmpDiffer(cmpopts.EquateEmpty())))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("POST", "/resource").WithBody(map[string]interface{}{
+		"name": "abc",
+		"size": float64(1),
+	}), 200).Maybe()
+
+	resp, err := http.Post(fmt.Sprintf("%s/resource", m.URL()), "application/json", strings.NewReader(`{"name":"abc","size":2}`))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /resource: %v", err)
+	}
+	resp.Body.Close()
+	m.Close()
+
+	errs := rt.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("Expected exactly one failure but got: %v", errs)
+	}
+	if !strings.Contains(errs[0], "body mismatch for") || !strings.Contains(errs[0], `"size"`) {
+		t.Fatalf("Expected the failure to include the body diff but got: %s", errs[0])
+	}
+}
@@ -1,5 +1,9 @@
 package mockapi
 
+import (
+	"encoding/json"
+)
+
 type BodyFormat string
 
 const (
@@ -12,6 +16,7 @@ const (
 type ResponseFormat string
 
 const (
+	ResponseFormatNone   ResponseFormat = "none"
 	ResponseFormatJSON   ResponseFormat = "json"
 	ResponseFormatString ResponseFormat = "string"
 	ResponseFormatStream ResponseFormat = "stream"
@@ -33,6 +38,9 @@ type Endpoint struct {
 
 	// PathParameters are the parameters required to be in the path
 	PathParameters []string
+	// PathParameterTypes are the golang types of the PathParameters in the
+	// same order. Parameters without a type are strings.
+	PathParameterTypes []string `json:",omitempty"`
 	// ResponseFormat is the format of Response that helpers should
 	ResponseFormat ResponseFormat
 	// ResponseType is the golang type of the Response
@@ -45,4 +53,20 @@ type Endpoint struct {
 	// query params which may be present and so the params should be part
 	// of the expectation
 	QueryParams bool
+	// ResponseExamples are example JSON response bodies keyed by status code
+	ResponseExamples map[string]json.RawMessage
+	// DefaultStatus, when set, is the status code used for replies instead of
+	// the helpers taking one as an argument
+	DefaultStatus int
+	// Responses are the documented responses keyed by status code. A helper
+	// replying with the status code is generated for each of them.
+	Responses map[string]EndpointResponse `json:",omitempty"`
+}
+
+// EndpointResponse is a documented response of an Endpoint.
+type EndpointResponse struct {
+	// Format is the format of the response body
+	Format ResponseFormat
+	// Type is the golang type of the response body
+	Type string `json:",omitempty"`
 }
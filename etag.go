@@ -0,0 +1,121 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// VersionedResource simulates a resource which uses ETags for optimistic concurrency
+// control. Its Read and Write methods are MockResponse functions which can be used
+// with MockAPI.WithRequest for the expectations of reading and writing the resource.
+// Writes carrying an If-Match header with a stale ETag are rejected with a 412 status
+// code while those with the current ETag replace the resource and reply with the new ETag.
+type VersionedResource struct {
+	// RequireIfMatch causes writes without an If-Match header to be rejected
+	// with a 428 status code.
+	RequireIfMatch bool
+
+	mu      sync.Mutex
+	version int
+	body    interface{}
+}
+
+// NewVersionedResource creates a VersionedResource with the initial body. The body
+// will be JSON encoded when replying to reads and writes.
+func NewVersionedResource(initial interface{}) *VersionedResource {
+	return &VersionedResource{version: 1, body: initial}
+}
+
+// ETag returns the current ETag of the resource.
+func (v *VersionedResource) ETag() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.etag()
+}
+
+func (v *VersionedResource) etag() string {
+	return fmt.Sprintf(`"%d"`, v.version)
+}
+
+// Version returns the current version of the resource. It starts at 1 and is incremented
+// with every successful write.
+func (v *VersionedResource) Version() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.version
+}
+
+// Update replaces the body of the resource and increments its version as if it had been
+// written by another client. This is useful for forcing a conflict for the client under test.
+func (v *VersionedResource) Update(body interface{}) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.body = body
+	v.version++
+}
+
+// Read replies with the current body and ETag of the resource. If the request has an
+// If-None-Match header with the current ETag then a 304 status code is sent instead.
+func (v *VersionedResource) Read(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	etag := v.etag()
+	body := v.body
+	v.mu.Unlock()
+
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writeVersionedBody(w, body)
+}
+
+// Write replaces the body of the resource with the JSON decoded request body and replies
+// with the new body and ETag. If the request has an If-Match header which does not match
+// the current ETag then the resource is left unmodified and a 412 status code is sent.
+func (v *VersionedResource) Write(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v.mu.Lock()
+	ifMatch := r.Header.Get("If-Match")
+	switch {
+	case ifMatch == "" && v.RequireIfMatch:
+		v.mu.Unlock()
+		w.WriteHeader(http.StatusPreconditionRequired)
+		return
+	case ifMatch != "" && ifMatch != "*" && ifMatch != v.etag():
+		etag := v.etag()
+		v.mu.Unlock()
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	v.body = body
+	v.version++
+	etag := v.etag()
+	v.mu.Unlock()
+
+	w.Header().Set("ETag", etag)
+	writeVersionedBody(w, body)
+}
+
+func writeVersionedBody(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body)
+}
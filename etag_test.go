@@ -0,0 +1,53 @@
+package mockapi
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestVersionedResource(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"If-Match",
+	})
+
+	res := NewVersionedResource(map[string]interface{}{"name": "abc"})
+	m.WithRequest(NewMockRequest("PUT", "/resource").WithBody(mock.Anything), res.Write).Twice()
+
+	put := func(etag string) *http.Response {
+		req, err := http.NewRequest("PUT", fmt.Sprintf("%s/resource", m.URL()), bytes.NewBufferString(`{"name":"def"}`))
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		req.Header.Set("If-Match", etag)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Error issuing PUT of /resource: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	etag := res.ETag()
+	res.Update(map[string]interface{}{"name": "changed"})
+
+	if resp := put(etag); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("Expected a 412 status code but got %d", resp.StatusCode)
+	}
+
+	resp := put(res.ETag())
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected a 200 status code but got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("ETag") != `"3"` || res.Version() != 3 {
+		t.Fatalf("Expected the resource version to be incremented: %s", resp.Header.Get("ETag"))
+	}
+}
@@ -0,0 +1,53 @@
+package mockapi
+
+import (
+	"io"
+)
+
+// PendingCall is an expectation for an API call which has not yet had its
+// response configured. It is created with MockAPI.Expect and the expectation
+// is only registered once one of the Respond* methods is called. This allows
+// the request and response halves of an expectation to be built independently
+// of each other.
+type PendingCall struct {
+	api *MockAPI
+	req *MockRequest
+}
+
+// Expect begins setting up an expectation for an API call to be made. No expectation
+// is registered until one of the Respond* methods is called on the returned
+// PendingCall.
+func (m *MockAPI) Expect(req *MockRequest) *PendingCall {
+	return &PendingCall{api: m, req: req}
+}
+
+// RespondWith completes the expectation using the supplied response function. This
+// is equivalent to calling MockAPI.WithRequest.
+func (p *PendingCall) RespondWith(resp MockResponse) *MockAPICall {
+	return p.api.WithRequest(p.req, resp)
+}
+
+// RespondNoBody completes the expectation with a reply having the given status code
+// and no body. This is equivalent to calling MockAPI.WithNoResponseBody.
+func (p *PendingCall) RespondNoBody(status int) *MockAPICall {
+	return p.api.WithNoResponseBody(p.req, status)
+}
+
+// RespondJSON completes the expectation with a reply having the given status code
+// and JSON encoded body. This is equivalent to calling MockAPI.WithJSONReply.
+func (p *PendingCall) RespondJSON(status int, reply interface{}) *MockAPICall {
+	return p.api.WithJSONReply(p.req, status, reply)
+}
+
+// RespondText completes the expectation with a reply having the given status code
+// and text body. This is equivalent to calling MockAPI.WithTextReply.
+func (p *PendingCall) RespondText(status int, reply string) *MockAPICall {
+	return p.api.WithTextReply(p.req, status, reply)
+}
+
+// RespondStream completes the expectation with a reply having the given status code
+// and the contents of the reader as its body. This is equivalent to calling
+// MockAPI.WithStreamingReply.
+func (p *PendingCall) RespondStream(status int, reply io.Reader) *MockAPICall {
+	return p.api.WithStreamingReply(p.req, status, reply)
+}
@@ -0,0 +1,38 @@
+package mockapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestExpectRespond(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	pending := m.Expect(NewMockRequest("GET", "/my/endpoint"))
+	pending.RespondText(201, "hello").Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /my/endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 201 {
+		t.Fatalf("Expected a 201 status code but got %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Error reading response: %v", err)
+	}
+
+	if string(body) != "hello" {
+		t.Fatalf("Didn't get the expected response: %q", body)
+	}
+}
@@ -0,0 +1,235 @@
+package mockapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Fault is a failure which can be injected into the handling of a request. It is
+// given the responder which would otherwise have been used and returns the responder
+// to use in its place. Some faults replace the response entirely while others alter
+// how or when the original response is sent.
+type Fault func(next MockResponse) MockResponse
+
+// StatusFault replaces the response with an empty one having the given status code.
+func StatusFault(status int) Fault {
+	return func(next MockResponse) MockResponse {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}
+	}
+}
+
+// LatencyFault delays sending the response by the given duration.
+func LatencyFault(d time.Duration) Fault {
+	return func(next MockResponse) MockResponse {
+		return func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(d):
+			case <-r.Context().Done():
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// ResetFault replaces the response with the underlying TCP connection being reset.
+func ResetFault() Fault {
+	return func(next MockResponse) MockResponse {
+		return resetConnection
+	}
+}
+
+// DropFault replaces the response with the underlying connection being closed without
+// any response bytes being written.
+func DropFault() Fault {
+	return func(next MockResponse) MockResponse {
+		return dropConnection
+	}
+}
+
+// WithDroppedConnection will setup an expectation for an API call to be made. The request
+// is fully read but then the connection is closed without writing any of the response,
+// which clients will typically observe as an unexpected EOF.
+func (m *MockAPI) WithDroppedConnection(req *MockRequest) *MockAPICall {
+	return m.WithRequest(req, dropConnection)
+}
+
+// WithEmptyResponse will setup an expectation for an API call to be made. The connection
+// is closed before the status line and headers are written. It is the same as
+// WithDroppedConnection.
+func (m *MockAPI) WithEmptyResponse(req *MockRequest) *MockAPICall {
+	return m.WithDroppedConnection(req)
+}
+
+// WithConnectionReset will setup an expectation for an API call to be made. The TCP
+// connection is abruptly reset instead of a response being written, which clients will
+// typically observe as a "connection reset by peer" error.
+func (m *MockAPI) WithConnectionReset(req *MockRequest) *MockAPICall {
+	return m.WithRequest(req, resetConnection)
+}
+
+// WithTruncatedBody will setup an expectation for an API call to be made. The response has the
+// supplied status code and a Content-Length for the whole body but the connection is closed after
+// only the first n bytes of the body were written. See TruncatedBodyFault.
+func (m *MockAPI) WithTruncatedBody(req *MockRequest, status int, body []byte, n int) *MockAPICall {
+	return m.WithRequest(req, TruncatedBodyFault(n)(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write(body)
+	}))
+}
+
+// dropConnection closes the connection the request was received on without writing
+// any response. When the connection cannot be hijacked (such as with HTTP/2) the
+// response is aborted instead.
+func dropConnection(w http.ResponseWriter, r *http.Request) {
+	io.Copy(ioutil.Discard, r.Body)
+
+	conn := hijack(w)
+	conn.Close()
+}
+
+// resetConnection abruptly resets the connection the request was received on
+// without writing any response. When the connection cannot be hijacked (such as
+// with HTTP/2) the response is aborted instead.
+func resetConnection(w http.ResponseWriter, r *http.Request) {
+	conn := hijack(w)
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetLinger(0)
+	}
+	conn.Close()
+}
+
+// SlowHeadersFault sends the response as normal except that the status line and headers
+// are trickled out to the client a byte at a time over the given duration. The body is
+// then sent all at once and the connection closed. This allows testing client header
+// read timeouts separately from body read timeouts. Only HTTP/1.x connections are
+// supported, for others the response is aborted.
+func SlowHeadersFault(d time.Duration) Fault {
+	return func(next MockResponse) MockResponse {
+		return func(w http.ResponseWriter, r *http.Request) {
+			rec := httptest.NewRecorder()
+			next(rec, r)
+			result := rec.Result()
+			result.Header.Set("Connection", "close")
+
+			var raw bytes.Buffer
+			result.Write(&raw)
+			headerLen := bytes.Index(raw.Bytes(), []byte("\r\n\r\n")) + 4
+
+			conn := hijack(w)
+			defer conn.Close()
+
+			interval := d / time.Duration(headerLen)
+			for _, b := range raw.Next(headerLen) {
+				if _, err := conn.Write([]byte{b}); err != nil {
+					return
+				}
+				time.Sleep(interval)
+			}
+			conn.Write(raw.Bytes())
+		}
+	}
+}
+
+// TruncatedBodyFault sends the status line, the headers with a Content-Length for the whole
+// body and then only the first n bytes of the body before closing the connection. This allows
+// testing how clients handle connections failing part way through a response. Only HTTP/1.x
+// connections are supported, for others the response is aborted.
+func TruncatedBodyFault(n int) Fault {
+	return func(next MockResponse) MockResponse {
+		return func(w http.ResponseWriter, r *http.Request) {
+			rec := httptest.NewRecorder()
+			next(rec, r)
+			result := rec.Result()
+
+			body := rec.Body.Bytes()
+			truncated := body
+			if n < len(body) {
+				truncated = body[:n]
+			}
+
+			var raw bytes.Buffer
+			fmt.Fprintf(&raw, "HTTP/1.1 %s\r\n", result.Status)
+			result.Header.Del("Transfer-Encoding")
+			result.Header.Set("Content-Length", strconv.Itoa(len(body)))
+			result.Header.Write(&raw)
+			raw.WriteString("\r\n")
+			raw.Write(truncated)
+
+			conn := hijack(w)
+			defer conn.Close()
+			conn.Write(raw.Bytes())
+		}
+	}
+}
+
+// Inject will inject the fault into the handling of every request matching this call.
+func (m *MockAPICall) Inject(fault Fault) *MockAPICall {
+	m.wrap(func(next MockResponse) MockResponse {
+		return fault(next)
+	})
+	return m
+}
+
+// FailTimes causes the first n requests matching this call to be replied to with an empty
+// response having the given status code. Later requests are replied to as normal. Note
+// that the failed requests still count towards the number of times the call is expected
+// to be made so Times(n+1) would be used to expect a single successful request after
+// the failures.
+func (m *MockAPICall) FailTimes(n int, status int) *MockAPICall {
+	var mu sync.Mutex
+	failures := 0
+
+	m.wrap(func(next MockResponse) MockResponse {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			fail := failures < n
+			if fail {
+				failures++
+			}
+			mu.Unlock()
+
+			if fail {
+				w.WriteHeader(status)
+				return
+			}
+			next(w, r)
+		}
+	})
+	return m
+}
+
+// WithFlakyReply will setup an expectation for an API call to be made. The first failCount
+// matching requests are replied to with an empty response having the failStatus status
+// code and every later request is replied to by successReply. It is shorthand for calling
+// FailTimes on the expectation created by WithRequest. The number of successful requests
+// is not limited; use Times(failCount+1) to expect exactly one.
+func (m *MockAPI) WithFlakyReply(req *MockRequest, failStatus int, failCount int, successReply MockResponse) *MockAPICall {
+	return m.WithRequest(req, successReply).FailTimes(failCount, failStatus)
+}
+
+// hijack takes over the connection of the response. If that is not possible
+// then the handler is aborted by panicking with http.ErrAbortHandler which
+// causes the server to abort the response.
+func hijack(w http.ResponseWriter) net.Conn {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	return conn
+}
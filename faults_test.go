@@ -0,0 +1,192 @@
+package mockapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestChaos(t *testing.T) {
+	m := NewMockAPI(t, WithRandomSeed(42))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Connection",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/my/endpoint"), 200).Times(3)
+
+	// Disabling keep alives prevents the client from transparently retrying
+	// requests when a reused connection is reset.
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func() (*http.Response, error) {
+		resp, err := client.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+		if err == nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	m.Chaos(1, StatusFault(503))
+	if resp, err := get(); err != nil || resp.StatusCode != 503 {
+		t.Fatalf("Expected the status fault to be injected: %v", err)
+	}
+
+	m.Chaos(1, ResetFault())
+	if _, err := get(); err == nil {
+		t.Fatalf("Expected the connection to be reset")
+	}
+
+	m.Chaos(0)
+	if resp, err := get(); err != nil || resp.StatusCode != 200 {
+		t.Fatalf("Expected no fault to be injected: %v", err)
+	}
+}
+
+func TestChaosInvalidRate(t *testing.T) {
+	for _, rate := range []float64{-0.5, 1.5} {
+		rt := &recordingT{}
+		m := NewMockAPI(rt)
+		m.Chaos(rate, StatusFault(503))
+		m.Close()
+
+		if len(rt.errors) != 1 {
+			t.Fatalf("Expected a failure for the chaos rate %v but got: %v", rate, rt.errors)
+		}
+		if m.chaos != nil {
+			t.Fatalf("Expected chaos mode to remain disabled for the chaos rate %v", rate)
+		}
+	}
+}
+
+func TestWithDroppedConnection(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Connection",
+	})
+
+	m.WithDroppedConnection(NewMockRequest("GET", "/my/endpoint")).Once()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	_, err := client.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+	if err == nil {
+		t.Fatalf("Expected the connection to be dropped")
+	}
+}
+
+func TestSlowHeadersFault(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Connection",
+	})
+
+	m.WithTextReply(NewMockRequest("GET", "/my/endpoint"), 200, "hello").Twice().Inject(SlowHeadersFault(time.Second))
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DisableKeepAlives:     true,
+			ResponseHeaderTimeout: 100 * time.Millisecond,
+		},
+	}
+	if _, err := client.Get(fmt.Sprintf("%s/my/endpoint", m.URL())); err == nil {
+		t.Fatalf("Expected the client to time out reading the headers")
+	}
+
+	client.Transport.(*http.Transport).ResponseHeaderTimeout = 5 * time.Second
+	resp, err := client.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /my/endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil || string(body) != "hello" {
+		t.Fatalf("Didn't get the expected response: %q %v", body, err)
+	}
+}
+
+func TestFailTimes(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithTextReply(NewMockRequest("GET", "/my/endpoint"), 200, "hello").Times(3).FailTimes(2, 503)
+
+	for i, expected := range []int{503, 503, 200} {
+		resp, err := http.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+		if err != nil {
+			t.Fatalf("Error issuing GET of /my/endpoint: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != expected {
+			t.Fatalf("Request %d: expected a %d status code but got %d", i, expected, resp.StatusCode)
+		}
+	}
+}
+
+func TestWithFlakyReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithFlakyReply(NewMockRequest("GET", "/my/endpoint"), 503, 2, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+
+	for i, expected := range []int{503, 503, 200, 200} {
+		resp, err := http.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+		if err != nil {
+			t.Fatalf("Error issuing GET of /my/endpoint: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != expected {
+			t.Fatalf("Request %d: expected a %d status code but got %d", i, expected, resp.StatusCode)
+		}
+	}
+}
+
+func TestConnectionFaults(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Connection",
+	})
+
+	m.WithConnectionReset(NewMockRequest("GET", "/reset")).Once()
+	m.WithEmptyResponse(NewMockRequest("GET", "/empty")).Once()
+	m.WithTruncatedBody(NewMockRequest("GET", "/truncated"), 200, []byte("hello world"), 5).Once()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	for _, path := range []string{"/reset", "/empty"} {
+		if _, err := client.Get(fmt.Sprintf("%s%s", m.URL(), path)); err == nil {
+			t.Fatalf("Expected the request to %s to fail", path)
+		}
+	}
+
+	resp, err := client.Get(fmt.Sprintf("%s/truncated", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /truncated: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 || resp.ContentLength != 11 {
+		t.Fatalf("Unexpected response: %d with a Content-Length of %d", resp.StatusCode, resp.ContentLength)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err == nil || string(body) != "hello" {
+		t.Fatalf("Expected the body to be truncated after 5 bytes but got %q: %v", body, err)
+	}
+}
@@ -0,0 +1,269 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture is a declarative set of expectations. Fixtures are usually stored as JSON
+// files so that the same mock definitions can be shared between Go tests (via
+// MockAPI.LoadFixtureFile) and other tooling such as the mock-http-server command.
+//
+// An example fixture file looks like:
+//
+//	{
+//	  "Expectations": [
+//	    {
+//	      "Request": {
+//	        "Method": "PUT",
+//	        "Path": "/resource/abc",
+//	        "Headers": {"Content-Type": "application/json"},
+//	        "Body": {"name": "abc"}
+//	      },
+//	      "Response": {
+//	        "Status": 200,
+//	        "JSON": {"name": "abc", "created": true}
+//	      },
+//	      "Times": 1
+//	    }
+//	  ]
+//	}
+type Fixture struct {
+	Expectations []FixtureExpectation
+}
+
+// FixtureExpectation is a single expected request along with the response that
+// should be sent when it is made.
+type FixtureExpectation struct {
+	Request  FixtureRequest
+	Response FixtureResponse
+	// Times is the number of times the request is expected to be made. A
+	// value of 0 means it may be made any number of times but, unless
+	// Optional is set, must still be made at least once.
+	Times int
+	// Optional marks the request as not being required to be made at all.
+	Optional bool
+}
+
+// FixtureRequest describes the expected request.
+type FixtureRequest struct {
+	Method      string
+	Path        string
+	Headers     map[string]string
+	QueryParams map[string]string
+	// Body is the expected body. A JSON object will be matched against a JSON
+	// request body while a JSON string will be matched against the raw body, or
+	// against the parsed form when the Content-Type header is that of a form.
+	Body interface{}
+}
+
+// FixtureResponse describes the response to send for a matched request.
+type FixtureResponse struct {
+	Status  int
+	Headers map[string]string
+	// JSON, when set, is written out as the response body with a Content-Type
+	// of application/json.
+	JSON json.RawMessage
+	// Text, when set, is written out as the response body.
+	Text string
+}
+
+// ParseFixture decodes a JSON encoded Fixture. Instead of a Fixture, the data may also
+// hold a single FixtureExpectation.
+func ParseFixture(data []byte) (*Fixture, error) {
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, err
+	}
+
+	if len(fixture.Expectations) == 0 {
+		var exp FixtureExpectation
+		if err := json.Unmarshal(data, &exp); err == nil && exp.Request.Method != "" {
+			fixture.Expectations = []FixtureExpectation{exp}
+		}
+	}
+	return &fixture, nil
+}
+
+// ParseFixtureYAML decodes a YAML encoded Fixture. The YAML document has the same structure
+// as the JSON encoding accepted by ParseFixture.
+func ParseFixtureYAML(data []byte) (*Fixture, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	// reuse the JSON decoding so that both encodings are handled identically
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFixture(encoded)
+}
+
+// LoadFixture reads and decodes the Fixture stored in the file at path. Files with a .yaml
+// or .yml extension are decoded as YAML and any others as JSON.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	parse := ParseFixture
+	if isYAMLFile(path) {
+		parse = ParseFixtureYAML
+	}
+
+	fixture, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fixture file %q: %w", path, err)
+	}
+	return fixture, nil
+}
+
+func isYAMLFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// mockRequest converts the fixture request into a MockRequest.
+func (f *FixtureRequest) mockRequest() (*MockRequest, error) {
+	req := NewMockRequest(f.Method, f.Path).
+		WithHeaders(f.Headers).
+		WithQueryParams(f.QueryParams)
+
+	switch body := f.Body.(type) {
+	case nil:
+	case map[string]interface{}:
+		req.WithBody(body)
+	case url.Values:
+		req.WithFormBody(body)
+	case string:
+		if isFormContentType(f.header("Content-Type")) {
+			values, err := url.ParseQuery(body)
+			if err != nil {
+				return nil, fmt.Errorf("invalid form body for request %s %s: %w", f.Method, f.Path, err)
+			}
+			req.WithFormBody(values)
+		} else {
+			req.WithBody([]byte(body))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported body type %T for request %s %s", f.Body, f.Method, f.Path)
+	}
+
+	// report invalid requests as errors rather than failing the test when registered
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// header returns the value of the named header of the request regardless of the
+// case used for its name in the fixture.
+func (f *FixtureRequest) header(name string) string {
+	for hdr, value := range f.Headers {
+		if strings.EqualFold(hdr, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// mockResponse converts the fixture response into a MockResponse.
+func (f *FixtureResponse) mockResponse() MockResponse {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for hdr, value := range f.Headers {
+			w.Header().Set(hdr, value)
+		}
+
+		var body []byte
+		if len(f.JSON) > 0 {
+			if w.Header().Get("Content-Type") == "" {
+				w.Header().Set("Content-Type", "application/json")
+			}
+			body = f.JSON
+		} else {
+			body = []byte(f.Text)
+		}
+
+		status := f.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		w.WriteHeader(status)
+		w.Write(body)
+	}
+}
+
+// WithFixture will setup expectations for all the requests within the Fixture.
+func (m *MockAPI) WithFixture(fixture *Fixture) error {
+	_, err := m.withFixtureExpectations(fixture.Expectations)
+	return err
+}
+
+func (m *MockAPI) withFixtureExpectations(expectations []FixtureExpectation) ([]*MockAPICall, error) {
+	var calls []*MockAPICall
+	for i := range expectations {
+		exp := &expectations[i]
+		req, err := exp.Request.mockRequest()
+		if err != nil {
+			return calls, err
+		}
+
+		call := m.WithRequest(req, exp.Response.mockResponse())
+		if exp.Times > 0 {
+			call.Times(exp.Times)
+		}
+		if exp.Optional {
+			call.Maybe()
+		}
+		calls = append(calls, call)
+	}
+	return calls, nil
+}
+
+// LoadFixtureFile will load the Fixture stored in the file at path and setup expectations
+// for all the requests within it.
+func (m *MockAPI) LoadFixtureFile(path string) error {
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		return err
+	}
+	return m.WithFixture(fixture)
+}
+
+// LoadFixtures will load every JSON (.json) and YAML (.yaml or .yml) Fixture within the
+// directory, including those in sub-directories, and setup expectations for all the
+// requests within them. Files are loaded in lexical order and files with any other
+// extension are ignored.
+func (m *MockAPI) LoadFixtures(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if !isYAMLFile(path) && !strings.EqualFold(filepath.Ext(path), ".json") {
+			return nil
+		}
+
+		fixture, err := LoadFixture(path)
+		if err != nil {
+			return err
+		}
+		if err := m.WithFixture(fixture); err != nil {
+			return fmt.Errorf("invalid fixture file %q: %w", path, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,140 @@
+package mockapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithFixture(t *testing.T) {
+	fixture, err := ParseFixture([]byte(`{
+		"Expectations": [
+			{
+				"Request": {
+					"Method": "POST",
+					"Path": "/resource",
+					"Headers": {"Content-Type": "application/json"},
+					"Body": {"name": "abc"}
+				},
+				"Response": {
+					"Status": 201,
+					"JSON": {"name": "abc", "created": true}
+				},
+				"Times": 1
+			}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("Error parsing fixture: %v", err)
+	}
+
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+	})
+
+	if err := m.WithFixture(fixture); err != nil {
+		t.Fatalf("Error setting up fixture expectations: %v", err)
+	}
+
+	resp, err := http.Post(fmt.Sprintf("%s/resource", m.URL()), "application/json", bytes.NewBufferString(`{"name":"abc"}`))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /resource: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 201 {
+		t.Fatalf("Expected a 201 status code but got %d", resp.StatusCode)
+	}
+
+	var output map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&output); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+
+	if created, ok := output["created"].(bool); !ok || !created {
+		t.Fatalf("Didn't get the expected response: %v", output)
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"nodes.json": `{
+			"Expectations": [
+				{
+					"Request": {"Method": "GET", "Path": "/nodes"},
+					"Response": {"Status": 200, "JSON": [{"id": "node-1"}]},
+					"Times": 1
+				}
+			]
+		}`,
+		"jobs/create.yaml": `
+Request:
+  Method: POST
+  Path: /jobs
+  Body:
+    name: example
+Response:
+  Status: 201
+  JSON:
+    id: job-1
+Times: 1
+`,
+		"README.md": "not a fixture",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Error creating directory: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Error writing fixture: %v", err)
+		}
+	}
+
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	if err := m.LoadFixtures(dir); err != nil {
+		t.Fatalf("Error loading fixtures: %v", err)
+	}
+
+	resp, err := http.Get(fmt.Sprintf("%s/nodes", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /nodes: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status code but got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Post(fmt.Sprintf("%s/jobs", m.URL()), "application/json", bytes.NewBufferString(`{"name":"example"}`))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /jobs: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 201 {
+		t.Fatalf("Expected a 201 status code but got %d", resp.StatusCode)
+	}
+
+	var output map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&output); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+	if output["id"] != "job-1" {
+		t.Fatalf("Didn't get the expected response: %v", output)
+	}
+}
@@ -0,0 +1,42 @@
+package mockapi
+
+import (
+	"mime"
+	"net/url"
+)
+
+// formContentType is the media type of form-urlencoded bodies.
+const formContentType = "application/x-www-form-urlencoded"
+
+// isFormContentType returns whether the Content-Type denotes a form-urlencoded body.
+func isFormContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == formContentType
+}
+
+// decodeRequestBody converts a received body into the value that is matched against
+// the body of the expectations. Form-urlencoded bodies are parsed into url.Values and
+// multipart/form-data bodies into a *MultipartBody while all other bodies are decoded
+// by decodeBody.
+func decodeRequestBody(contentType string, body []byte) interface{} {
+	if isFormContentType(contentType) {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return values
+		}
+	}
+	if isMultipartFormContentType(contentType) {
+		if parsed, err := parseMultipartBody(contentType, body); err == nil {
+			return parsed
+		}
+	}
+	return decodeBody(body)
+}
+
+// WithFormBody will expect the request to have a form-urlencoded body with exactly these
+// values. The order of the fields in the body does not matter but the order of the values
+// of repeated fields does. It may not be combined with any other body expectation.
+func (r *MockRequest) WithFormBody(values url.Values) *MockRequest {
+	r.setBodyOption("WithFormBody")
+	r.body = values
+	return r
+}
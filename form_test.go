@@ -0,0 +1,82 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWithFormBody(t *testing.T) {
+	m := NewMockAPI(t, WithRequestJournal())
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	m.WithJSONReply(NewMockRequest("POST", "/oauth/token").WithFormBody(url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {"read", "write"},
+	}), 200, map[string]string{"access_token": "abc"}).Once()
+
+	resp, err := http.PostForm(fmt.Sprintf("%s/oauth/token", m.URL()), url.Values{
+		"scope":      {"read", "write"},
+		"grant_type": {"client_credentials"},
+	})
+	if err != nil {
+		t.Fatalf("Error issuing POST of /oauth/token: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status code but got %d", resp.StatusCode)
+	}
+
+	journal := m.Journal()
+	if len(journal) != 1 {
+		t.Fatalf("Expected one journal entry but got %d", len(journal))
+	}
+	if form, ok := journal[0].Body.(url.Values); !ok || form.Get("grant_type") != "client_credentials" {
+		t.Fatalf("Form body was not recorded as url.Values: %#v", journal[0].Body)
+	}
+}
+
+func TestFixtureFormBody(t *testing.T) {
+	fixture, err := ParseFixture([]byte(`{
+		"Expectations": [{
+			"Request": {
+				"Method": "POST",
+				"Path": "/login",
+				"Headers": {"Content-Type": "application/x-www-form-urlencoded"},
+				"Body": "user=jane&remember=true"
+			},
+			"Response": {"Status": 204}
+		}]
+	}`))
+	if err != nil {
+		t.Fatalf("Error parsing fixture: %v", err)
+	}
+
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+	})
+	if err := m.WithFixture(fixture); err != nil {
+		t.Fatalf("Error loading fixture: %v", err)
+	}
+
+	resp, err := http.Post(fmt.Sprintf("%s/login", m.URL()), "application/x-www-form-urlencoded", strings.NewReader("remember=true&user=jane"))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /login: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 204 {
+		t.Fatalf("Expected a 204 status code but got %d", resp.StatusCode)
+	}
+}
@@ -1,5 +1,18 @@
 module github.com/mkeeler/mock-http-api
 
-go 1.14
+go 1.18
 
-require github.com/stretchr/testify v1.6.1
+require (
+	github.com/google/go-cmp v0.5.9
+	github.com/stretchr/testify v1.6.1
+	golang.org/x/net v0.11.0
+	google.golang.org/protobuf v1.28.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/objx v0.1.0 // indirect
+	golang.org/x/text v0.10.0 // indirect
+)
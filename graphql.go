@@ -0,0 +1,97 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// graphQLOperation finds the name of the first named operation within a GraphQL document.
+var graphQLOperation = regexp.MustCompile(`\b(?:query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+
+// GraphQLRequest is the body of a GraphQL request sent over HTTP.
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// operation returns the name of the requested operation. When the request does not
+// name it explicitly, the name of the first operation of the query is used.
+func (g *GraphQLRequest) operation() string {
+	if g.OperationName != "" {
+		return g.OperationName
+	}
+	if match := graphQLOperation.FindStringSubmatch(g.Query); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// GraphQLError is an error within a GraphQL response.
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLResponse is the body of a GraphQL response.
+type GraphQLResponse struct {
+	Data   interface{}    `json:"data"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// WithGraphQLRequest will expect the request to have a JSON encoded GraphQL body for the named
+// operation. The operation is taken from the operationName of the body or otherwise from the
+// query itself, so the formatting of the query does not matter. When variables is non-nil the
+// variables of the request must equal them as well. It may not be combined with any other
+// body expectation.
+func (r *MockRequest) WithGraphQLRequest(opName string, variables map[string]interface{}) *MockRequest {
+	r.setBodyOption("WithGraphQLRequest")
+
+	var wantVariables map[string]interface{}
+	if variables != nil {
+		// round trip the variables so that they compare equal to decoded ones
+		data, err := json.Marshal(variables)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("failed to encode expected GraphQL variables: %w", err))
+			return r
+		}
+		json.Unmarshal(data, &wantVariables)
+	}
+
+	r.body = mock.Anything
+	r.matchers = append(r.matchers, func(rr *receivedRequest) bool {
+		var req GraphQLRequest
+		if err := json.Unmarshal(rr.bodyBytes, &req); err != nil || req.Query == "" {
+			return false
+		}
+		if req.operation() != opName {
+			return false
+		}
+		if wantVariables == nil {
+			return true
+		}
+		if len(req.Variables) == 0 && len(wantVariables) == 0 {
+			return true
+		}
+		return reflect.DeepEqual(req.Variables, wantVariables)
+	})
+	return r
+}
+
+// WithGraphQLReply will setup an expectation for an API call to be made. The reply is a GraphQL
+// response with a 200 status code holding the data and, when non-empty, the errors.
+func (m *MockAPI) WithGraphQLReply(req *MockRequest, data interface{}, errors ...GraphQLError) *MockAPICall {
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		resp := GraphQLResponse{
+			Data:   data,
+			Errors: errors,
+		}
+		checkError(m.t, writeJSON(w, http.StatusOK, "application/json", &resp))
+	})
+}
@@ -0,0 +1,53 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGraphQL(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	m.WithGraphQLReply(NewMockRequest("POST", "/graphql").WithGraphQLRequest("GetUser", map[string]interface{}{"id": 1}),
+		map[string]interface{}{"user": map[string]interface{}{"name": "jane"}}).Once()
+	m.WithGraphQLReply(NewMockRequest("POST", "/graphql").WithGraphQLRequest("DeleteUser", nil),
+		nil, GraphQLError{Message: "forbidden", Path: []interface{}{"deleteUser"}}).Once()
+
+	post := func(body string) GraphQLResponse {
+		t.Helper()
+		resp, err := http.Post(fmt.Sprintf("%s/graphql", m.URL()), "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Error issuing POST of /graphql: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			t.Fatalf("Expected a 200 status code but got %d", resp.StatusCode)
+		}
+
+		var reply GraphQLResponse
+		if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+			t.Fatalf("Error decoding response: %v", err)
+		}
+		return reply
+	}
+
+	reply := post(`{"query": "query GetUser($id: ID!) {\n  user(id: $id) { name }\n}", "variables": {"id": 1}}`)
+	if user, ok := reply.Data.(map[string]interface{})["user"].(map[string]interface{}); !ok || user["name"] != "jane" {
+		t.Fatalf("Unexpected response: %+v", reply)
+	}
+
+	reply = post(`{"query": "mutation DeleteUser { deleteUser(id: 1) }", "operationName": "DeleteUser"}`)
+	if reply.Data != nil || len(reply.Errors) != 1 || reply.Errors[0].Message != "forbidden" {
+		t.Fatalf("Unexpected response: %+v", reply)
+	}
+}
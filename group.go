@@ -0,0 +1,94 @@
+package mockapi
+
+import "sync"
+
+// Group is a named group of expectations which can be enabled and disabled as a
+// whole. While a group is disabled none of its expectations will match any request.
+// This allows alternative server behaviors to be swapped in and out mid-test without
+// re-registering expectations:
+//
+//	m.WithJSONReply(req, 200, healthy).InGroup("healthy")
+//	m.WithJSONReply(req, 503, degraded).InGroup("degraded-mode").Maybe()
+//	m.Group("degraded-mode").Disable()
+//	...
+//	m.Group("healthy").Disable()
+//	m.Group("degraded-mode").Enable()
+//
+// Disabling a group does not change whether its expectations are required to be
+// met so expectations which may never be used should be marked with Maybe.
+type Group struct {
+	name string
+
+	mu       sync.Mutex
+	disabled bool
+}
+
+// Group returns the group with the given name, creating it if necessary. Groups
+// are enabled when created.
+func (m *MockAPI) Group(name string) *Group {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if g, ok := m.groups[name]; ok {
+		return g
+	}
+	if m.groups == nil {
+		m.groups = make(map[string]*Group)
+	}
+	g := &Group{name: name}
+	m.groups[name] = g
+	return g
+}
+
+// Name returns the name of the group.
+func (g *Group) Name() string {
+	return g.name
+}
+
+// Enable allows the expectations of the group to match requests again.
+func (g *Group) Enable() *Group {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.disabled = false
+	return g
+}
+
+// Disable stops the expectations of the group from matching any requests.
+func (g *Group) Disable() *Group {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.disabled = true
+	return g
+}
+
+// Enabled returns whether the expectations of the group may match requests.
+func (g *Group) Enabled() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return !g.disabled
+}
+
+// InGroup adds the call to the named group. A call may be in multiple groups in
+// which case it only matches requests while all of them are enabled.
+func (m *MockAPICall) InGroup(name string) *MockAPICall {
+	g := m.api.Group(name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.groups = append(m.groups, g)
+	return m
+}
+
+// active returns whether the call may currently match requests.
+func (m *MockAPICall) active() bool {
+	m.mu.Lock()
+	groups := m.groups
+	m.mu.Unlock()
+
+	for _, g := range groups {
+		if !g.Enabled() {
+			return false
+		}
+	}
+	return m.inState()
+}
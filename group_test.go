@@ -0,0 +1,42 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/health"), 200).InGroup("healthy")
+	m.WithNoResponseBody(NewMockRequest("GET", "/health"), 503).InGroup("degraded-mode")
+	m.Group("degraded-mode").Disable()
+
+	check := func(expected int) {
+		t.Helper()
+		resp, err := http.Get(fmt.Sprintf("%s/health", m.URL()))
+		if err != nil {
+			t.Fatalf("Error issuing GET of /health: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Fatalf("Expected status code %d but got %d", expected, resp.StatusCode)
+		}
+	}
+
+	check(200)
+
+	m.Group("healthy").Disable()
+	m.Group("degraded-mode").Enable()
+	check(503)
+	check(503)
+
+	m.Group("healthy").Enable()
+	m.Group("degraded-mode").Disable()
+	check(200)
+}
@@ -0,0 +1,43 @@
+package mockapi
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// WithH2C configures the MockAPI to serve HTTP/2 over plaintext connections (h2c) in addition
+// to HTTP/1.1. Clients may either start with HTTP/2 directly (prior knowledge) or upgrade from
+// HTTP/1.1. Use MockAPI.Client to get an *http.Client which speaks HTTP/2 with prior knowledge.
+// Combine it with WithHTTP2 and WithHTTPAndHTTPS to serve both h2 over TLS and h2c.
+func WithH2C() Option {
+	return func(m *MockAPI) error {
+		m.h2c = true
+		return nil
+	}
+}
+
+// h2cHandler returns the handler serving the expectations over h2c.
+func (m *MockAPI) h2cHandler() http.Handler {
+	return h2c.NewHandler(m, &http2.Server{})
+}
+
+// h2cClient returns a client making HTTP/2 requests over plaintext connections.
+func (m *MockAPI) h2cClient() *http.Client {
+	var dialer net.Dialer
+	return &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				if m.unixSocket != "" {
+					return dialer.DialContext(ctx, "unix", m.unixSocket)
+				}
+				return dialer.DialContext(ctx, network, addr)
+			},
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestWithH2C(t *testing.T) {
+	m := NewMockAPI(t, WithH2C())
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithTextReply(NewMockRequest("GET", "/proto"), 200, "hello").Twice()
+
+	resp, err := m.Client().Get(fmt.Sprintf("%s/proto", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /proto: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("Expected the request to be made over HTTP/2 but got %s", resp.Proto)
+	}
+
+	// clients without HTTP/2 support are still served over HTTP/1.1
+	resp, err = http.Get(fmt.Sprintf("%s/proto", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /proto: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.ProtoMajor != 1 {
+		t.Fatalf("Expected the request to be made over HTTP/1.1 but got %s", resp.Proto)
+	}
+}
+
+func TestWithHTTP2(t *testing.T) {
+	m := NewMockAPI(t, WithHTTP2())
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithTextReply(NewMockRequest("GET", "/proto"), 200, "hello").Once()
+
+	resp, err := m.Client().Get(fmt.Sprintf("%s/proto", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /proto: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("Expected the request to be made over HTTP/2 but got %s", resp.Proto)
+	}
+}
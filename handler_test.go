@@ -0,0 +1,51 @@
+package mockapi
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewMockHandler(t *testing.T) {
+	m := NewMockHandler(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	if m.URL() != "" {
+		t.Fatalf("Expected no URL without an HTTP server but got %q", m.URL())
+	}
+
+	m.WithTextReply(NewMockRequest("GET", "/api/resource"), 200, "mocked").Once()
+
+	// mount the mock alongside another handler within our own server
+	mux := http.NewServeMux()
+	mux.Handle("/api/", m.Handler())
+	mux.HandleFunc("/other", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("real"))
+	})
+
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	for path, expected := range map[string]string{"/api/resource": "mocked", "/other": "real"} {
+		resp, err := http.Get(s.URL + path)
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", path, err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if string(body) != expected {
+			t.Fatalf("Expected %q for %s but got %q", expected, path, body)
+		}
+	}
+}
+
+func TestNewHandlerServerOptions(t *testing.T) {
+	if _, err := NewHandler(WithTLS()); err == nil {
+		t.Fatalf("Expected an error using WithTLS without an HTTP server")
+	}
+}
@@ -0,0 +1,41 @@
+package mockapi
+
+import (
+	"net/http"
+)
+
+// WithNoReply will setup an expectation for an API call to be made. The request is accepted and
+// recorded but no response is ever written. The request stays open until the client gives up on
+// it, such as due to a timeout or the cancellation of its context, or the MockAPI is closed in
+// which case the connection is closed without a response. This allows testing client timeouts
+// deterministically.
+func (m *MockAPI) WithNoReply(req *MockRequest) *MockAPICall {
+	return m.WithRequest(req, m.hang)
+}
+
+// Hang replaces the response to requests matching this call with the request being left open
+// without a response, as with WithNoReply. To release hung requests at a point controlled by
+// the test and then reply as normal, use WaitUntil instead.
+func (m *MockAPICall) Hang() *MockAPICall {
+	api := m.api
+	m.wrap(func(next MockResponse) MockResponse {
+		return api.hang
+	})
+	return m
+}
+
+// hang blocks until either the client gives up on the request or the MockAPI is closed in
+// which case the connection is closed without writing a response.
+func (m *MockAPI) hang(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-r.Context().Done():
+	case <-m.releasing:
+		conn := hijack(w)
+		conn.Close()
+	}
+}
+
+// releaseHung abandons all hung requests.
+func (m *MockAPI) releaseHung() {
+	m.releaseOnce.Do(func() { close(m.releasing) })
+}
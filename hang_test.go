@@ -0,0 +1,70 @@
+package mockapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithNoReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	call := m.WithNoReply(NewMockRequest("GET", "/hang")).Once()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/hang", m.URL()), nil)
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+
+	_, err = http.DefaultClient.Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the request to time out but got: %v", err)
+	}
+	if len(call.Requests()) != 1 {
+		t.Fatalf("Expected the hung request to be recorded")
+	}
+}
+
+func TestHangReleasedOnClose(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/hang"), 200).Once().Hang()
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(fmt.Sprintf("%s/hang", m.URL()))
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Expected the request to hang but it completed: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	m.Close()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("Expected the hung request to fail once the MockAPI was closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the hung request to be released once the MockAPI was closed")
+	}
+}
@@ -0,0 +1,370 @@
+package mockapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR is an HTTP Archive as exported by browser devtools and many proxies. Only the
+// parts of the format needed to setup expectations are decoded.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of the archive.
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator identifies the application which created the archive.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a single request and the response it received.
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+}
+
+// HARRequest is a recorded request.
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+}
+
+// HARPostData is the body of a recorded request.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARResponse is a recorded response.
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+}
+
+// HARContent is the body of a recorded response. The text is base64 encoded
+// when Encoding is "base64".
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARNameValue is a header or query param.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// harSkippedResponseHeaders are the response headers which are not replayed as
+// the mock HTTP server will generate its own or the recorded body has already
+// been decoded.
+var harSkippedResponseHeaders = []string{
+	"Date",
+	"Content-Length",
+	"Content-Encoding",
+	"Transfer-Encoding",
+	"Connection",
+	"Keep-Alive",
+}
+
+// ParseHAR decodes a HAR file.
+func ParseHAR(data []byte) (*HAR, error) {
+	var har HAR
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, err
+	}
+	return &har, nil
+}
+
+// LoadHARFile reads and decodes the HAR file at path.
+func LoadHARFile(path string) (*HAR, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	har, err := ParseHAR(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HAR file %q: %w", path, err)
+	}
+	return har, nil
+}
+
+// ForHost returns a HAR holding only the entries for requests made to the given host.
+// Archives exported from browsers usually hold requests made to many hosts, such as
+// those serving assets, while only those made to the API being mocked are of interest.
+// The host matches requests to it on any port unless the port is included.
+func (h *HAR) ForHost(host string) *HAR {
+	filtered := &HAR{Log: h.Log}
+	filtered.Log.Entries = nil
+	for _, entry := range h.Log.Entries {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(u.Host, host) || strings.EqualFold(u.Hostname(), host) {
+			filtered.Log.Entries = append(filtered.Log.Entries, entry)
+		}
+	}
+	return filtered
+}
+
+// Fixture converts the archived entries into a Fixture where each entry is expected
+// to occur exactly once. Entries without a response, such as blocked or aborted
+// requests, are skipped.
+//
+// The request headers are not part of the expectations as those captured by a browser
+// are mostly incidental. Headers which are significant can be added to the requests
+// of the returned Fixture.
+func (h *HAR) Fixture() (*Fixture, error) {
+	fixture := &Fixture{}
+	for i, entry := range h.Log.Entries {
+		if entry.Response.Status == 0 {
+			continue
+		}
+
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d has an invalid URL: %w", i, err)
+		}
+
+		req := FixtureRequest{
+			Method:      entry.Request.Method,
+			Path:        u.Path,
+			QueryParams: firstValues(u.Query(), nil),
+		}
+		if entry.Request.PostData != nil {
+			req.Body = recordedRequestBody(entry.Request.PostData.MimeType, entry.Request.PostData.Text)
+		}
+
+		resp := FixtureResponse{
+			Status:  entry.Response.Status,
+			Headers: harHeaders(entry.Response.Headers, harSkippedResponseHeaders),
+		}
+
+		body := entry.Response.Content.Text
+		if entry.Response.Content.Encoding == "base64" {
+			decoded, err := base64.StdEncoding.DecodeString(body)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d has an invalid base64 encoded response body: %w", i, err)
+			}
+			body = string(decoded)
+		}
+		resp.setRecordedBody(body)
+
+		fixture.Expectations = append(fixture.Expectations, FixtureExpectation{
+			Request:  req,
+			Response: resp,
+			Times:    1,
+		})
+	}
+	return fixture, nil
+}
+
+// harHeaders converts HAR headers to a map, skipping the excluded headers and
+// HTTP/2 pseudo headers. Only the first value of multi-value headers is kept.
+func harHeaders(headers []HARNameValue, exclude []string) map[string]string {
+	values := make(map[string][]string)
+	for _, hdr := range headers {
+		if strings.HasPrefix(hdr.Name, ":") {
+			continue
+		}
+		name := http.CanonicalHeaderKey(hdr.Name)
+		values[name] = append(values[name], hdr.Value)
+	}
+	return firstValues(values, exclude)
+}
+
+// LoadHAR will load the HAR file at path and setup an expectation with the
+// recorded response for each of its entries. See HAR.Fixture for details.
+func (m *MockAPI) LoadHAR(path string) error {
+	har, err := LoadHARFile(path)
+	if err != nil {
+		return err
+	}
+
+	fixture, err := har.Fixture()
+	if err != nil {
+		return fmt.Errorf("failed to convert HAR file %q: %w", path, err)
+	}
+	return m.WithFixture(fixture)
+}
+
+// WithTrafficCapture makes the MockAPI keep every request it handles, along with the response
+// it sent, so that they can be exported with ExportHAR. As the bodies are held in memory
+// until the MockAPI is reset or closed, capturing is off unless this option is used.
+func WithTrafficCapture() Option {
+	return func(m *MockAPI) error {
+		m.captureTraffic = true
+		return nil
+	}
+}
+
+// ExportHAR writes all the requests the MockAPI has handled, along with the responses it
+// sent, to w as a HAR file. This includes requests which did not match any expectation.
+// Entries are ordered by the time the requests were received. Requests whose connection
+// was hijacked, such as those used to inject faults, are exported with a status of 0.
+// The MockAPI must have been created with the WithTrafficCapture option.
+func (m *MockAPI) ExportHAR(w io.Writer) error {
+	if !m.captureTraffic {
+		return fmt.Errorf("traffic is only captured by a MockAPI created with the WithTrafficCapture option")
+	}
+
+	m.mu.Lock()
+	entries := make([]HAREntry, len(m.traffic))
+	copy(entries, m.traffic)
+	m.mu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime < entries[j].StartedDateTime
+	})
+
+	har := HAR{
+		Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{Name: "mock-http-api"},
+			Entries: entries,
+		},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&har)
+}
+
+// harTimeFormat is the format of the start time of exported entries. Its fixed
+// width allows the entries to be ordered by comparing the formatted times.
+const harTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// recordTraffic adds the entry to the traffic exported by ExportHAR.
+func (m *MockAPI) recordTraffic(entry HAREntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.traffic = append(m.traffic, entry)
+}
+
+// harWriter is an http.ResponseWriter which records the response being sent
+// so that it can be exported as a HAR entry.
+type harWriter struct {
+	wrappedWriter
+	status   int
+	header   http.Header
+	body     bytes.Buffer
+	hijacked bool
+}
+
+func (w *harWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *harWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *harWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return w.wrappedWriter.Hijack()
+}
+
+// entry returns the HAR entry for the request and the recorded response.
+func (w *harWriter) entry(r *http.Request, body []byte, start time.Time) HAREntry {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	req := HARRequest{
+		Method:      r.Method,
+		URL:         fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.RequestURI()),
+		HTTPVersion: r.Proto,
+		Headers:     harNameValues(r.Header),
+		QueryString: harNameValues(r.URL.Query()),
+	}
+	if len(body) > 0 {
+		req.PostData = &HARPostData{MimeType: r.Header.Get("Content-Type"), Text: string(body)}
+	}
+
+	resp := HARResponse{HTTPVersion: r.Proto}
+	if !w.hijacked {
+		if w.status == 0 {
+			w.status = http.StatusOK
+			w.header = w.Header().Clone()
+		}
+
+		resp.Status = w.status
+		resp.StatusText = http.StatusText(w.status)
+		resp.Headers = harNameValues(w.header)
+		resp.Content = HARContent{
+			Size:     w.body.Len(),
+			MimeType: w.header.Get("Content-Type"),
+		}
+		if utf8.Valid(w.body.Bytes()) {
+			resp.Content.Text = w.body.String()
+		} else {
+			resp.Content.Text = base64.StdEncoding.EncodeToString(w.body.Bytes())
+			resp.Content.Encoding = "base64"
+		}
+	}
+
+	return HAREntry{
+		StartedDateTime: start.UTC().Format(harTimeFormat),
+		Time:            float64(time.Since(start)) / float64(time.Millisecond),
+		Request:         req,
+		Response:        resp,
+	}
+}
+
+// harNameValues converts headers or query params into HAR name/value pairs ordered
+// by name.
+func harNameValues(values map[string][]string) []HARNameValue {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := []HARNameValue{}
+	for _, name := range names {
+		for _, value := range values[name] {
+			pairs = append(pairs, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,147 @@
+package mockapi
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLoadHAR(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Content-Length",
+		"Content-Type",
+		"User-Agent",
+	})
+
+	if err := m.LoadHAR("testdata/capture.har"); err != nil {
+		t.Fatalf("Error loading HAR file: %v", err)
+	}
+
+	resp, err := http.Post(fmt.Sprintf("%s/v1/nodes?region=east", m.URL()), "application/json", bytes.NewReader([]byte(`{"name":"node-1"}`)))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /v1/nodes: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != 201 || string(body) != `{"id": "node-1"}` || resp.Header.Get("X-Request-Id") != "abc123" {
+		t.Fatalf("Unexpected response: %d %s %v", resp.StatusCode, body, resp.Header)
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/v1/health", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /v1/health: %v", err)
+	}
+	body, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 || string(body) != "ok" || resp.Header.Get("Content-Type") != "text/plain" {
+		t.Fatalf("Unexpected response: %d %s %v", resp.StatusCode, body, resp.Header)
+	}
+}
+
+func TestHARForHost(t *testing.T) {
+	har, err := LoadHARFile("testdata/capture.har")
+	if err != nil {
+		t.Fatalf("Error loading HAR file: %v", err)
+	}
+
+	if entries := har.ForHost("api.example.com").Log.Entries; len(entries) != 2 {
+		t.Fatalf("Expected 2 entries for api.example.com but got %d", len(entries))
+	}
+	if entries := har.ForHost("api.example.com:443").Log.Entries; len(entries) != 0 {
+		t.Fatalf("Expected no entries for api.example.com:443 but got %d", len(entries))
+	}
+	if entries := har.ForHost("tracker.example.com").Log.Entries; len(entries) != 1 {
+		t.Fatalf("Expected 1 entry for tracker.example.com but got %d", len(entries))
+	}
+	if len(har.Log.Entries) != 3 {
+		t.Fatalf("Expected the original HAR to be unchanged")
+	}
+}
+
+func TestExportHAR(t *testing.T) {
+	rt := &recordingT{}
+	m := NewMockAPI(rt, WithTrafficCapture())
+	defer m.Close()
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Content-Length",
+		"Content-Type",
+		"User-Agent",
+	})
+
+	m.WithJSONReply(NewMockRequest("POST", "/v1/nodes").WithQueryParams(map[string]string{"region": "east"}).WithBody(map[string]interface{}{"name": "node-1"}), 201, map[string]string{"id": "node-1"}).Once()
+
+	resp, err := http.Post(fmt.Sprintf("%s/v1/nodes?region=east", m.URL()), "application/json", bytes.NewReader([]byte(`{"name":"node-1"}`)))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /v1/nodes: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/v1/unknown", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /v1/unknown: %v", err)
+	}
+	resp.Body.Close()
+
+	var buf bytes.Buffer
+	if err := m.ExportHAR(&buf); err != nil {
+		t.Fatalf("Error exporting HAR: %v", err)
+	}
+
+	har, err := ParseHAR(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Error parsing exported HAR: %v", err)
+	}
+	if len(har.Log.Entries) != 2 {
+		t.Fatalf("Expected 2 entries but got %d", len(har.Log.Entries))
+	}
+
+	created := har.Log.Entries[0]
+	if created.Request.Method != "POST" || created.Request.URL != m.URL()+"/v1/nodes?region=east" {
+		t.Fatalf("Unexpected request: %+v", created.Request)
+	}
+	if created.Request.PostData == nil || created.Request.PostData.Text != `{"name":"node-1"}` {
+		t.Fatalf("Unexpected request body: %+v", created.Request.PostData)
+	}
+	if created.Response.Status != 201 || strings.TrimSpace(created.Response.Content.Text) != `{"id":"node-1"}` {
+		t.Fatalf("Unexpected response: %+v", created.Response)
+	}
+
+	if unknown := har.Log.Entries[1]; unknown.Response.Status != 404 {
+		t.Fatalf("Expected the unexpected request to be exported with a 404 status but got %d", unknown.Response.Status)
+	}
+}
+
+func TestExportHARWithoutTrafficCapture(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithJSONReply(NewMockRequest("GET", "/v1/nodes"), 200, []string{"node-1"}).Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/v1/nodes", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /v1/nodes: %v", err)
+	}
+	resp.Body.Close()
+
+	m.mu.Lock()
+	traffic := len(m.traffic)
+	m.mu.Unlock()
+	if traffic != 0 {
+		t.Fatalf("Expected no traffic to be captured but got %d entries", traffic)
+	}
+
+	if err := m.ExportHAR(ioutil.Discard); err == nil {
+		t.Fatalf("Expected an error exporting a HAR without capturing traffic")
+	}
+}
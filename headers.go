@@ -0,0 +1,86 @@
+package mockapi
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// headerMatcher is a condition on the values of a single header. Headers with a
+// matcher are not part of the exact comparison of the other headers.
+type headerMatcher func(values []string) bool
+
+// withHeaderMatcher sets the condition for the values of the named header.
+func (r *MockRequest) withHeaderMatcher(name string, matcher headerMatcher) *MockRequest {
+	if r.headerMatchers == nil {
+		r.headerMatchers = make(map[string]headerMatcher)
+	}
+	r.headerMatchers[http.CanonicalHeaderKey(name)] = matcher
+	return r
+}
+
+// WithHeaderSubset will expect the request to have at least these headers with these values.
+// Unlike WithHeaders, any other headers of the request are ignored, including those which
+// would be expected by WithHeaders or WithHeaderValues as long as they are present.
+func (r *MockRequest) WithHeaderSubset(headers map[string]string) *MockRequest {
+	r.headerSubset = true
+	return r.WithHeaders(headers)
+}
+
+// canonicalizeHeaders converts the names of the expected headers to their canonical
+// form, merging the values of names which only differ in case.
+func (r *MockRequest) canonicalizeHeaders() {
+	if r.headers == nil {
+		return
+	}
+
+	canonical := make(http.Header, len(r.headers))
+	for name, values := range r.headers {
+		key := http.CanonicalHeaderKey(name)
+		canonical[key] = append(canonical[key], values...)
+	}
+	r.headers = canonical
+}
+
+// headersArgument returns the argument to use for matching the headers of the request.
+func (r *MockRequest) headersArgument() interface{} {
+	if len(r.headerMatchers) == 0 && !r.headerSubset {
+		return r.headers
+	}
+
+	expected := r.headers
+	matchers := r.headerMatchers
+	subset := r.headerSubset
+	return mock.MatchedBy(func(headers http.Header) bool {
+		for name, matcher := range matchers {
+			if !matcher(headers[name]) {
+				return false
+			}
+		}
+
+		if subset {
+			for name, values := range expected {
+				if !reflect.DeepEqual(headers[name], values) {
+					return false
+				}
+			}
+			return true
+		}
+
+		var rest http.Header
+		for name, values := range headers {
+			if _, ok := matchers[name]; ok {
+				continue
+			}
+			if rest == nil {
+				rest = make(http.Header)
+			}
+			rest[name] = values
+		}
+		if len(rest) == 0 && len(expected) == 0 {
+			return true
+		}
+		return reflect.DeepEqual(rest, expected)
+	})
+}
@@ -0,0 +1,68 @@
+// Package http3 serves the expectations of a MockAPI over HTTP/3. It is a separate
+// module so that only the tests which need HTTP/3 depend on the
+// github.com/quic-go/quic-go module.
+package http3
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	mockapi "github.com/mkeeler/mock-http-api"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// WithHTTP3 configures the MockAPI to additionally serve HTTP/3 over QUIC. The QUIC
+// listener uses the UDP port with the same number as the TCP port of the HTTPS server
+// and the same certificate so that the URL of the MockAPI is valid for both. Requests
+// received over HTTP/3 are matched against the same expectations. Use Client to get
+// an *http.Client which will make requests over HTTP/3.
+func WithHTTP3() mockapi.Option {
+	return func(m *mockapi.MockAPI) error {
+		if err := mockapi.WithTLS()(m); err != nil {
+			return err
+		}
+		return mockapi.WithStartHook(start)(m)
+	}
+}
+
+// start starts the HTTP/3 server alongside the running HTTPS server.
+func start(m *mockapi.MockAPI) (func(), error) {
+	u, err := url.Parse(m.HTTPSURL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the URL of the HTTPS server: %w", err)
+	}
+	addr, err := net.ResolveUDPAddr("udp", u.Host)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP/3 requires a TCP listener but the server is listening on %s: %w", u.Host, err)
+	}
+
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for QUIC connections on %s: %w", addr, err)
+	}
+
+	srv := &http3.Server{
+		Handler:   m,
+		TLSConfig: http3.ConfigureTLSConfig(m.TLSConfig()),
+	}
+	go srv.Serve(conn)
+
+	return func() {
+		srv.Close()
+		conn.Close()
+	}, nil
+}
+
+// Client returns an *http.Client which makes requests to the MockAPI over HTTP/3
+// and trusts the server's certificate.
+func Client(m *mockapi.MockAPI) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(m.Certificate())
+	return &http.Client{
+		Transport: &http3.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+	}
+}
@@ -0,0 +1,28 @@
+package http3
+
+import (
+	"fmt"
+	"testing"
+
+	mockapi "github.com/mkeeler/mock-http-api"
+)
+
+func TestWithHTTP3(t *testing.T) {
+	m := mockapi.NewMockAPI(t, WithHTTP3())
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(mockapi.NewMockRequest("GET", "/h3"), 200).Once()
+
+	resp, err := Client(m).Get(fmt.Sprintf("%s/h3", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /h3: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.ProtoMajor != 3 {
+		t.Fatalf("Expected the request to be served over HTTP/3 but got %s", resp.Proto)
+	}
+}
@@ -0,0 +1,277 @@
+package mockapi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	httpFileVariable = regexp.MustCompile(`^@([A-Za-z0-9_.-]+)\s*=\s*(.*)$`)
+	httpFileRef      = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.$-]+)\s*\}\}`)
+	httpFileStatus   = regexp.MustCompile(`\.([1-5][0-9][0-9])\.[A-Za-z0-9]+$`)
+)
+
+// ParseHTTPRequests converts the requests of a .http or .rest file, as used by the VS Code
+// REST Client and IntelliJ HTTP Client, into a Fixture where each request is expected to
+// occur exactly once. An example file looks like:
+//
+//	@host = https://api.example.com
+//
+//	### Create a node
+//	POST {{host}}/v1/nodes?region=east
+//	Content-Type: application/json
+//
+//	{"name": "node-1"}
+//
+//	<> responses/create-node.201.json
+//
+// File variables are substituted and a leading reference to an undefined variable, such as
+// an environment's base URL, is ignored. Request bodies may be given inline or read from a
+// file with "< path". Response handler scripts are ignored.
+//
+// The response to reply with is attached with an IntelliJ style "<> path" response reference.
+// The file holds the response body and a status code may be part of its name as in the example.
+// Requests without a response reference are replied to with a 200 status code and no body.
+// Relative file references are resolved against dir.
+func ParseHTTPRequests(data []byte, dir string) (*Fixture, error) {
+	p := httpFileParser{dir: dir, vars: make(map[string]string)}
+	for i, block := range splitHTTPRequests(data) {
+		exp, ok, err := p.parse(block)
+		if err != nil {
+			return nil, fmt.Errorf("request %d: %w", i+1, err)
+		}
+		if ok {
+			p.fixture.Expectations = append(p.fixture.Expectations, exp)
+		}
+	}
+	return &p.fixture, nil
+}
+
+// LoadHTTPRequests reads the .http or .rest file at path and converts its requests into a
+// Fixture. See ParseHTTPRequests for details.
+func LoadHTTPRequests(path string) (*Fixture, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture, err := ParseHTTPRequests(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTTP request file %q: %w", path, err)
+	}
+	return fixture, nil
+}
+
+// LoadHTTPRequestsFile will load the .http or .rest file at path and setup an expectation
+// for each of its requests. See ParseHTTPRequests for details.
+func (m *MockAPI) LoadHTTPRequestsFile(path string) error {
+	fixture, err := LoadHTTPRequests(path)
+	if err != nil {
+		return err
+	}
+	return m.WithFixture(fixture)
+}
+
+// splitHTTPRequests splits the file into the lines of each request using the
+// ### separators.
+func splitHTTPRequests(data []byte) [][]string {
+	var blocks [][]string
+	var block []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "###") {
+			blocks = append(blocks, block)
+			block = nil
+			continue
+		}
+		block = append(block, line)
+	}
+	return append(blocks, block)
+}
+
+type httpFileParser struct {
+	dir     string
+	vars    map[string]string
+	fixture Fixture
+}
+
+// parse converts the lines of a single request. Blocks holding only variables
+// and comments do not result in an expectation.
+func (p *httpFileParser) parse(lines []string) (FixtureExpectation, bool, error) {
+	var exp FixtureExpectation
+
+	// skip over comments and variables until the request line
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if match := httpFileVariable.FindStringSubmatch(line); match != nil {
+			p.vars[match[1]] = p.substitute(match[2])
+			continue
+		}
+		break
+	}
+	if i == len(lines) {
+		return exp, false, nil
+	}
+
+	// the request line is optionally followed by query continuation lines
+	target := p.substitute(strings.TrimSpace(lines[i]))
+	for i++; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "?") && !strings.HasPrefix(line, "&") {
+			break
+		}
+		target += p.substitute(line)
+	}
+
+	method := http.MethodGet
+	fields := strings.Fields(target)
+	switch {
+	case len(fields) == 0:
+		return exp, false, fmt.Errorf("missing request line")
+	case len(fields) > 1 && !strings.HasPrefix(fields[1], "HTTP/"):
+		method = fields[0]
+		fields = fields[1:]
+	case len(fields) > 2:
+		method = fields[0]
+		fields = fields[1:]
+	}
+
+	rawURL := stripUndefinedVariable(fields[0])
+	if strings.Contains(rawURL, "{{") {
+		return exp, false, fmt.Errorf("undefined variable in URL %q", fields[0])
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return exp, false, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+
+	exp.Times = 1
+	exp.Request = FixtureRequest{
+		Method:      strings.ToUpper(method),
+		Path:        u.Path,
+		QueryParams: firstValues(u.Query(), nil),
+	}
+	if exp.Request.Path == "" {
+		exp.Request.Path = "/"
+	}
+
+	// headers continue until the first blank line
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			i++
+			break
+		}
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return exp, false, fmt.Errorf("invalid header %q", line)
+		}
+		name := http.CanonicalHeaderKey(strings.TrimSpace(parts[0]))
+		if name == "Host" {
+			continue
+		}
+		if exp.Request.Headers == nil {
+			exp.Request.Headers = make(map[string]string)
+		}
+		exp.Request.Headers[name] = p.substitute(strings.TrimSpace(parts[1]))
+	}
+
+	// the body continues until a response handler or response reference
+	var body []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "<>") {
+			resp, err := p.response(strings.TrimSpace(strings.TrimPrefix(trimmed, "<>")))
+			if err != nil {
+				return exp, false, err
+			}
+			exp.Response = resp
+			continue
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			// skip over the rest of an inline handler script
+			if strings.Contains(trimmed, "{%") && !strings.Contains(trimmed, "%}") {
+				for i++; i < len(lines) && !strings.Contains(lines[i], "%}"); i++ {
+				}
+			}
+			continue
+		}
+		if len(body) == 0 && strings.HasPrefix(trimmed, "< ") {
+			data, err := ioutil.ReadFile(p.path(strings.TrimSpace(trimmed[2:])))
+			if err != nil {
+				return exp, false, fmt.Errorf("failed to read request body: %w", err)
+			}
+			body = append(body, string(data))
+			continue
+		}
+		body = append(body, p.substitute(line))
+	}
+
+	exp.Request.Body = recordedRequestBody(exp.Request.Headers["Content-Type"], strings.TrimSpace(strings.Join(body, "\n")))
+	if exp.Response.Status == 0 {
+		exp.Response.Status = http.StatusOK
+	}
+	return exp, true, nil
+}
+
+// response reads a referenced response file.
+func (p *httpFileParser) response(ref string) (FixtureResponse, error) {
+	var resp FixtureResponse
+
+	data, err := ioutil.ReadFile(p.path(ref))
+	if err != nil {
+		return resp, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	resp.Status = http.StatusOK
+	if match := httpFileStatus.FindStringSubmatch(ref); match != nil {
+		resp.Status, _ = strconv.Atoi(match[1])
+	}
+	resp.setRecordedBody(strings.TrimSpace(string(data)))
+	return resp, nil
+}
+
+func (p *httpFileParser) path(ref string) string {
+	if filepath.IsAbs(ref) {
+		return ref
+	}
+	return filepath.Join(p.dir, ref)
+}
+
+// substitute replaces references to the defined file variables.
+func (p *httpFileParser) substitute(s string) string {
+	return httpFileRef.ReplaceAllStringFunc(s, func(ref string) string {
+		name := httpFileRef.FindStringSubmatch(ref)[1]
+		if value, ok := p.vars[name]; ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// stripUndefinedVariable removes a leading variable reference left over after
+// substitution, which is usually an environment's base URL.
+func stripUndefinedVariable(target string) string {
+	if loc := httpFileRef.FindStringIndex(target); loc != nil && loc[0] == 0 {
+		return target[loc[1]:]
+	}
+	return target
+}
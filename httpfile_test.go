@@ -0,0 +1,43 @@
+package mockapi
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestLoadHTTPRequestsFile(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Content-Length",
+		"User-Agent",
+	})
+
+	if err := m.LoadHTTPRequestsFile("testdata/requests.http"); err != nil {
+		t.Fatalf("Error loading HTTP request file: %v", err)
+	}
+
+	resp, err := http.Post(fmt.Sprintf("%s/v1/nodes?region=east", m.URL()), "application/json", bytes.NewReader([]byte(`{"name":"node-1"}`)))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /v1/nodes: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != 201 || string(body) != `{"id": "node-1"}` {
+		t.Fatalf("Unexpected response: %d %s", resp.StatusCode, body)
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/v1/health", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /v1/health: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Unexpected status code: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,143 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// absoluteURL turns a path into a URL on the MockAPI. Values which already
+// have a scheme are returned as is.
+func (m *MockAPI) absoluteURL(path string) string {
+	if strings.Contains(path, "://") {
+		return path
+	}
+	return m.URL() + path
+}
+
+func writeJSON(w http.ResponseWriter, status int, contentType string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, err = w.Write(data)
+	return err
+}
+
+// WithHALReply will setup an expectation for an API call to be made. The reply is a HAL
+// (application/hal+json) document made up of the properties of data along with a _links
+// object and, when non-empty, an _embedded object. The links map relation names to paths
+// or URLs. Paths are turned into URLs of the MockAPI and a self link pointing at the
+// requested URL is added unless one was given.
+func (m *MockAPI) WithHALReply(req *MockRequest, status int, data map[string]interface{}, links map[string]string, embedded map[string]interface{}) *MockAPICall {
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		doc := make(map[string]interface{}, len(data)+2)
+		for key, value := range data {
+			doc[key] = value
+		}
+
+		halLinks := map[string]interface{}{
+			"self": map[string]string{"href": m.absoluteURL(r.URL.RequestURI())},
+		}
+		for rel, href := range links {
+			halLinks[rel] = map[string]string{"href": m.absoluteURL(href)}
+		}
+		doc["_links"] = halLinks
+
+		if len(embedded) > 0 {
+			doc["_embedded"] = embedded
+		}
+
+		checkError(m.t, writeJSON(w, status, "application/hal+json", doc))
+	})
+}
+
+// JSONAPIIdentifier identifies a JSON:API resource.
+type JSONAPIIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// JSONAPIRelationship is a relationship from one JSON:API resource to others. Only one
+// of One (for to-one relationships) or Many (for to-many relationships) should be set.
+type JSONAPIRelationship struct {
+	One  *JSONAPIIdentifier
+	Many []JSONAPIIdentifier
+}
+
+// JSONAPIResource is a resource to be included in a JSON:API reply.
+type JSONAPIResource struct {
+	Type          string
+	ID            string
+	Attributes    map[string]interface{}
+	Relationships map[string]JSONAPIRelationship
+}
+
+// document converts the resource into its JSON:API representation. The links
+// of the resource are URLs of the MockAPI of the form /<type>/<id>.
+func (res *JSONAPIResource) document(m *MockAPI) map[string]interface{} {
+	self := m.absoluteURL("/" + res.Type + "/" + res.ID)
+	doc := map[string]interface{}{
+		"type":  res.Type,
+		"id":    res.ID,
+		"links": map[string]string{"self": self},
+	}
+
+	if len(res.Attributes) > 0 {
+		doc["attributes"] = res.Attributes
+	}
+
+	if len(res.Relationships) > 0 {
+		relationships := make(map[string]interface{}, len(res.Relationships))
+		for name, rel := range res.Relationships {
+			var data interface{}
+			if rel.One != nil {
+				data = rel.One
+			} else if rel.Many != nil {
+				data = rel.Many
+			}
+			relationships[name] = map[string]interface{}{
+				"data": data,
+				"links": map[string]string{
+					"self":    self + "/relationships/" + name,
+					"related": self + "/" + name,
+				},
+			}
+		}
+		doc["relationships"] = relationships
+	}
+
+	return doc
+}
+
+// WithJSONAPIReply will setup an expectation for an API call to be made. The reply is a
+// JSON:API (application/vnd.api+json) document whose primary data is the resource. The
+// document and resource contain self links pointing back at the MockAPI.
+func (m *MockAPI) WithJSONAPIReply(req *MockRequest, status int, resource JSONAPIResource) *MockAPICall {
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		doc := map[string]interface{}{
+			"data":  resource.document(m),
+			"links": map[string]string{"self": m.absoluteURL(r.URL.RequestURI())},
+		}
+		checkError(m.t, writeJSON(w, status, "application/vnd.api+json", doc))
+	})
+}
+
+// WithJSONAPICollectionReply will setup an expectation for an API call to be made. The reply
+// is a JSON:API (application/vnd.api+json) document whose primary data is the list of resources.
+func (m *MockAPI) WithJSONAPICollectionReply(req *MockRequest, status int, resources []JSONAPIResource) *MockAPICall {
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		data := make([]interface{}, 0, len(resources))
+		for i := range resources {
+			data = append(data, resources[i].document(m))
+		}
+
+		doc := map[string]interface{}{
+			"data":  data,
+			"links": map[string]string{"self": m.absoluteURL(r.URL.RequestURI())},
+		}
+		checkError(m.t, writeJSON(w, status, "application/vnd.api+json", doc))
+	})
+}
@@ -0,0 +1,88 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestWithHALReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithHALReply(NewMockRequest("GET", "/orders/1"), 200,
+		map[string]interface{}{"total": 10},
+		map[string]string{"next": "/orders/2"},
+		nil).Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/orders/1", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /orders/1: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var doc struct {
+		Total int
+		Links map[string]struct{ Href string } `json:"_links"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+
+	if doc.Total != 10 || doc.Links["self"].Href != m.URL()+"/orders/1" || doc.Links["next"].Href != m.URL()+"/orders/2" {
+		t.Fatalf("Unexpected HAL document: %+v", doc)
+	}
+}
+
+func TestWithJSONAPIReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithJSONAPIReply(NewMockRequest("GET", "/articles/1"), 200, JSONAPIResource{
+		Type:       "articles",
+		ID:         "1",
+		Attributes: map[string]interface{}{"title": "Hello"},
+		Relationships: map[string]JSONAPIRelationship{
+			"author": {One: &JSONAPIIdentifier{Type: "people", ID: "9"}},
+		},
+	}).Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/articles/1", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /articles/1: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "application/vnd.api+json" {
+		t.Fatalf("Unexpected Content-Type %q", ct)
+	}
+
+	var doc struct {
+		Data struct {
+			Type          string
+			ID            string
+			Attributes    map[string]string
+			Relationships map[string]struct {
+				Data JSONAPIIdentifier
+			}
+			Links map[string]string
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+
+	if doc.Data.ID != "1" || doc.Data.Attributes["title"] != "Hello" || doc.Data.Relationships["author"].Data.ID != "9" {
+		t.Fatalf("Unexpected JSON:API document: %+v", doc)
+	}
+	if doc.Data.Links["self"] != m.URL()+"/articles/1" {
+		t.Fatalf("Unexpected self link: %q", doc.Data.Links["self"])
+	}
+}
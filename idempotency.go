@@ -0,0 +1,132 @@
+package mockapi
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// IdempotencyStore simulates a server which deduplicates requests using an
+// Idempotency-Key header. The first response sent for a key is stored and
+// replayed for any later request carrying the same key. Its Wrap method
+// can be used with MockAPICall.Inject to apply it to an expectation.
+type IdempotencyStore struct {
+	// Header is the name of the header holding the idempotency key. When
+	// empty, Idempotency-Key is used.
+	Header string
+	// ConflictOnMismatch causes requests reusing a key with a different
+	// body to be rejected with a 409 status code.
+	ConflictOnMismatch bool
+
+	mu       sync.Mutex
+	entries  map[string]*idempotencyEntry
+	replayed int
+}
+
+type idempotencyEntry struct {
+	body []byte
+	// done is closed once the response to the first request is stored or it failed
+	done   chan struct{}
+	failed bool
+
+	status int
+	header http.Header
+	resp   []byte
+}
+
+// NewIdempotencyStore creates an empty IdempotencyStore.
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{entries: make(map[string]*idempotencyEntry)}
+}
+
+// Replayed returns the number of requests which were replied to with a stored response.
+func (s *IdempotencyStore) Replayed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.replayed
+}
+
+// Wrap returns a MockResponse which deduplicates requests by their idempotency key,
+// using next to generate the response to the first request for each key. Requests
+// without a key are always passed through to next.
+func (s *IdempotencyStore) Wrap(next MockResponse) MockResponse {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := s.Header
+		if header == "" {
+			header = "Idempotency-Key"
+		}
+
+		key := r.Header.Get(header)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		var entry *idempotencyEntry
+		for {
+			s.mu.Lock()
+			var seen bool
+			entry, seen = s.entries[key]
+			if !seen {
+				entry = &idempotencyEntry{body: body, done: make(chan struct{})}
+				s.entries[key] = entry
+			}
+			s.mu.Unlock()
+
+			if !seen {
+				s.record(key, entry, next, r)
+				break
+			}
+
+			if s.ConflictOnMismatch && !bytes.Equal(body, entry.body) {
+				http.Error(w, "idempotency key reused with a different request body", http.StatusConflict)
+				return
+			}
+
+			<-entry.done
+			if entry.failed {
+				// the first request failed and so this one takes its place
+				continue
+			}
+
+			s.mu.Lock()
+			s.replayed++
+			s.mu.Unlock()
+			break
+		}
+
+		for hdr, values := range entry.header {
+			w.Header()[hdr] = values
+		}
+		w.WriteHeader(entry.status)
+		w.Write(entry.resp)
+	}
+}
+
+// record stores the response generated by next for the first request with the key. When
+// next fails, such as by panicking, the key is forgotten so that the next request with it
+// is handled as the first one again.
+func (s *IdempotencyStore) record(key string, entry *idempotencyEntry, next MockResponse, r *http.Request) {
+	completed := false
+	defer func() {
+		if !completed {
+			s.mu.Lock()
+			delete(s.entries, key)
+			s.mu.Unlock()
+			entry.failed = true
+		}
+		close(entry.done)
+	}()
+
+	rec := httptest.NewRecorder()
+	next(rec, r)
+	entry.status = rec.Code
+	entry.header = rec.Header()
+	entry.resp = rec.Body.Bytes()
+	completed = true
+}
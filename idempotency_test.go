@@ -0,0 +1,119 @@
+package mockapi
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIdempotencyStore(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Idempotency-Key",
+	})
+
+	var created int32
+	store := NewIdempotencyStore()
+	store.ConflictOnMismatch = true
+	m.WithRequest(NewMockRequest("POST", "/payments").WithBody(mock.Anything), func(w http.ResponseWriter, r *http.Request) {
+		id := atomic.AddInt32(&created, 1)
+		w.WriteHeader(201)
+		fmt.Fprintf(w, "payment-%d", id)
+	}).Times(3).Inject(store.Wrap)
+
+	post := func(body string) (int, string) {
+		req, err := http.NewRequest("POST", fmt.Sprintf("%s/payments", m.URL()), strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		req.Header.Set("Idempotency-Key", "key-1")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Error issuing POST of /payments: %v", err)
+		}
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	for i := 0; i < 2; i++ {
+		if status, body := post("amount=10"); status != 201 || body != "payment-1" {
+			t.Fatalf("Request %d: unexpected response %d %q", i, status, body)
+		}
+	}
+
+	if status, _ := post("amount=20"); status != http.StatusConflict {
+		t.Fatalf("Expected a 409 status code but got %d", status)
+	}
+
+	if store.Replayed() != 1 {
+		t.Fatalf("Expected 1 replayed response but got %d", store.Replayed())
+	}
+}
+
+func TestIdempotencyStoreFailedFirstRequest(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Idempotency-Key",
+	})
+
+	var calls int32
+	store := NewIdempotencyStore()
+	m.WithRequest(NewMockRequest("POST", "/payments").WithBody(mock.Anything), func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			panic(http.ErrAbortHandler)
+		}
+		w.WriteHeader(201)
+		io.WriteString(w, "payment-1")
+	}).Times(3).Inject(store.Wrap)
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	post := func() (int, string, error) {
+		req, err := http.NewRequest("POST", fmt.Sprintf("%s/payments", m.URL()), strings.NewReader("amount=10"))
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		req.Header.Set("Idempotency-Key", "key-1")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(data), nil
+	}
+
+	if _, _, err := post(); err == nil {
+		t.Fatalf("Expected the first request to fail")
+	}
+
+	// the key of the failed request is reused rather than waiting on it forever
+	for i := 0; i < 2; i++ {
+		status, body, err := post()
+		if err != nil {
+			t.Fatalf("Request %d: error issuing POST of /payments: %v", i, err)
+		}
+		if status != 201 || body != "payment-1" {
+			t.Fatalf("Request %d: unexpected response %d %q", i, status, body)
+		}
+	}
+
+	if store.Replayed() != 1 {
+		t.Fatalf("Expected 1 replayed response but got %d", store.Replayed())
+	}
+}
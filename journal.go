@@ -0,0 +1,74 @@
+package mockapi
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// JournalEntry is a record of a single request received by the MockAPI.
+type JournalEntry struct {
+	// ID is the correlation ID of the request. See RequestID.
+	ID string
+	// Time is when the request was received.
+	Time        time.Time
+	Method      string
+	Path        string
+	Headers     http.Header
+	QueryParams url.Values
+	// Body is the recorded body. Its type follows the same rules as
+	// for the body used in expectations.
+	Body     interface{}
+	Trailers map[string]string
+}
+
+// WithRequestJournal makes the MockAPI record every request it receives in the journal
+// returned by Journal. As the requests, including their bodies, are held in memory until
+// the MockAPI is reset or closed, the journal is only kept when this option or
+// WithAdminAPI is used.
+func WithRequestJournal() Option {
+	return func(m *MockAPI) error {
+		m.journaling = true
+		return nil
+	}
+}
+
+// record appends an entry to the request journal if it is being kept.
+func (m *MockAPI) record(entry JournalEntry) {
+	if !m.journaling && !m.admin {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.journal = append(m.journal, entry)
+}
+
+// Journal returns all the requests received by the MockAPI in the order they
+// were received. It is empty unless the MockAPI was created with the
+// WithRequestJournal or WithAdminAPI option.
+func (m *MockAPI) Journal() []JournalEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	journal := make([]JournalEntry, len(m.journal))
+	copy(journal, m.journal)
+	return journal
+}
+
+// Reset removes all expectations and clears the request journal along with the
+// traffic exported by ExportHAR. Expectations which have been removed will no
+// longer be matched and are not asserted when the MockAPI is closed.
+func (m *MockAPI) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, call := range m.calls {
+		call.disable()
+	}
+	m.calls = nil
+	m.journal = nil
+	m.traffic = nil
+	m.retries = nil
+	m.adminCalls = nil
+}
@@ -0,0 +1,198 @@
+package mockapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// WithIPv6 makes the HTTP server listen on an ephemeral port of the IPv6 loopback
+// interface. The URL of the MockAPI will then contain a literal IPv6 address such
+// as http://[::1]:34567. An error is returned from New if IPv6 is unavailable.
+func WithIPv6() Option {
+	return func(m *MockAPI) error {
+		m.listen = func() (net.Listener, error) {
+			return net.Listen("tcp6", "[::1]:0")
+		}
+		return nil
+	}
+}
+
+// WithDualStack makes the HTTP server listen on the same ephemeral port of both
+// the IPv4 and IPv6 loopback interfaces. The URL method returns the IPv4 URL and
+// the URLs method returns the URLs for both address families. An error is returned
+// from New if IPv6 is unavailable.
+func WithDualStack() Option {
+	return func(m *MockAPI) error {
+		m.listen = listenDualStack
+		return nil
+	}
+}
+
+// listenDualStack listens on the same port of the IPv4 and IPv6 loopback
+// interfaces. The chosen port may already be in use for IPv6 so a few
+// attempts are made.
+func listenDualStack() (net.Listener, error) {
+	var lastErr error
+	for attempt := 0; attempt < 5; attempt++ {
+		l4, err := net.Listen("tcp4", "127.0.0.1:0")
+		if err != nil {
+			return nil, err
+		}
+
+		port := l4.Addr().(*net.TCPAddr).Port
+		l6, err := net.Listen("tcp6", net.JoinHostPort("::1", strconv.Itoa(port)))
+		if err != nil {
+			l4.Close()
+			lastErr = err
+			continue
+		}
+
+		return newMultiListener(l4, l6), nil
+	}
+	return nil, fmt.Errorf("failed to listen on both the IPv4 and IPv6 loopback interfaces: %w", lastErr)
+}
+
+// WithUnixSocket makes the HTTP server listen on a unix domain socket at the given path
+// instead of a TCP port. As a socket path cannot be part of a URL, the URL of the MockAPI
+// is http://localhost and the client returned by Client dials the socket for every request.
+// SocketPath returns the path for configuring other clients. The socket file is removed
+// when the MockAPI is closed. It may not be combined with serving HTTPS.
+func WithUnixSocket(path string) Option {
+	return func(m *MockAPI) error {
+		m.unixSocket = path
+		m.listen = func() (net.Listener, error) {
+			if m.tls {
+				return nil, fmt.Errorf("HTTPS may not be served on a unix socket")
+			}
+
+			l, err := net.Listen("unix", path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to listen on unix socket %s: %w", path, err)
+			}
+			return l, nil
+		}
+		return nil
+	}
+}
+
+// SocketPath returns the path of the unix domain socket the HTTP server is listening on.
+// It is empty unless WithUnixSocket was used.
+func (m *MockAPI) SocketPath() string {
+	return m.unixSocket
+}
+
+// useUnixSocket points the URL of the server at localhost and makes the
+// server's client dial the unix socket.
+func (m *MockAPI) useUnixSocket() {
+	m.s.URL = "http://localhost"
+
+	if transport, ok := m.s.Client().Transport.(*http.Transport); ok {
+		var dialer net.Dialer
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", m.unixSocket)
+		}
+	}
+}
+
+// URLs returns the URLs for every address the HTTP server is listening on. Unless
+// WithDualStack was used this only contains the value returned by URL.
+func (m *MockAPI) URLs() []string {
+	if len(m.addrs) < 2 {
+		return []string{m.URL()}
+	}
+
+	scheme := m.URL()[:strings.Index(m.URL(), "://")]
+	urls := make([]string, 0, len(m.addrs))
+	for _, addr := range m.addrs {
+		urls = append(urls, scheme+"://"+addr.String())
+	}
+	return urls
+}
+
+var errListenerClosed = errors.New("mockapi: listener closed")
+
+// multiListener is a net.Listener accepting connections from several
+// listeners. Its address is that of the first listener.
+type multiListener struct {
+	listeners []net.Listener
+	conns     chan net.Conn
+	errs      chan error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newMultiListener(listeners ...net.Listener) *multiListener {
+	ml := &multiListener{
+		listeners: listeners,
+		conns:     make(chan net.Conn),
+		errs:      make(chan error),
+		done:      make(chan struct{}),
+	}
+	for _, l := range listeners {
+		go ml.acceptLoop(l)
+	}
+	return ml
+}
+
+func (ml *multiListener) acceptLoop(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case ml.errs <- err:
+			case <-ml.done:
+			}
+			return
+		}
+
+		select {
+		case ml.conns <- conn:
+		case <-ml.done:
+			conn.Close()
+			return
+		}
+	}
+}
+
+func (ml *multiListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-ml.conns:
+		return conn, nil
+	case err := <-ml.errs:
+		return nil, err
+	case <-ml.done:
+		return nil, errListenerClosed
+	}
+}
+
+func (ml *multiListener) Close() error {
+	var err error
+	ml.closeOnce.Do(func() {
+		close(ml.done)
+		for _, l := range ml.listeners {
+			if cerr := l.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+	})
+	return err
+}
+
+func (ml *multiListener) Addr() net.Addr {
+	return ml.listeners[0].Addr()
+}
+
+// addrs returns the addresses of all the listeners.
+func (ml *multiListener) addrs() []net.Addr {
+	addrs := make([]net.Addr, 0, len(ml.listeners))
+	for _, l := range ml.listeners {
+		addrs = append(addrs, l.Addr())
+	}
+	return addrs
+}
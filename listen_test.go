@@ -0,0 +1,168 @@
+package mockapi
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func requireIPv6(t *testing.T) {
+	l, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback is unavailable: %v", err)
+	}
+	l.Close()
+}
+
+func TestWithIPv6(t *testing.T) {
+	requireIPv6(t)
+
+	m := NewMockAPI(t, WithIPv6())
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	if !strings.HasPrefix(m.URL(), "http://[::1]:") {
+		t.Fatalf("Expected an IPv6 URL but got %s", m.URL())
+	}
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/v6"), 200).Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/v6", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /v6: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestWithDualStack(t *testing.T) {
+	requireIPv6(t)
+
+	m := NewMockAPI(t, WithDualStack())
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	urls := m.URLs()
+	if len(urls) != 2 || !strings.HasPrefix(urls[0], "http://127.0.0.1:") || !strings.HasPrefix(urls[1], "http://[::1]:") {
+		t.Fatalf("Unexpected URLs: %v", urls)
+	}
+	if urls[0] != m.URL() {
+		t.Fatalf("Expected the first URL to be %s but got %s", m.URL(), urls[0])
+	}
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/dual"), 200).Twice()
+
+	for _, url := range urls {
+		resp, err := http.Get(fmt.Sprintf("%s/dual", url))
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s/dual: %v", url, err)
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestWithListener(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	expected := fmt.Sprintf("http://%s", l.Addr())
+
+	m := NewMockAPI(t, WithListener(l))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	if m.URL() != expected {
+		t.Fatalf("Expected the URL to be %s but got %s", expected, m.URL())
+	}
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/fixed"), 200).Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/fixed", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /fixed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestWithListenAddress(t *testing.T) {
+	// find a free port to use as the fixed port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error listening: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	m := NewMockAPI(t, WithListenAddress(addr))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	if m.URL() != "http://"+addr {
+		t.Fatalf("Expected the URL to be http://%s but got %s", addr, m.URL())
+	}
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/fixed"), 200).Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/fixed", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /fixed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestWithUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+
+	m := NewMockAPI(t, WithUnixSocket(path), WithoutCleanup())
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Connection",
+	})
+
+	if m.SocketPath() != path {
+		t.Fatalf("Expected the socket path to be %s but got %s", path, m.SocketPath())
+	}
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/_ping"), 200).Twice()
+
+	resp, err := m.Client().Get(fmt.Sprintf("%s/_ping", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /_ping: %v", err)
+	}
+	resp.Body.Close()
+
+	conn, err := net.Dial("unix", m.SocketPath())
+	if err != nil {
+		t.Fatalf("Error dialing %s: %v", m.SocketPath(), err)
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "GET /_ping HTTP/1.1\r\nHost: docker\r\nConnection: close\r\n\r\n")
+	resp, err = http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("Error reading response: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	m.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected the socket file to be removed but got: %v", err)
+	}
+}
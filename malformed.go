@@ -0,0 +1,101 @@
+package mockapi
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// MalformedResponse is a kind of canned invalid HTTP/1.1 response.
+type MalformedResponse int
+
+const (
+	// MalformedShortContentLength sends a Content-Length header which is smaller than the
+	// size of the body that follows.
+	MalformedShortContentLength MalformedResponse = iota
+	// MalformedLongContentLength sends a Content-Length header which is larger than the
+	// size of the body and then closes the connection.
+	MalformedLongContentLength
+	// MalformedChunkedEncoding sends a chunked body with invalid chunk framing.
+	MalformedChunkedEncoding
+	// MalformedHeaderBytes sends headers containing bytes which are not allowed
+	// in header names or values.
+	MalformedHeaderBytes
+	// MalformedDuplicateContentLength sends two Content-Length headers with
+	// conflicting values.
+	MalformedDuplicateContentLength
+)
+
+var malformedResponses = map[MalformedResponse]string{
+	MalformedShortContentLength: "HTTP/1.1 200 OK\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"Content-Length: 5\r\n" +
+		"\r\n" +
+		"hello world",
+	MalformedLongContentLength: "HTTP/1.1 200 OK\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"Content-Length: 100\r\n" +
+		"\r\n" +
+		"hello world",
+	MalformedChunkedEncoding: "HTTP/1.1 200 OK\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"Transfer-Encoding: chunked\r\n" +
+		"\r\n" +
+		"zz\r\n" +
+		"hello world\r\n" +
+		"0\r\n" +
+		"\r\n",
+	MalformedHeaderBytes: "HTTP/1.1 200 OK\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"Bad Header\x01: bad\x00value\r\n" +
+		"Content-Length: 11\r\n" +
+		"\r\n" +
+		"hello world",
+	MalformedDuplicateContentLength: "HTTP/1.1 200 OK\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"Content-Length: 11\r\n" +
+		"Content-Length: 12\r\n" +
+		"\r\n" +
+		"hello world",
+}
+
+// MalformedResponder creates a MockResponse which writes the given kind of malformed
+// response directly to the connection and then closes it. Only HTTP/1.x connections
+// are supported, for others the response is aborted.
+func MalformedResponder(kind MalformedResponse) (MockResponse, error) {
+	raw, ok := malformedResponses[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown malformed response kind: %d", kind)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+
+		conn := hijack(w)
+		defer conn.Close()
+		io.WriteString(conn, raw)
+	}, nil
+}
+
+// MalformedFault creates a Fault which replaces the response with the given kind of
+// malformed response. An error is returned for an unknown kind.
+func MalformedFault(kind MalformedResponse) (Fault, error) {
+	resp, err := MalformedResponder(kind)
+	if err != nil {
+		return nil, err
+	}
+	return func(next MockResponse) MockResponse {
+		return resp
+	}, nil
+}
+
+// WithMalformedReply will setup an expectation for an API call to be made. The response
+// will be the given kind of malformed HTTP response which allows testing the hardening
+// of HTTP clients. An invalid kind will fail the test object passed into the NewMockAPI
+// constructor if that was non-nil and if it was nil, will panic.
+func (m *MockAPI) WithMalformedReply(req *MockRequest, kind MalformedResponse) *MockAPICall {
+	resp, err := MalformedResponder(kind)
+	checkError(m.t, err)
+	return m.WithRequest(req, resp)
+}
@@ -0,0 +1,73 @@
+package mockapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestWithMalformedReply(t *testing.T) {
+	kinds := map[string]MalformedResponse{
+		"long-content-length":      MalformedLongContentLength,
+		"chunked-encoding":         MalformedChunkedEncoding,
+		"header-bytes":             MalformedHeaderBytes,
+		"duplicate-content-length": MalformedDuplicateContentLength,
+	}
+
+	for name, kind := range kinds {
+		kind := kind
+		t.Run(name, func(t *testing.T) {
+			m := NewMockAPI(t)
+			m.SetFilteredHeaders([]string{
+				"Accept-Encoding",
+				"User-Agent",
+				"Connection",
+			})
+
+			m.WithMalformedReply(NewMockRequest("GET", "/my/endpoint"), kind).Once()
+
+			client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+			resp, err := client.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+			if err == nil {
+				_, err = ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+			}
+
+			if err == nil {
+				t.Fatalf("Expected the client to fail handling the malformed response")
+			}
+		})
+	}
+}
+
+func TestMalformedFault(t *testing.T) {
+	if _, err := MalformedFault(MalformedResponse(-1)); err == nil {
+		t.Fatalf("Expected an error creating a fault for an unknown kind of malformed response")
+	}
+
+	fault, err := MalformedFault(MalformedChunkedEncoding)
+	if err != nil {
+		t.Fatalf("Error creating the malformed response fault: %v", err)
+	}
+
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Connection",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/my/endpoint"), 200).Inject(fault).Once()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+	if err == nil {
+		_, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	if err == nil {
+		t.Fatalf("Expected the client to fail handling the malformed response")
+	}
+}
@@ -0,0 +1,230 @@
+package mockapi
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// receivedRequest holds everything about a received request which may be
+// needed to evaluate the additional conditions of an expectation. It is
+// passed as the final argument when recording a call with the mock.
+type receivedRequest struct {
+	r         *http.Request
+	bodyBytes []byte
+	trailers  map[string]string
+
+	// digests caches the hex encoded digests of the body by algorithm
+	digests map[string]string
+}
+
+// digest returns the hex encoded digest of the body using the given algorithm.
+func (rr *receivedRequest) digest(algorithm string) string {
+	if digest, ok := rr.digests[algorithm]; ok {
+		return digest
+	}
+
+	h := digestAlgorithms[algorithm]()
+	h.Write(rr.bodyBytes)
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	if rr.digests == nil {
+		rr.digests = make(map[string]string)
+	}
+	rr.digests[algorithm] = digest
+	return digest
+}
+
+// streamedDigests returns the algorithms of the body digest expectations when they are the
+// only expectations of the MockAPI. The body of requests then need not be buffered and is
+// instead hashed while it is read. It returns nil whenever anything else may need the body,
+// such as the journal, snapshots, default routes or Matching predicates.
+func (m *MockAPI) streamedDigests() []string {
+	if m.contract != nil || m.cassette != nil || m.captureTraffic || m.snapshot != nil || m.journaling || m.admin {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.defaultRoutes) > 0 {
+		return nil
+	}
+
+	var algorithms []string
+	seen := make(map[string]bool)
+	for _, call := range m.calls {
+		algorithm := call.req.bodyDigest
+		if algorithm == "" || call.req.readsBody {
+			return nil
+		}
+		if !seen[algorithm] {
+			seen[algorithm] = true
+			algorithms = append(algorithms, algorithm)
+		}
+	}
+	return algorithms
+}
+
+// hashBody reads the body to its end, hashing it with each of the algorithms, and
+// returns the hex encoded digests by algorithm. When the body cannot be read all the
+// digests are empty so that no digest expectation matches the truncated body.
+func hashBody(body io.Reader, algorithms []string) map[string]string {
+	hashes := make(map[string]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, 0, len(algorithms))
+	for _, algorithm := range algorithms {
+		h := digestAlgorithms[algorithm]()
+		hashes[algorithm] = h
+		writers = append(writers, h)
+	}
+
+	_, err := io.Copy(io.MultiWriter(writers...), body)
+
+	digests := make(map[string]string, len(hashes))
+	for algorithm, h := range hashes {
+		if err == nil {
+			digests[algorithm] = hex.EncodeToString(h.Sum(nil))
+		} else {
+			digests[algorithm] = ""
+		}
+	}
+	return digests
+}
+
+var digestAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// String keeps the output of testify's call diffs readable.
+func (rr *receivedRequest) String() string {
+	return rr.r.Method + " " + rr.r.URL.String()
+}
+
+// requestMatcher is an additional condition that a received request must
+// meet in order to match an expectation.
+type requestMatcher func(*receivedRequest) bool
+
+// requestMatcher returns the argument to use for matching the additional
+// conditions of the request. The request is only matched while active returns
+// true.
+func (r *MockRequest) requestMatcher(active func() bool) interface{} {
+	matchers := r.matchers
+	return mock.MatchedBy(func(rr *receivedRequest) bool {
+		if !active() {
+			return false
+		}
+		for _, matcher := range matchers {
+			if !matcher(rr) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// WithBodyDigest will expect the request body to have the given hex encoded digest when
+// hashed with the algorithm. Supported algorithms are md5, sha1, sha256 and sha512.
+// This allows asserting the contents of large uploads without holding the expected
+// content in the test. It may not be combined with any other body expectation.
+//
+// When every expectation of the MockAPI is a body digest expectation, bodies are hashed
+// while they are read rather than being buffered. The body is then not available to the
+// MockResponses of those expectations or their captured requests. Bodies are always
+// buffered when anything else may need them, such as the journal, snapshots, default
+// routes or Matching predicates.
+func (r *MockRequest) WithBodyDigest(algorithm, expectedHex string) *MockRequest {
+	r.setBodyOption("WithBodyDigest")
+	if _, ok := digestAlgorithms[algorithm]; !ok {
+		r.errs = append(r.errs, fmt.Errorf("unsupported body digest algorithm %q", algorithm))
+		return r
+	}
+
+	expected := strings.ToLower(expectedHex)
+	r.bodyDigest = algorithm
+	r.body = mock.Anything
+	r.matchers = append(r.matchers, func(rr *receivedRequest) bool {
+		return rr.digest(algorithm) == expected
+	})
+	return r
+}
+
+// WithBodySubset will expect the request body to be a JSON object containing at least the
+// given keys with the given values. Any other fields of the body are ignored. Nested
+// objects are matched the same way while all other values, including arrays, must be
+// equal. It may not be combined with any other body expectation.
+func (r *MockRequest) WithBodySubset(subset map[string]interface{}) *MockRequest {
+	r.setBodyOption("WithBodySubset")
+
+	data, err := json.Marshal(subset)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("failed to encode expected JSON body subset: %w", err))
+		return r
+	}
+	var want map[string]interface{}
+	json.Unmarshal(data, &want)
+
+	r.body = mock.Anything
+	r.matchers = append(r.matchers, func(rr *receivedRequest) bool {
+		var got map[string]interface{}
+		if err := json.Unmarshal(rr.bodyBytes, &got); err != nil || got == nil {
+			return false
+		}
+		return containsJSON(got, want)
+	})
+	return r
+}
+
+// containsJSON returns whether the decoded JSON value got contains want. Objects
+// contain another object when they have all of its fields with values containing
+// the values of the other object. All other values must be equal.
+func containsJSON(got, want interface{}) bool {
+	wantObject, ok := want.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(got, want)
+	}
+
+	gotObject, ok := got.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for key, wantValue := range wantObject {
+		gotValue, ok := gotObject[key]
+		if !ok || !containsJSON(gotValue, wantValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matching will require the request to satisfy the given predicate in addition to
+// all the other expectations. The predicate receives the raw request, with the body
+// still available to be read, and may be used for any conditions which cannot be
+// expressed through the other methods of MockRequest. The predicate may be invoked
+// multiple times for the same request and so must not have side effects.
+func (r *MockRequest) Matching(predicate func(r *http.Request) bool) *MockRequest {
+	r.readsBody = true
+	r.matchers = append(r.matchers, func(rr *receivedRequest) bool {
+		rr.r.Body = ioutil.NopCloser(bytes.NewReader(rr.bodyBytes))
+		defer func() {
+			rr.r.Body = ioutil.NopCloser(bytes.NewReader(rr.bodyBytes))
+		}()
+		return predicate(rr.r)
+	})
+	return r
+}
@@ -0,0 +1,266 @@
+package mockapi
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWithTrailers(t *testing.T) {
+	m := NewMockAPI(t, WithRequestJournal())
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Trailer",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("POST", "/upload").
+		WithBody(mock.Anything).
+		WithTrailers(map[string]string{"Checksum": "abc123"}), 200).Once()
+
+	pr, pw := io.Pipe()
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/upload", m.URL()), pr)
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+	req.Trailer = http.Header{"Checksum": nil}
+
+	go func() {
+		pw.Write([]byte("some data"))
+		req.Trailer.Set("Checksum", "abc123")
+		pw.Close()
+	}()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error issuing POST of /upload: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status code but got %d", resp.StatusCode)
+	}
+
+	journal := m.Journal()
+	if len(journal) != 1 || journal[0].Trailers["Checksum"] != "abc123" {
+		t.Fatalf("Trailers were not recorded in the journal: %+v", journal)
+	}
+}
+
+func TestWithBodyDigest(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	// sha256 of "hello world"
+	m.WithNoResponseBody(NewMockRequest("POST", "/upload").
+		WithBodyDigest("sha256", "B94D27B9934D3E08A52E52D7DA7DABFAC484EFE37A5380EE9088F7ACE2EFCDE9"), 200).Once()
+
+	resp, err := http.Post(fmt.Sprintf("%s/upload", m.URL()), "text/plain", strings.NewReader("hello world"))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /upload: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status code but got %d", resp.StatusCode)
+	}
+}
+
+func TestMatching(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	m.WithTextReply(NewMockRequest("POST", "/echo").
+		WithBody(mock.Anything).
+		Matching(func(r *http.Request) bool {
+			body, err := ioutil.ReadAll(r.Body)
+			return err == nil && strings.HasPrefix(string(body), "hello")
+		}), 200, "matched").Once()
+
+	resp, err := http.Post(fmt.Sprintf("%s/echo", m.URL()), "text/plain", strings.NewReader("hello world"))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /echo: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil || string(body) != "matched" {
+		t.Fatalf("Didn't get the expected response: %q %v", body, err)
+	}
+}
+
+func TestWithBodySubset(t *testing.T) {
+	rt := &recordingT{}
+	m := NewMockAPI(rt)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("POST", "/events").WithBodySubset(map[string]interface{}{
+		"type":  "deploy",
+		"count": 2,
+		"meta":  map[string]interface{}{"env": "prod"},
+	}), 200).Maybe()
+
+	for body, expected := range map[string]int{
+		`{"type":"deploy","count":2,"meta":{"env":"prod"}}`:                               200,
+		`{"type":"deploy","count":2,"meta":{"env":"prod","region":"us"},"ts":1700000000}`: 200,
+		`{"type":"deploy","count":3,"meta":{"env":"prod"}}`:                               404,
+		`{"type":"deploy","count":2}`:                                                     404,
+		`["type","deploy"]`:                                                               404,
+	} {
+		resp, err := http.Post(fmt.Sprintf("%s/events", m.URL()), "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Error issuing POST of /events: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != expected {
+			t.Fatalf("Expected a %d status code for %s but got %d", expected, body, resp.StatusCode)
+		}
+	}
+	m.Close()
+}
+
+func TestWithBodyDigestStreamed(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Type",
+		"Content-Length",
+	})
+
+	// sha1 of "hello world"
+	hello := m.WithNoResponseBody(NewMockRequest("POST", "/hello").
+		WithBodyDigest("sha1", "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed"), 200).Once()
+	// sha256 of 1MiB of zeroes
+	m.WithNoResponseBody(NewMockRequest("POST", "/zeroes").
+		WithBodyDigest("sha256", "30e14955ebf1352266dc2ff8067e68104607e750abb9d3b36582b8af909fcb58"), 200).Once()
+
+	bodies := map[string]io.Reader{
+		"/hello":  strings.NewReader("hello world"),
+		"/zeroes": io.LimitReader(zeroReader{}, 1<<20),
+	}
+	for path, body := range bodies {
+		resp, err := http.Post(fmt.Sprintf("%s%s", m.URL(), path), "application/octet-stream", body)
+		if err != nil {
+			t.Fatalf("Error issuing POST of %s: %v", path, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			t.Fatalf("Expected a 200 status code for %s but got %d", path, resp.StatusCode)
+		}
+	}
+
+	// the body was hashed as it was read rather than being buffered
+	if requests := hello.Requests(); len(requests) != 1 || requests[0].Body() != nil {
+		t.Fatalf("Expected the body of the matched request not to be buffered")
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestWithBodyDigestBufferedForOtherConsumers(t *testing.T) {
+	// sha1 of "hello world"
+	const digest = "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed"
+
+	t.Run("default-handler", func(t *testing.T) {
+		m := NewMockAPI(t)
+		m.SetFilteredHeaders([]string{
+			"Accept-Encoding",
+			"User-Agent",
+			"Content-Type",
+			"Content-Length",
+		})
+
+		m.WithNoResponseBody(NewMockRequest("POST", "/hello").WithBodyDigest("sha1", digest), 200).Maybe()
+		m.DefaultHandler(func(w http.ResponseWriter, r *http.Request) {
+			io.Copy(w, r.Body)
+		})
+
+		resp, err := http.Post(fmt.Sprintf("%s/other", m.URL()), "text/plain", strings.NewReader("hello world"))
+		if err != nil {
+			t.Fatalf("Error issuing POST of /other: %v", err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if string(body) != "hello world" {
+			t.Fatalf("Expected the default handler to echo the body but got %q", body)
+		}
+	})
+
+	t.Run("matching", func(t *testing.T) {
+		m := NewMockAPI(t)
+		m.SetFilteredHeaders([]string{
+			"Accept-Encoding",
+			"User-Agent",
+			"Content-Type",
+			"Content-Length",
+		})
+
+		m.WithNoResponseBody(NewMockRequest("POST", "/hello").WithBodyDigest("sha1", digest).Matching(func(r *http.Request) bool {
+			body, _ := ioutil.ReadAll(r.Body)
+			return string(body) == "hello world"
+		}), 200).Once()
+
+		resp, err := http.Post(fmt.Sprintf("%s/hello", m.URL()), "text/plain", strings.NewReader("hello world"))
+		if err != nil {
+			t.Fatalf("Error issuing POST of /hello: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			t.Fatalf("Expected the predicate to see the body but got status %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("journal", func(t *testing.T) {
+		m := NewMockAPI(t, WithRequestJournal())
+		m.SetFilteredHeaders([]string{
+			"Accept-Encoding",
+			"User-Agent",
+			"Content-Type",
+			"Content-Length",
+		})
+
+		m.WithNoResponseBody(NewMockRequest("POST", "/hello").WithBodyDigest("sha1", digest), 200).Once()
+
+		resp, err := http.Post(fmt.Sprintf("%s/hello", m.URL()), "text/plain", strings.NewReader("hello world"))
+		if err != nil {
+			t.Fatalf("Error issuing POST of /hello: %v", err)
+		}
+		resp.Body.Close()
+
+		if journal := m.Journal(); len(journal) != 1 || journal[0].Body == nil {
+			t.Fatalf("Expected the journal to hold the body: %+v", journal)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithMetrics enables serving metrics in the Prometheus text exposition format
+// at the given path. The following metrics are reported:
+//
+//	mockapi_requests_total           - counter of requests by method, path and status code
+//	mockapi_request_duration_seconds - summary of the time spent handling requests by method and path
+//
+// Requests for the metrics path are not matched against the expectations.
+func WithMetrics(path string) Option {
+	return func(m *MockAPI) error {
+		m.metrics = &metrics{
+			path:      path,
+			requests:  make(map[requestMetricKey]uint64),
+			durations: make(map[durationMetricKey]*durationMetric),
+		}
+		return nil
+	}
+}
+
+type requestMetricKey struct {
+	method string
+	path   string
+	status int
+}
+
+type durationMetricKey struct {
+	method string
+	path   string
+}
+
+type durationMetric struct {
+	sum   time.Duration
+	count uint64
+}
+
+type metrics struct {
+	path string
+
+	mu        sync.Mutex
+	requests  map[requestMetricKey]uint64
+	durations map[durationMetricKey]*durationMetric
+}
+
+func (m *metrics) observe(method, path string, status int, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestMetricKey{method: method, path: path, status: status}]++
+
+	key := durationMetricKey{method: method, path: path}
+	d, ok := m.durations[key]
+	if !ok {
+		d = &durationMetric{}
+		m.durations[key] = d
+	}
+	d.sum += elapsed
+	d.count++
+}
+
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	var requests []string
+	for key, count := range m.requests {
+		requests = append(requests, fmt.Sprintf("mockapi_requests_total{method=%s,path=%s,status=\"%d\"} %d\n",
+			escapeLabel(key.method), escapeLabel(key.path), key.status, count))
+	}
+	var durations []string
+	for key, d := range m.durations {
+		labels := fmt.Sprintf("method=%s,path=%s", escapeLabel(key.method), escapeLabel(key.path))
+		durations = append(durations, fmt.Sprintf("mockapi_request_duration_seconds_sum{%s} %g\nmockapi_request_duration_seconds_count{%s} %d\n",
+			labels, d.sum.Seconds(), labels, d.count))
+	}
+	m.mu.Unlock()
+
+	sort.Strings(requests)
+	sort.Strings(durations)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintf(w, "# HELP mockapi_requests_total Number of requests served by the mock API.\n")
+	fmt.Fprintf(w, "# TYPE mockapi_requests_total counter\n")
+	for _, line := range requests {
+		fmt.Fprint(w, line)
+	}
+	fmt.Fprintf(w, "# HELP mockapi_request_duration_seconds Time spent handling requests.\n")
+	fmt.Fprintf(w, "# TYPE mockapi_request_duration_seconds summary\n")
+	for _, line := range durations {
+		fmt.Fprint(w, line)
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel quotes a label value as required by the Prometheus text format.
+func escapeLabel(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
@@ -0,0 +1,46 @@
+package mockapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMockAPI(t, WithMetrics("/metrics"))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/my/endpoint"), 204).Twice()
+
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+		if err != nil {
+			t.Fatalf("Error issuing GET of /my/endpoint: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	resp, err := http.Get(fmt.Sprintf("%s/metrics", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Error reading metrics: %v", err)
+	}
+
+	expected := `mockapi_requests_total{method="GET",path="/my/endpoint",status="204"} 2`
+	if !strings.Contains(string(body), expected) {
+		t.Fatalf("Metrics output did not contain %q:\n%s", expected, body)
+	}
+	if !strings.Contains(string(body), `mockapi_request_duration_seconds_count{method="GET",path="/my/endpoint"} 2`) {
+		t.Fatalf("Metrics output did not contain the request durations:\n%s", body)
+	}
+}
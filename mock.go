@@ -1,14 +1,25 @@
 package mockapi
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -46,33 +57,120 @@ type MockRequest struct {
 	method      string
 	path        string
 	body        interface{}
-	headers     map[string]string
-	queryParams map[string]string
+	headers     http.Header
+	queryParams url.Values
+
+	// headerMatchers are conditions on single headers which are excluded from
+	// the exact comparison with headers.
+	headerMatchers map[string]headerMatcher
+
+	// headerSubset allows the request to have headers besides the expected ones.
+	headerSubset bool
+
+	// queryPresent are the query params which must be present with any values.
+	queryPresent map[string]struct{}
+
+	// querySubset allows the request to have query params besides the expected ones.
+	querySubset bool
+
+	// pathPattern, when set, is matched against the path instead of
+	// requiring it to equal path.
+	pathPattern *regexp.Regexp
+
+	// matchers are additional conditions the received request must meet
+	matchers []requestMatcher
+
+	// bodyDiff, when set, renders the difference between the expected body and
+	// a received body which cannot be rendered by the Differ.
+	bodyDiff func(body []byte) string
+
+	// bodyOption is the name of the builder method which set the body
+	// expectation. It is used to detect conflicting body expectations.
+	bodyOption string
+
+	// bodyDigest is the algorithm of the body digest expectation, if any.
+	bodyDigest string
+	// readsBody is set when a matcher may read the body of the request
+	// itself and so it must always be buffered.
+	readsBody bool
+
+	// errs holds any errors from building the request. They are reported
+	// when the expectation is registered.
+	errs []error
 }
 
 // NewMockRequest will create a new MockRequest. Other With* methods
-// can then be called to build out the other parts of the expected request
+// can then be called to build out the other parts of the expected request.
+//
+// The path may be a template such as "/users/{id}/posts/{postID}" where each
+// parameter matches any value of a path segment. The values are available to
+// the MockResponse through PathParam.
 func NewMockRequest(method, path string) *MockRequest {
-	return &MockRequest{
+	req := &MockRequest{
 		method: method,
 		path:   path,
 	}
+
+	if strings.Contains(path, "{") || strings.Contains(path, "}") {
+		pattern, err := compilePathTemplate(path)
+		if err != nil {
+			req.errs = append(req.errs, err)
+		}
+		req.pathPattern = pattern
+	}
+	return req
 }
 
 func (r *MockRequest) WithBody(body interface{}) *MockRequest {
+	r.setBodyOption("WithBody")
 	r.body = body
 	return r
 }
 
 // WithHeaders will set these headers to be expected in the request
 func (r *MockRequest) WithHeaders(headers map[string]string) *MockRequest {
-	r.headers = headers
+	for name, value := range headers {
+		r.WithHeaderValues(name, value)
+	}
+	return r
+}
+
+// WithHeaderValues will expect the header to be present in the request with exactly
+// these values in this order, such as for repeated X-Forwarded-For headers.
+func (r *MockRequest) WithHeaderValues(name string, values ...string) *MockRequest {
+	if r.headers == nil {
+		r.headers = make(http.Header)
+	}
+	r.headers[name] = values
 	return r
 }
 
 // WithQueryParams will set these query params to be expected in the request
 func (r *MockRequest) WithQueryParams(params map[string]string) *MockRequest {
-	r.queryParams = params
+	for name, value := range params {
+		r.WithQueryValues(url.Values{name: {value}})
+	}
+	return r
+}
+
+// WithQueryValues will expect the query params to be present in the request with
+// exactly these values in this order, such as for repeated params like ?tag=a&tag=b.
+func (r *MockRequest) WithQueryValues(params url.Values) *MockRequest {
+	for name, values := range params {
+		if r.queryParams == nil {
+			r.queryParams = make(url.Values)
+		}
+		r.queryParams[name] = values
+	}
+	return r
+}
+
+// WithTrailers will set these trailers to be expected in the request. The
+// trailers are only available once the whole request body has been read.
+func (r *MockRequest) WithTrailers(trailers map[string]string) *MockRequest {
+	r.matchers = append(r.matchers, func(rr *receivedRequest) bool {
+		return assert.ObjectsAreEqual(trailers, rr.trailers)
+	})
 	return r
 }
 
@@ -87,10 +185,89 @@ type MockAPI struct {
 	s *httptest.Server
 	t TestingT
 
-	filteredHeaders map[string]struct{}
-	filteredParams  map[string]struct{}
+	noCleanup bool
+
+	// alt serves the other scheme when serving both HTTP and HTTPS
+	alt *httptest.Server
+
+	listen   func() (net.Listener, error)
+	addrs    []net.Addr
+	tls      bool
+	http2    bool
+	h2c      bool
+	tlsFault *tlsFaultConfig
+	sniCerts map[string]tls.Certificate
+	// clientCAs verify the certificates clients are required to present
+	clientCAs *x509.CertPool
+	// unixSocket is the path of the unix domain socket being served on
+	unixSocket string
+
+	timeouts    ServerTimeouts
+	bodyTimeout time.Duration
+
+	// startHooks start additional servers sharing the expectations once the
+	// HTTP server is running. The functions they return stop those servers.
+	startHooks []func() (func(), error)
+	admin      bool
+	metrics    *metrics
+	spanHook   SpanHook
+
+	recorder     *CallRecorder
+	recorderName string
+	report       *failureReport
+	differ       Differ
+	groups       map[string]*Group
+	scenarios    map[string]*Scenario
+	snapshot     *snapshotRecorder
+	cassette     *cassetteRecorder
+	contract     *contractValidator
+	// captureTraffic records the requests and responses for ExportHAR
+	captureTraffic bool
+	// journaling records the requests in the journal
+	journaling bool
+
+	correlationHeader string
+	requestLog        bool
+	requestCount      uint64
+
+	writeLimiter *rateLimiter
+	readLimiter  *rateLimiter
+
+	filteredHeaders  map[string]struct{}
+	filteredParams   map[string]struct{}
+	canonicalHeaders bool
+
+	// closing is closed when the MockAPI is shutting down so that any
+	// responders blocked in WaitUntil can unwind.
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	// releasing is closed as soon as the MockAPI starts shutting down so that
+	// any hung requests are abandoned.
+	releasing   chan struct{}
+	releaseOnce sync.Once
+
+	scratchpad Scratchpad
+
+	// mu protects the fields below
+	mu         sync.Mutex
+	calls      []*MockAPICall
+	journal    []JournalEntry
+	traffic    []HAREntry
+	retries    []*retryAfter
+	adminID    int
+	adminCalls []*adminExpectation
+	deadlines  []*time.Timer
+	stops      []func()
+	chaos      *chaos
+	rand       *rand.Rand
 
 	m mock.Mock
+
+	// defaults holds the default handlers which are used for requests not
+	// matching any expectation.
+	defaults      mock.Mock
+	defaultRoutes []defaultRoute
 }
 
 // NewMockAPI creates a MockAPI. If `t` supports the Go 1.14 Cleanup function
@@ -98,16 +275,133 @@ type MockAPI struct {
 // completes. This will teardown the HTTP server and assert that all the
 // required HTTP calls were made. If not using Go 1.14 then the caller
 // should ensure that Close() is called in order to properly shut things down.
-func NewMockAPI(t TestingT) *MockAPI {
-	mapi := MockAPI{t: t}
-	mapi.m.Test(t)
-	mapi.s = httptest.NewServer(&mapi)
+// Any additional options are applied in the same manner as for New.
+func NewMockAPI(t TestingT, opts ...Option) *MockAPI {
+	mapi, err := New(append([]Option{WithTestingT(t)}, opts...)...)
+	checkError(t, err)
+	return mapi
+}
+
+// New creates a MockAPI configured with the given options. Unlike NewMockAPI, a
+// TestingT is not required which allows the MockAPI to be used outside of tests
+// (for example in demo servers or sandboxes). Without a TestingT, requests
+// which do not match any expectation are replied to with a 404 status code
+// and Close will not assert that the expected requests were made.
+func New(opts ...Option) (*MockAPI, error) {
+	mapi, err := newMockAPI(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mapi.start(); err != nil {
+		return nil, err
+	}
+
+	mapi.registerCleanup()
+	return mapi, nil
+}
+
+// NewMockHandler creates a MockAPI which does not start an HTTP server. Instead its
+// expectations are served by the http.Handler returned from Handler, which may be
+// mounted within another server, combined with other handlers or wrapped with custom
+// middleware. Cleanup is registered in the same manner as for NewMockAPI.
+func NewMockHandler(t TestingT, opts ...Option) *MockAPI {
+	mapi, err := NewHandler(append([]Option{WithTestingT(t)}, opts...)...)
+	checkError(t, err)
+	return mapi
+}
+
+// NewHandler is like New but does not start an HTTP server. See NewMockHandler. Options
+// which configure the HTTP server, such as WithTLS or WithListenAddress, may not be used.
+func NewHandler(opts ...Option) (*MockAPI, error) {
+	mapi, err := newMockAPI(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if mapi.listen != nil || mapi.tls || mapi.http2 || mapi.h2c || mapi.tlsFault != nil || len(mapi.sniCerts) > 0 || mapi.clientCAs != nil || len(mapi.startHooks) > 0 {
+		return nil, fmt.Errorf("options configuring the HTTP server may not be used without one")
+	}
+
+	mapi.registerCleanup()
+	return mapi, nil
+}
+
+// newMockAPI creates a MockAPI with the options applied.
+func newMockAPI(opts []Option) (*MockAPI, error) {
+	mapi := &MockAPI{closing: make(chan struct{}), releasing: make(chan struct{})}
+	for _, opt := range opts {
+		if err := opt(mapi); err != nil {
+			return nil, err
+		}
+	}
+	return mapi, nil
+}
+
+// start starts the HTTP server along with any additional servers.
+func (m *MockAPI) start() error {
+	m.s = httptest.NewUnstartedServer(m)
+	if m.h2c && !m.tls {
+		m.s.Config.Handler = m.h2cHandler()
+	}
+	if m.listen != nil {
+		m.s.Listener.Close()
+		l, err := m.listen()
+		if err != nil {
+			return err
+		}
+		m.s.Listener = l
+
+		if ml, ok := l.(*multiListener); ok {
+			m.addrs = ml.addrs()
+		}
+	}
 
-	if cleanupT, canUseCleanup := t.(CleanerT); canUseCleanup {
-		cleanupT.Cleanup(mapi.Close)
+	if m.tlsFault != nil {
+		l, err := newTLSFaultListener(m.s.Listener, m.tlsFault)
+		if err != nil {
+			m.s.Listener.Close()
+			return err
+		}
+		m.s.Listener = l
 	}
 
-	return &mapi
+	m.s.TLS = m.serverTLSConfig()
+
+	m.applyTimeouts(m.s.Config)
+	m.s.EnableHTTP2 = m.http2
+	if m.tls {
+		m.s.StartTLS()
+	} else {
+		m.s.Start()
+	}
+
+	if m.unixSocket != "" {
+		m.useUnixSocket()
+	}
+
+	for _, start := range m.startHooks {
+		stop, err := start()
+		if err != nil {
+			m.s.Close()
+			m.stopServers()
+			return err
+		}
+		m.stops = append(m.stops, stop)
+	}
+
+	return nil
+}
+
+// registerCleanup closes the MockAPI when the test completes if the TestingT
+// supports it.
+func (m *MockAPI) registerCleanup() {
+	if m.noCleanup {
+		return
+	}
+	if cleanupT, canUseCleanup := m.t.(CleanerT); canUseCleanup {
+		cleanupT.Cleanup(m.Close)
+	}
 }
 
 // SetFilteredHeaders sets a list of headers that shouldn't be taken into
@@ -115,6 +409,9 @@ func NewMockAPI(t TestingT) *MockAPI {
 func (m *MockAPI) SetFilteredHeaders(headers []string) {
 	hdrMap := make(map[string]struct{})
 	for _, hdr := range headers {
+		if m.canonicalHeaders {
+			hdr = http.CanonicalHeaderKey(hdr)
+		}
 		hdrMap[hdr] = struct{}{}
 	}
 	m.filteredHeaders = hdrMap
@@ -133,63 +430,322 @@ func (m *MockAPI) SetFilteredQueryParams(params []string) {
 // URL returns the URL the HTTP server is listening on. It will have the
 // form described for the httptest.Server's URL field
 // https://pkg.go.dev/net/http/httptest#Server
+// It is empty when the MockAPI was created without an HTTP server.
 func (m *MockAPI) URL() string {
+	if m.s == nil {
+		return ""
+	}
 	return m.s.URL
 }
 
+// Handler returns an http.Handler serving the expectations of the MockAPI. It may
+// be used with a MockAPI created by NewMockHandler or in addition to the MockAPI's
+// own HTTP server.
+func (m *MockAPI) Handler() http.Handler {
+	return http.HandlerFunc(m.ServeHTTP)
+}
+
 // ServeHTTP implements the HTTP.Handler interface
 func (m *MockAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if m.admin && strings.HasPrefix(r.URL.Path, adminPathPrefix) {
+		m.serveAdmin(w, r)
+		return
+	}
+
+	if m.metrics != nil && r.URL.Path == m.metrics.path {
+		m.metrics.ServeHTTP(w, r)
+		return
+	}
+
+	r = m.withRequestID(w, r)
+
+	if m.metrics != nil || m.spanHook != nil {
+		start := time.Now()
+		sw := newStatusWriter(w)
+		defer func() {
+			m.observe(r, sw.Status(), start)
+		}()
+		w = sw
+	}
+
+	if m.writeLimiter != nil {
+		w = &throttledWriter{wrappedWriter: wrappedWriter{w}, ctx: r.Context(), limiter: m.writeLimiter}
+	}
+
+	if m.readLimiter != nil && r.Body != nil {
+		r.Body = &throttledReader{ReadCloser: r.Body, ctx: r.Context(), limiter: m.readLimiter}
+	}
+
+	m.handle(w, r)
+}
+
+// observe reports the handling of a request to the metrics and span hook.
+func (m *MockAPI) observe(r *http.Request, status int, start time.Time) {
+	if m.metrics != nil {
+		m.metrics.observe(r.Method, r.URL.Path, status, time.Since(start))
+	}
+	if m.spanHook != nil {
+		m.spanHook(newSpan(r, status, start))
+	}
+}
+
+// handle matches the request against the expectations and sends the response.
+func (m *MockAPI) handle(w http.ResponseWriter, r *http.Request) {
+	if m.bodyTimeout > 0 && r.Body != nil && !m.awaitBody(w, r) {
+		return
+	}
+
 	var body interface{}
+	var bodyBytes []byte
+	var digests map[string]string
 
-	if r.Body != nil {
-		bodyBytes, err := ioutil.ReadAll(r.Body)
+	if algorithms := m.streamedDigests(); r.Body != nil && algorithms != nil {
+		digests = hashBody(r.Body, algorithms)
+		r.Body = http.NoBody
+	} else if r.Body != nil {
+		var err error
+		bodyBytes, err = ioutil.ReadAll(r.Body)
+		// allow responders to read the body again
+		r.Body = ioutil.NopCloser(bytes.NewReader(bodyBytes))
 		if err == nil && len(bodyBytes) > 0 {
-			body = bodyBytes
-
-			var bodyMap map[string]interface{}
-			if err := json.Unmarshal(bodyBytes, &bodyMap); err != nil {
-				body = bodyMap
-			}
+			body = decodeRequestBody(r.Header.Get("Content-Type"), bodyBytes)
 		}
 	}
 
-	var headers map[string]string
+	// the response is only recorded when something needs it
+	var hw *harWriter
+	if m.captureTraffic || m.contract != nil {
+		hw = &harWriter{wrappedWriter: wrappedWriter{w}}
+		w = hw
+	}
+	if m.captureTraffic {
+		start := time.Now()
+		defer func() {
+			m.recordTraffic(hw.entry(r, bodyBytes, start))
+		}()
+	}
+
+	var headers http.Header
 	for hdr, values := range r.Header {
 		if _, ok := m.filteredHeaders[hdr]; ok {
 			continue
 		}
 		if headers == nil {
-			headers = make(map[string]string)
+			headers = make(http.Header)
 		}
-		headers[hdr] = values[0]
-		m.t.Errorf("multi-value header was unexpected")
+		headers[hdr] = values
 	}
 
-	var params map[string]string
+	var params url.Values
 	for param, values := range r.URL.Query() {
 		if _, ok := m.filteredParams[param]; ok {
 			continue
 		}
 		if params == nil {
-			params = make(map[string]string)
+			params = make(url.Values)
+		}
+		params[param] = values
+	}
+
+	var trailers map[string]string
+	for trailer, values := range r.Trailer {
+		if trailers == nil {
+			trailers = make(map[string]string)
 		}
-		params[param] = values[0]
-		m.t.Errorf("multi-value query param was unexpected")
+		trailers[trailer] = values[0]
+	}
+
+	received := time.Now()
+	m.observeRetries(r.Method, r.URL.Path, received)
+	m.record(JournalEntry{
+		ID:          RequestID(r),
+		Time:        received,
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		Headers:     headers,
+		QueryParams: params,
+		Body:        body,
+		Trailers:    trailers,
+	})
+
+	if m.contract != nil {
+		m.contract.checkRequest(m, r, bodyBytes)
+	}
+
+	if m.cassette != nil {
+		m.cassette.proxy(m, w, r, bodyBytes)
+		return
+	}
+
+	rr := &receivedRequest{
+		r:         r,
+		bodyBytes: bodyBytes,
+		trailers:  trailers,
+		digests:   digests,
+	}
+
+	ret, err := m.methodCalled(r.Method, r.URL.Path, headers, params, body, rr)
+	if err != nil {
+		if m.serveDefault(w, r, bodyBytes) {
+			return
+		}
+
+		if diffs := m.bodyDiffs(r.Method, r.URL.Path, body, bodyBytes); diffs != "" {
+			err = fmt.Errorf("%v%s", err, diffs)
+		}
+		m.unexpectedRequest(r, bodyBytes, err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	m.respond(w, r, bodyBytes, ret)
+	if m.contract != nil {
+		m.contract.checkResponse(m, r, hw)
 	}
+}
 
-	ret := m.m.Called(r.Method, r.URL.Path, headers, params, body)
+// respond records the request against the matched call and sends its reply.
+func (m *MockAPI) respond(w http.ResponseWriter, r *http.Request, bodyBytes []byte, ret mock.Arguments) {
+	if call, ok := ret.Get(1).(*MockAPICall); ok {
+		now := time.Now()
+		r = withPathParams(r, call.req.pathParams(r.URL.Path))
+		m.logRequest(r, "matched "+call.String())
+		call.matched(now)
+		call.capture(newCapturedRequest(r, bodyBytes, now))
+		if m.recorder != nil {
+			m.recorder.record(RecordedCall{
+				ID:     RequestID(r),
+				Time:   now,
+				API:    m.recorderName,
+				Method: r.Method,
+				Path:   r.URL.Path,
+				Call:   call,
+			})
+		}
+	}
 
 	if replyFn, ok := ret.Get(0).(MockResponse); ok {
-		replyFn(w, r)
+		if m.snapshot != nil {
+			sw := &snapshotWriter{wrappedWriter: wrappedWriter{w}}
+			defer func() {
+				m.snapshot.record(sw.response(r))
+			}()
+			w = sw
+		}
+
+		m.applyChaos(replyFn)(w, r)
 		return
 	}
 }
 
+// decodeBody converts a received body into the value that is matched against the
+// expected body. JSON objects are decoded into a map while any other body is kept raw.
+func decodeBody(body []byte) interface{} {
+	var bodyMap map[string]interface{}
+	if err := json.Unmarshal(body, &bodyMap); err == nil {
+		return bodyMap
+	}
+	return body
+}
+
+// errorf reports an error to the TestingT if there is one.
+func (m *MockAPI) errorf(format string, args ...interface{}) {
+	m.report.emit(FailureEvent{Kind: FailureAssertion, Message: fmt.Sprintf(format, args...)})
+	if m.t != nil {
+		m.t.Errorf(format, args...)
+	}
+}
+
+// unexpectedRequest reports a request which did not match any expectation along
+// with a curl command to reproduce it.
+func (m *MockAPI) unexpectedRequest(r *http.Request, body []byte, err error) {
+	m.logRequest(r, "did not match any expectation")
+	msg := fmt.Sprintf("unexpected request %s: %v\n\nReproduce with:\n\t%s", RequestID(r), err, CurlCommand(r, body))
+	m.report.emit(FailureEvent{
+		RequestID: RequestID(r),
+		Kind:      FailureUnexpectedRequest,
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Message:   msg,
+	})
+	if m.t != nil {
+		m.t.Errorf("%s", msg)
+	}
+}
+
+// methodCalled records the call with the underlying mock. The mock is not given
+// the TestingT so that it panics for unexpected calls rather than calling FailNow
+// from the server's goroutine. The panic is converted into an error which the
+// caller reports.
+func (m *MockAPI) methodCalled(args ...interface{}) (ret mock.Arguments, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return m.m.MethodCalled("ServeHTTP", args...), nil
+}
+
 // Close will stop the HTTP server and also assert that all expected HTTP invocations
 // have happened.
 func (m *MockAPI) Close() {
-	m.s.Close()
-	m.m.AssertExpectations(m.t)
+	m.stopDeadlines()
+	m.releaseHung()
+	if m.s != nil {
+		m.s.Close()
+	}
+	m.stopServers()
+	m.checkSnapshot()
+	m.saveCassette()
+	m.AssertExpectations(m.t)
+	m.report.close()
+}
+
+// stopServers stops any additional servers started by the start hooks.
+func (m *MockAPI) stopServers() {
+	m.mu.Lock()
+	stops := m.stops
+	m.stops = nil
+	m.mu.Unlock()
+
+	for _, stop := range stops {
+		stop()
+	}
+}
+
+// CloseContext is like Close but bounds how long it will wait for the HTTP server
+// to shut down. If the context is done before all outstanding requests have completed,
+// any responders blocked in WaitUntil are released, all client connections are forcibly
+// closed and the context's error is returned without waiting any further. Expectations
+// are asserted in either case.
+func (m *MockAPI) CloseContext(ctx context.Context) error {
+	m.stopDeadlines()
+	m.releaseHung()
+	done := make(chan struct{})
+	go func() {
+		if m.s != nil {
+			m.s.Close()
+		}
+		m.stopServers()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		m.closeOnce.Do(func() { close(m.closing) })
+		if m.s != nil {
+			m.s.CloseClientConnections()
+		}
+		err = fmt.Errorf("timed out waiting for the mock API server to shut down: %w", ctx.Err())
+	}
+
+	m.checkSnapshot()
+	m.saveCassette()
+	m.AssertExpectations(m.t)
+	m.report.close()
+	return err
 }
 
 // WithRequest will setup an expectation for an API call to be made. Its is the responsibility of the
@@ -200,13 +756,23 @@ func (m *MockAPI) Close() {
 // contents into a map[string]interface{} is made. If successful the map is recorded as the body, if
 // unsuccessful then the raw []byte is recorded as the body.
 func (m *MockAPI) WithRequest(req *MockRequest, resp MockResponse) *MockAPICall {
-	c := m.m.On("ServeHTTP", req.method, req.path, req.headers, req.queryParams, req.body).Return(resp)
-	return &MockAPICall{c: c}
-}
+	checkError(m.t, req.validate())
+	if resp == nil {
+		checkError(m.t, fmt.Errorf("nil responder for expected request %s %s", req.method, req.path))
+	}
+	if m.canonicalHeaders {
+		req.canonicalizeHeaders()
+	}
 
-func (m *MockAPI) DefaultHandler(response func(http.ResponseWriter, *http.Request)) *MockAPICall {
-	c := m.m.On("ServeHTTP", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.Anything).Return(response).Times(0)
-	return &MockAPICall{c: c}
+	call := newMockAPICall(m, req, resp, func(call *MockAPICall) *mock.Call {
+		return m.m.On("ServeHTTP", req.method, req.pathArgument(), req.headersArgument(), req.queryArgument(), req.body, req.requestMatcher(call.active))
+	})
+
+	m.mu.Lock()
+	m.calls = append(m.calls, call)
+	m.mu.Unlock()
+
+	return call
 }
 
 // WithNoResponseBody will setup an expectation for an API call to be made. The supplied status code will
@@ -226,21 +792,56 @@ func (m *MockAPI) WithJSONReply(req *MockRequest, status int, reply interface{})
 	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(status)
 
-		fmt.Printf("reply: %v\n", reply)
 		if reply == nil {
 			return
 		}
 
 		enc := json.NewEncoder(w)
 		err := enc.Encode(reply)
-		if m.t != nil {
-			require.NoError(m.t, err)
-		} else {
-			panic(err)
-		}
+		checkError(m.t, err)
 	})
 }
 
+// WithJSONFileReply will setup an expectation for an API call to be made. The supplied status code
+// will be used for the responses reply and the contents of the JSON file at path will be written
+// to the response with a Content-Type of application/json. The file is read and validated when the
+// expectation is setup. Failure to read the file or it not containing valid JSON will fail the test
+// object passed into the NewMockAPI constructor if that was non-nil and if it was nil, will panic.
+func (m *MockAPI) WithJSONFileReply(req *MockRequest, status int, path string) *MockAPICall {
+	data, err := ioutil.ReadFile(path)
+	checkError(m.t, err)
+
+	if !json.Valid(data) {
+		checkError(m.t, fmt.Errorf("file %q does not contain valid JSON", path))
+	}
+
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(data)
+	})
+}
+
+// JSONResponse creates a MockResponse which will reply with the supplied status code and
+// the JSON encoding of the reply object. Unlike WithJSONReply, the reply is encoded
+// immediately so that any encoding error is returned to the caller rather than failing
+// the test or panicking when the request is made. The result can be passed to WithRequest.
+func JSONResponse(status int, reply interface{}) (MockResponse, error) {
+	var encoded []byte
+	if reply != nil {
+		var err error
+		encoded, err = json.Marshal(reply)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write(encoded)
+	}, nil
+}
+
 // WithTextReply will setup an expectation for an API call to be made. The supplied status code will
 // be use for the responses reply and the reply string will be written to the response.
 func (m *MockAPI) WithTextReply(req *MockRequest, status int, reply string) *MockAPICall {
@@ -265,6 +866,76 @@ func (m *MockAPI) WithStreamingReply(req *MockRequest, status int, reply io.Read
 	})
 }
 
+// WithChannelReply will setup an expectation for an API call to be made. The supplied status code will
+// be used for the responses reply and each item received from the channel will be written to the response
+// and flushed immediately. The reply is complete once the channel is closed. This allows tests to drive a
+// live stream interactively. The reply is abandoned if the client goes away or the MockAPI is shut down
+// via CloseContext.
+func (m *MockAPI) WithChannelReply(req *MockRequest, status int, ch <-chan []byte) *MockAPICall {
+	closing := m.closing
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		flusher, _ := w.(http.Flusher)
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		for {
+			select {
+			case data, ok := <-ch:
+				if !ok {
+					return
+				}
+				if _, err := w.Write(data); err != nil {
+					return
+				}
+				if flusher != nil {
+					flusher.Flush()
+				}
+			case <-r.Context().Done():
+				return
+			case <-closing:
+				return
+			}
+		}
+	})
+}
+
+// WithChunkedReply will setup an expectation for an API call to be made. The supplied status code will
+// be used for the responses reply and each of the chunks will be written to the response and flushed,
+// waiting for interval between chunks. This exercises clients which process partial responses
+// incrementally. The reply is abandoned if the client goes away or the MockAPI is shut down via
+// CloseContext.
+func (m *MockAPI) WithChunkedReply(req *MockRequest, status int, chunks [][]byte, interval time.Duration) *MockAPICall {
+	closing := m.closing
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		flusher, _ := w.(http.Flusher)
+
+		for i, chunk := range chunks {
+			if i > 0 && interval > 0 {
+				timer := time.NewTimer(interval)
+				select {
+				case <-timer.C:
+				case <-r.Context().Done():
+					timer.Stop()
+					return
+				case <-closing:
+					timer.Stop()
+					return
+				}
+			}
+
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	})
+}
+
 // AssertExpectations will assert that all expected API invocations have happened and fail
 // the test if any required calls did not happen.
 func (m *MockAPI) AssertExpectations(t TestingT) {
@@ -275,44 +946,249 @@ func (m *MockAPI) AssertExpectations(t TestingT) {
 		// defer m.Close() and let us call AssertExpectations that way.
 		return
 	}
+	// testify only fails for unmet expectations, which are reported with their
+	// counts by reportUnmet, and so its failures are not reported again
+	rt := t
+	if m.report != nil {
+		m.reportUnmet()
+		rt = &reportingT{TestingT: t, report: m.report}
+	}
+
 	m.m.AssertExpectations(t)
+	m.defaults.AssertExpectations(t)
+	m.assertTiming(rt)
+	m.assertOrder(rt)
+	m.assertRetries(rt)
 }
 
 // MockAPICall is a wrapper around the github.com/stretchr/testify/mock.Call
 // type. It provides a smaller interface that is more suitable for use with
 // the MockAPI type and should prevent some accidental issues.
 type MockAPICall struct {
-	c *mock.Call
+	c    *mock.Call
+	api  *MockAPI
+	req  *MockRequest
+	resp MockResponse
+
+	registered time.Time
+
+	// mu protects the fields below
+	mu       sync.Mutex
+	times    []time.Time
+	windows  []timeWindow
+	captured []*CapturedRequest
+	hooks    []func(*CapturedRequest)
+
+	// expected is the number of times the call is expected to occur with
+	// zero meaning at least once.
+	expected int
+	optional bool
+	disabled bool
+
+	groups []*Group
+
+	// notBefore are the calls which must all have occurred before this one
+	notBefore []*MockAPICall
+
+	scenario *scenarioStep
+}
+
+// newMockAPICall creates a MockAPICall for the expectation registered with the
+// underlying mock by on. The call is passed to on so that the arguments of the
+// expectation may refer to it.
+func newMockAPICall(api *MockAPI, req *MockRequest, resp MockResponse, on func(call *MockAPICall) *mock.Call) *MockAPICall {
+	call := &MockAPICall{api: api, req: req, resp: resp, registered: time.Now()}
+	call.c = on(call)
+	call.c.Return(resp, call)
+	return call
+}
+
+// String describes the request this call is expecting.
+func (m *MockAPICall) String() string {
+	if m.req == nil {
+		return "the default handler"
+	}
+	return m.req.method + " " + m.req.path
+}
+
+// matched records that a request matched this call.
+func (m *MockAPICall) matched(at time.Time) {
+	m.mu.Lock()
+	m.times = append(m.times, at)
+	m.mu.Unlock()
+
+	m.transition()
+}
+
+// firstMatch returns when this call was first matched.
+func (m *MockAPICall) firstMatch() (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.times) == 0 {
+		return time.Time{}, false
+	}
+	return m.times[0], true
+}
+
+// lastMatch returns when this call was last matched.
+func (m *MockAPICall) lastMatch() (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.times) == 0 {
+		return time.Time{}, false
+	}
+	return m.times[len(m.times)-1], true
+}
+
+// disable prevents this call from being matched any further and from
+// being asserted when expectations are checked.
+func (m *MockAPICall) disable() {
+	m.mu.Lock()
+	m.disabled = true
+	m.mu.Unlock()
+
+	m.c.Maybe()
+	m.c.Times(-1)
+}
+
+// wrap replaces the responder for this call with the result of passing the
+// current responder to fn.
+func (m *MockAPICall) wrap(fn func(MockResponse) MockResponse) {
+	m.resp = fn(m.resp)
+	m.c.Return(m.resp, m)
+}
+
+// Wrap wraps the responder of this call with the given middleware, which may add
+// headers, latency or logging to the reply of just this call. Middleware is applied
+// on top of any decorators configured earlier, with the first middleware given
+// being the outermost.
+func (m *MockAPICall) Wrap(middleware ...func(next MockResponse) MockResponse) *MockAPICall {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		m.wrap(middleware[i])
+	}
+	return m
 }
 
 // Maybe marks this API call as optional.
 func (m *MockAPICall) Maybe() *MockAPICall {
+	m.mu.Lock()
+	m.optional = true
+	m.mu.Unlock()
+
 	m.c.Maybe()
 	return m
 }
 
 // Once marks this API call as being expected to occur exactly once.
 func (m *MockAPICall) Once() *MockAPICall {
-	m.c.Once()
-	return m
+	return m.Times(1)
 }
 
 // Times marks this API call as being expected to occur the specified number of times.
 func (m *MockAPICall) Times(i int) *MockAPICall {
+	m.mu.Lock()
+	m.expected = i
+	m.mu.Unlock()
+
 	m.c.Times(i)
 	return m
 }
 
 // Twice marks this API call as being expected to occur exactly twice
 func (m *MockAPICall) Twice() *MockAPICall {
-	m.c.Twice()
-	return m
+	return m.Times(2)
 }
 
 // WaitUntil sets the channel that will block the sending back an HTTP response
 // to this Call. This happens prior to setting the status code as well as writing
-// out any of the reply (before the function passed to MockAPI.Request is called)
+// out any of the reply (before the function passed to MockAPI.Request is called).
+// A blocked response is abandoned if the MockAPI is shut down via CloseContext
+// before the channel fires.
 func (m *MockAPICall) WaitUntil(w <-chan time.Time) *MockAPICall {
-	m.c.WaitUntil(w)
+	closing := m.api.closing
+	m.wrap(func(next MockResponse) MockResponse {
+		return func(rw http.ResponseWriter, r *http.Request) {
+			select {
+			case <-w:
+			case <-closing:
+				return
+			}
+			next(rw, r)
+		}
+	})
+	return m
+}
+
+// WaitUntilContext blocks sending back an HTTP response to this Call until the context
+// is done, after which the reply is sent as normal. Passing a context derived from the
+// test's deadline ensures blocked responders are always released. As with WaitUntil, a
+// blocked response is abandoned if the MockAPI is shut down via CloseContext or the
+// client gives up on the request.
+func (m *MockAPICall) WaitUntilContext(ctx context.Context) *MockAPICall {
+	closing := m.api.closing
+	m.wrap(func(next MockResponse) MockResponse {
+		return func(rw http.ResponseWriter, r *http.Request) {
+			select {
+			case <-ctx.Done():
+			case <-r.Context().Done():
+				return
+			case <-closing:
+				return
+			}
+			next(rw, r)
+		}
+	})
+	return m
+}
+
+// WaitFor delays sending back each HTTP response to this Call by d. Unlike passing
+// time.After(d) to WaitUntil, every matching request is delayed rather than only those
+// received before the channel fired.
+func (m *MockAPICall) WaitFor(d time.Duration) *MockAPICall {
+	return m.delay(func() time.Duration { return d })
+}
+
+// After delays sending back each HTTP response to this Call by d, mirroring testify's
+// Call.After. It is the same as WaitFor.
+func (m *MockAPICall) After(d time.Duration) *MockAPICall {
+	return m.WaitFor(d)
+}
+
+// WithJitter delays sending back each HTTP response to this Call by a random duration
+// within [min, max]. Use WithRandomSeed to make the delays reproducible.
+func (m *MockAPICall) WithJitter(min, max time.Duration) *MockAPICall {
+	if max < min {
+		checkError(m.api.t, fmt.Errorf("invalid jitter for %s: max %v is less than min %v", m, max, min))
+		return m
+	}
+
+	api := m.api
+	return m.delay(func() time.Duration {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		return min + time.Duration(api.random().Int63n(int64(max-min)+1))
+	})
+}
+
+// delay delays sending back each HTTP response to this Call by the duration returned
+// by duration. Delayed responses are abandoned if the client gives up on the request or
+// the MockAPI is shut down.
+func (m *MockAPICall) delay(duration func() time.Duration) *MockAPICall {
+	closing := m.api.closing
+	m.wrap(func(next MockResponse) MockResponse {
+		return func(rw http.ResponseWriter, r *http.Request) {
+			timer := time.NewTimer(duration())
+			defer timer.Stop()
+
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				return
+			case <-closing:
+				return
+			}
+			next(rw, r)
+		}
+	})
 	return m
 }
@@ -1,10 +1,16 @@
 package mockapi
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 	// mockapi "github.com/mkeeler/mock-http-api"
 )
 
@@ -44,3 +50,424 @@ func TestMyAPI(t *testing.T) {
 		t.Fatalf("Didn't get the expected response")
 	}
 }
+
+func TestCloseContext(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	// this response will never be released by the test
+	m.WithNoResponseBody(NewMockRequest("GET", "/hung"), 200).Once().WaitUntil(make(chan time.Time))
+
+	errCh := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(fmt.Sprintf("%s/hung", m.URL()))
+		if err == nil {
+			resp.Body.Close()
+		}
+		errCh <- err
+	}()
+
+	// give the request a chance to become blocked within the mock
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := m.CloseContext(ctx)
+	if err == nil {
+		t.Fatalf("Expected CloseContext to time out")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("CloseContext took too long to return: %v", elapsed)
+	}
+
+	select {
+	case <-errCh:
+	case <-time.After(time.Second):
+		t.Fatalf("Client request was never unblocked")
+	}
+}
+
+func TestNewWithoutTestingT(t *testing.T) {
+	m, err := New()
+	if err != nil {
+		t.Fatalf("Error creating the mock API: %v", err)
+	}
+	defer m.Close()
+
+	resp, err := JSONResponse(200, map[string]string{"foo": "bar"})
+	if err != nil {
+		t.Fatalf("Error creating the JSON response: %v", err)
+	}
+	m.WithRequest(NewMockRequest("GET", "/my/endpoint"), resp)
+
+	_, err = JSONResponse(200, make(chan int))
+	if err == nil {
+		t.Fatalf("Expected an error when creating an unencodable JSON response")
+	}
+
+	// this request does not match the expectation and must not panic
+	unexpected, err := http.Get(fmt.Sprintf("%s/other/endpoint", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /other/endpoint: %v", err)
+	}
+	unexpected.Body.Close()
+
+	if unexpected.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected a 404 status code but got %d", unexpected.StatusCode)
+	}
+}
+
+// cleanupT is a CleanerT which records the registered cleanup functions.
+type cleanupT struct {
+	recordingT
+	cleanups []func()
+}
+
+func (t *cleanupT) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+func TestWithoutCleanup(t *testing.T) {
+	ct := &cleanupT{}
+	m := NewMockAPI(ct)
+	m.Close()
+	if len(ct.cleanups) != 1 {
+		t.Fatalf("Expected the MockAPI to register a cleanup but got %d", len(ct.cleanups))
+	}
+
+	ct = &cleanupT{}
+	m = NewMockAPI(ct, WithoutCleanup())
+	defer m.Close()
+	if len(ct.cleanups) != 0 {
+		t.Fatalf("Expected no cleanup to be registered but got %d", len(ct.cleanups))
+	}
+}
+
+func TestWithJSONFileReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithJSONFileReply(NewMockRequest("GET", "/my/endpoint"), 200, "testdata/reply.json").Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /my/endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Expected a JSON Content-Type but got %q", ct)
+	}
+
+	var output map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&output); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+
+	if output["foo"] != "bar" {
+		t.Fatalf("Didn't get the expected response: %v", output)
+	}
+}
+
+func TestWithChannelReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	ch := make(chan []byte)
+	m.WithChannelReply(NewMockRequest("GET", "/events"), 200, ch).Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/events", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /events: %v", err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	for _, event := range []string{"first\n", "second\n"} {
+		ch <- []byte(event)
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Error reading event: %v", err)
+		}
+		if line != event {
+			t.Fatalf("Expected event %q but got %q", event, line)
+		}
+	}
+
+	close(ch)
+	if _, err := reader.ReadByte(); err != io.EOF {
+		t.Fatalf("Expected the stream to end but got: %v", err)
+	}
+}
+
+func TestMockAPICallWrap(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	var order []string
+	trace := func(name string) func(MockResponse) MockResponse {
+		return func(next MockResponse) MockResponse {
+			return func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				w.Header().Add("X-Trace", name)
+				next(w, r)
+			}
+		}
+	}
+
+	m.WithTextReply(NewMockRequest("GET", "/wrapped"), 200, "ok").Once().Wrap(trace("outer"), trace("inner"))
+
+	resp, err := http.Get(fmt.Sprintf("%s/wrapped", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /wrapped: %v", err)
+	}
+	resp.Body.Close()
+
+	if strings.Join(order, ",") != "outer,inner" || len(resp.Header["X-Trace"]) != 2 {
+		t.Fatalf("Unexpected middleware order %v and headers %v", order, resp.Header)
+	}
+}
+
+func TestWithHeaderValues(t *testing.T) {
+	m := NewMockAPI(t, WithRequestJournal())
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/forwarded").
+		WithHeaders(map[string]string{"X-Request-Source": "proxy"}).
+		WithHeaderValues("X-Forwarded-For", "10.0.0.1", "10.0.0.2"), 200).Once()
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/forwarded", m.URL()), nil)
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+	req.Header.Set("X-Request-Source", "proxy")
+	req.Header.Add("X-Forwarded-For", "10.0.0.1")
+	req.Header.Add("X-Forwarded-For", "10.0.0.2")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error issuing GET of /forwarded: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status code but got %d", resp.StatusCode)
+	}
+
+	journal := m.Journal()
+	if len(journal) != 1 || len(journal[0].Headers.Values("X-Forwarded-For")) != 2 {
+		t.Fatalf("Multi-value headers were not recorded in the journal: %+v", journal)
+	}
+}
+
+func TestWithQueryValues(t *testing.T) {
+	m := NewMockAPI(t, WithRequestJournal())
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/items").
+		WithQueryParams(map[string]string{"limit": "10"}).
+		WithQueryValues(url.Values{"tag": {"a", "b"}}), 200).Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/items?tag=a&limit=10&tag=b", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /items: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status code but got %d", resp.StatusCode)
+	}
+
+	journal := m.Journal()
+	if len(journal) != 1 || len(journal[0].QueryParams["tag"]) != 2 {
+		t.Fatalf("Multi-value query params were not recorded in the journal: %+v", journal)
+	}
+}
+
+func TestWithHeaderSubset(t *testing.T) {
+	rt := &recordingT{}
+	m := NewMockAPI(rt)
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/subset").
+		WithHeaderSubset(map[string]string{"X-Api-Key": "secret"}), 200).Maybe()
+
+	for key, expected := range map[string]int{
+		"secret": 200,
+		"other":  404,
+		"":       404,
+	} {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/subset", m.URL()), nil)
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		if key != "" {
+			req.Header.Set("X-Api-Key", key)
+		}
+		req.Header.Set("X-Client-Version", "1.2.3")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Error issuing GET of /subset: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != expected {
+			t.Fatalf("Expected a %d status code for key %q but got %d", expected, key, resp.StatusCode)
+		}
+	}
+	m.Close()
+}
+
+func TestWithCanonicalHeaders(t *testing.T) {
+	m := NewMockAPI(t, WithCanonicalHeaders())
+	m.SetFilteredHeaders([]string{
+		"accept-encoding",
+		"user-agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/canonical").
+		WithHeaders(map[string]string{"x-api-key": "secret"}), 200).Once()
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/canonical", m.URL()), nil)
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+	req.Header.Set("X-Api-Key", "secret")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error issuing GET of /canonical: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status code but got %d", resp.StatusCode)
+	}
+}
+
+func TestQueryParamSubsetAndPresence(t *testing.T) {
+	rt := &recordingT{}
+	m := NewMockAPI(rt)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/subset").
+		WithQueryParamSubset(map[string]string{"region": "east"}), 200).Maybe()
+	m.WithNoResponseBody(NewMockRequest("GET", "/blocking").
+		WithQueryParams(map[string]string{"stale": "true"}).
+		WithQueryParamPresent("index", "wait"), 200).Maybe()
+
+	for query, expected := range map[string]int{
+		"/subset?region=east":                          200,
+		"/subset?region=east&trace=1&page=2":           200,
+		"/subset?region=west":                          404,
+		"/subset":                                      404,
+		"/blocking?stale=true&index=42&wait=5s":        200,
+		"/blocking?stale=true&index=7&wait=1m":         200,
+		"/blocking?stale=true&index=42":                404,
+		"/blocking?index=42&wait=5s":                   404,
+		"/blocking?stale=true&index=42&wait=5s&page=1": 404,
+	} {
+		resp, err := http.Get(fmt.Sprintf("%s%s", m.URL(), query))
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", query, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != expected {
+			t.Fatalf("Expected a %d status code for %s but got %d", expected, query, resp.StatusCode)
+		}
+	}
+	m.Close()
+}
+
+func TestWithChunkedReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	chunks := [][]byte{[]byte("first\n"), []byte("second\n"), []byte("third\n")}
+	m.WithChunkedReply(NewMockRequest("GET", "/chunks"), 200, chunks, 20*time.Millisecond).Once()
+
+	start := time.Now()
+	resp, err := http.Get(fmt.Sprintf("%s/chunks", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /chunks: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if len(resp.TransferEncoding) != 1 || resp.TransferEncoding[0] != "chunked" {
+		t.Fatalf("Expected a chunked response but got %v", resp.TransferEncoding)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil || line != "first\n" {
+		t.Fatalf("Expected the first chunk but got %q: %v", line, err)
+	}
+	if elapsed := time.Since(start); elapsed >= 40*time.Millisecond {
+		t.Fatalf("Expected the first chunk before the others were written but it took %s", elapsed)
+	}
+
+	rest, err := io.ReadAll(reader)
+	if err != nil || string(rest) != "second\nthird\n" {
+		t.Fatalf("Expected the remaining chunks but got %q: %v", rest, err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("Expected the chunks to be written at intervals but it took %s", elapsed)
+	}
+}
+
+func TestRequestBodyDecoding(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Content-Length",
+		"Content-Type",
+		"User-Agent",
+	})
+
+	// JSON objects are matched against a map regardless of formatting while other bodies
+	// are matched as is
+	m.WithNoResponseBody(NewMockRequest("POST", "/json").WithBody(map[string]interface{}{"name": "abc"}), 200).Once()
+	m.WithNoResponseBody(NewMockRequest("POST", "/raw").WithBody([]byte("name=abc")), 200).Once()
+
+	for path, body := range map[string]string{
+		"/json": "{\n  \"name\": \"abc\"\n}",
+		"/raw":  "name=abc",
+	} {
+		resp, err := http.Post(fmt.Sprintf("%s%s", m.URL(), path), "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Error issuing POST of %s: %v", path, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			t.Fatalf("Expected the POST of %s to match its expectation but got status %d", path, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package mockapi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+)
+
+// WithMutualTLS configures the MockAPI to serve HTTPS and to require every client to present
+// a certificate which verifies against the given pool of certificate authorities. Clients
+// which do not are rejected during the TLS handshake. Use MockAPI.ClientWithCertificate to get
+// an *http.Client presenting a certificate and MockRequest.WithClientCert to expect requests
+// to be made with a particular certificate.
+func WithMutualTLS(clientCAs *x509.CertPool) Option {
+	return func(m *MockAPI) error {
+		if clientCAs == nil {
+			return fmt.Errorf("no client certificate authorities provided")
+		}
+
+		m.tls = true
+		m.clientCAs = clientCAs
+		return nil
+	}
+}
+
+// ClientWithCertificate returns an *http.Client configured in the same manner as the one
+// returned by Client which also presents the given certificate to the server.
+func (m *MockAPI) ClientWithCertificate(cert tls.Certificate) *http.Client {
+	client := *m.Client()
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		transport = &http.Transport{}
+	} else {
+		transport = transport.Clone()
+	}
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+
+	client.Transport = transport
+	return &client
+}
+
+// WithClientCert will expect the request to be made over a TLS connection on which the client
+// presented a certificate. When commonName is not empty, the subject common name of the
+// certificate must equal it. Every one of the subject alternative names given, whether DNS
+// names, email addresses, IP addresses or URIs, must also be present in the certificate.
+func (r *MockRequest) WithClientCert(commonName string, sans ...string) *MockRequest {
+	r.matchers = append(r.matchers, func(rr *receivedRequest) bool {
+		if rr.r.TLS == nil || len(rr.r.TLS.PeerCertificates) == 0 {
+			return false
+		}
+
+		cert := rr.r.TLS.PeerCertificates[0]
+		if commonName != "" && cert.Subject.CommonName != commonName {
+			return false
+		}
+
+		present := make(map[string]struct{})
+		for _, name := range cert.DNSNames {
+			present[name] = struct{}{}
+		}
+		for _, email := range cert.EmailAddresses {
+			present[email] = struct{}{}
+		}
+		for _, ip := range cert.IPAddresses {
+			present[ip.String()] = struct{}{}
+		}
+		for _, uri := range cert.URIs {
+			present[uri.String()] = struct{}{}
+		}
+
+		for _, san := range sans {
+			if _, ok := present[san]; !ok {
+				return false
+			}
+		}
+		return true
+	})
+	return r
+}
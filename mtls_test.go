@@ -0,0 +1,78 @@
+package mockapi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// clientCertificate generates a self-signed client certificate for the common
+// name and DNS name.
+func clientCertificate(t *testing.T, commonName, dnsName string) (tls.Certificate, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{dnsName},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error creating certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Error parsing certificate: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, cert
+}
+
+func TestWithMutualTLS(t *testing.T) {
+	staging, stagingCert := clientCertificate(t, "staging-client", "staging.example.com")
+	production, productionCert := clientCertificate(t, "production-client", "production.example.com")
+
+	pool := x509.NewCertPool()
+	pool.AddCert(stagingCert)
+	pool.AddCert(productionCert)
+
+	m := NewMockAPI(t, WithMutualTLS(pool))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithTextReply(NewMockRequest("GET", "/whoami").WithClientCert("staging-client", "staging.example.com"), 200, "staging").Once()
+	m.WithTextReply(NewMockRequest("GET", "/whoami").WithClientCert("production-client"), 200, "production").Once()
+
+	for _, cert := range []tls.Certificate{staging, production} {
+		resp, err := m.ClientWithCertificate(cert).Get(fmt.Sprintf("%s/whoami", m.URL()))
+		if err != nil {
+			t.Fatalf("Error issuing GET of /whoami: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			t.Fatalf("Unexpected status code %d", resp.StatusCode)
+		}
+	}
+
+	if _, err := m.Client().Get(fmt.Sprintf("%s/whoami", m.URL())); err == nil {
+		t.Fatalf("Expected the request without a client certificate to be rejected")
+	}
+}
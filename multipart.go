@@ -0,0 +1,200 @@
+package mockapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// ReplyPart is a single part of a multipart response.
+type ReplyPart struct {
+	Headers map[string]string
+	Body    []byte
+}
+
+// MultipartResponse creates a MockResponse which replies with the given status code and
+// a multipart body made up of the parts. The subtype determines the Content-Type of the
+// response, for example "mixed" results in multipart/mixed.
+func MultipartResponse(status int, subtype string, parts ...ReplyPart) MockResponse {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+
+		for _, part := range parts {
+			header := make(textproto.MIMEHeader)
+			keys := make([]string, 0, len(part.Headers))
+			for key := range part.Headers {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				header.Set(key, part.Headers[key])
+			}
+
+			pw, err := mw.CreatePart(header)
+			if err != nil {
+				panic(err)
+			}
+			pw.Write(part.Body)
+		}
+		mw.Close()
+
+		w.Header().Set("Content-Type", fmt.Sprintf("multipart/%s; boundary=%s", subtype, mw.Boundary()))
+		w.WriteHeader(status)
+		w.Write(buf.Bytes())
+	}
+}
+
+// ByteRangeParts creates the parts of a multipart/byteranges response containing the
+// given ranges of the content. Each range holds the inclusive first and last byte offsets.
+func ByteRangeParts(content []byte, contentType string, ranges ...[2]int) []ReplyPart {
+	parts := make([]ReplyPart, 0, len(ranges))
+	for _, rng := range ranges {
+		parts = append(parts, ReplyPart{
+			Headers: map[string]string{
+				"Content-Type":  contentType,
+				"Content-Range": fmt.Sprintf("bytes %d-%d/%d", rng[0], rng[1], len(content)),
+			},
+			Body: content[rng[0] : rng[1]+1],
+		})
+	}
+	return parts
+}
+
+// WithMultipartReply will setup an expectation for an API call to be made. The supplied status
+// code will be used for the responses reply and the parts will be written as a multipart body
+// with the given subtype (such as "mixed" or "byteranges").
+func (m *MockAPI) WithMultipartReply(req *MockRequest, status int, subtype string, parts ...ReplyPart) *MockAPICall {
+	return m.WithRequest(req, MultipartResponse(status, subtype, parts...))
+}
+
+// MultipartBody is a parsed multipart/form-data request body. Bodies of such requests
+// are recorded in this form in the journal.
+type MultipartBody struct {
+	// Fields holds the values of the parts which are not files.
+	Fields map[string][]string
+	// Files holds the metadata of the uploaded files keyed by their field name.
+	Files map[string][]UploadedFile
+}
+
+// UploadedFile describes a file uploaded as part of a multipart/form-data request.
+type UploadedFile struct {
+	Filename    string
+	ContentType string
+	Size        int64
+	// SHA256 is the hex encoded SHA-256 digest of the file content.
+	SHA256 string
+}
+
+// FileExpectation describes an expected file upload. Fields left empty, or zero for
+// the Size, are not checked.
+type FileExpectation struct {
+	Filename    string
+	ContentType string
+	Size        int64
+	// SHA256 is the hex encoded SHA-256 digest of the expected file content.
+	SHA256 string
+}
+
+// matches returns whether the uploaded file satisfies the expectation.
+func (f FileExpectation) matches(file UploadedFile) bool {
+	return (f.Filename == "" || f.Filename == file.Filename) &&
+		(f.ContentType == "" || f.ContentType == file.ContentType) &&
+		(f.Size == 0 || f.Size == file.Size) &&
+		(f.SHA256 == "" || strings.EqualFold(f.SHA256, file.SHA256))
+}
+
+// isMultipartFormContentType returns whether the Content-Type denotes a
+// multipart/form-data body.
+func isMultipartFormContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// parseMultipartBody parses a multipart/form-data body.
+func parseMultipartBody(contentType string, body []byte) (*MultipartBody, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if mediaType != "multipart/form-data" {
+		return nil, fmt.Errorf("unexpected Content-Type %q for a multipart/form-data body", mediaType)
+	}
+
+	parsed := &MultipartBody{}
+	mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return parsed, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+
+		name := part.FormName()
+		if part.FileName() == "" {
+			if parsed.Fields == nil {
+				parsed.Fields = make(map[string][]string)
+			}
+			parsed.Fields[name] = append(parsed.Fields[name], string(content))
+			continue
+		}
+
+		digest := sha256.Sum256(content)
+		if parsed.Files == nil {
+			parsed.Files = make(map[string][]UploadedFile)
+		}
+		parsed.Files[name] = append(parsed.Files[name], UploadedFile{
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Size:        int64(len(content)),
+			SHA256:      hex.EncodeToString(digest[:]),
+		})
+	}
+}
+
+// WithMultipartBody will expect the request to have a multipart/form-data body with exactly
+// the given fields and files, each of them occurring once. It may not be combined with any
+// other body expectation.
+func (r *MockRequest) WithMultipartBody(fields map[string]string, files map[string]FileExpectation) *MockRequest {
+	r.setBodyOption("WithMultipartBody")
+	r.body = mock.Anything
+	r.matchers = append(r.matchers, func(rr *receivedRequest) bool {
+		body, err := parseMultipartBody(rr.r.Header.Get("Content-Type"), rr.bodyBytes)
+		if err != nil {
+			return false
+		}
+		if len(body.Fields) != len(fields) || len(body.Files) != len(files) {
+			return false
+		}
+		for name, value := range fields {
+			if values := body.Fields[name]; len(values) != 1 || values[0] != value {
+				return false
+			}
+		}
+		for name, expected := range files {
+			if uploaded := body.Files[name]; len(uploaded) != 1 || !expected.matches(uploaded[0]) {
+				return false
+			}
+		}
+		return true
+	})
+	return r
+}
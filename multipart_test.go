@@ -0,0 +1,123 @@
+package mockapi
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithMultipartReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	content := []byte("hello world")
+	m.WithMultipartReply(NewMockRequest("GET", "/file"), 206, "byteranges", ByteRangeParts(content, "text/plain", [2]int{0, 4}, [2]int{6, 10})...).Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/file", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /file: %v", err)
+	}
+	defer resp.Body.Close()
+
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Unexpected Content-Type %q: %v", resp.Header.Get("Content-Type"), err)
+	}
+
+	mr := multipart.NewReader(resp.Body, params["boundary"])
+	for _, expected := range []string{"hello", "world"} {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("Error reading part: %v", err)
+		}
+		body, _ := ioutil.ReadAll(part)
+		if string(body) != expected {
+			t.Fatalf("Expected part body %q but got %q", expected, body)
+		}
+	}
+}
+
+func TestWithMultipartBody(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	// sha256 of "hello world"
+	call := m.WithNoResponseBody(NewMockRequest("POST", "/upload").WithMultipartBody(
+		map[string]string{"description": "greeting"},
+		map[string]FileExpectation{
+			"file": {
+				Filename: "hello.txt",
+				Size:     11,
+				SHA256:   "B94D27B9934D3E08A52E52D7DA7DABFAC484EFE37A5380EE9088F7ACE2EFCDE9",
+			},
+		},
+	), 201).Once()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("description", "greeting")
+	fw, err := mw.CreateFormFile("file", "hello.txt")
+	if err != nil {
+		t.Fatalf("Error creating form file: %v", err)
+	}
+	fw.Write([]byte("hello world"))
+	mw.Close()
+
+	resp, err := http.Post(fmt.Sprintf("%s/upload", m.URL()), mw.FormDataContentType(), &buf)
+	if err != nil {
+		t.Fatalf("Error issuing POST of /upload: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 201 {
+		t.Fatalf("Expected a 201 status code but got %d", resp.StatusCode)
+	}
+
+	body, err := call.Requests()[0].MultipartBody()
+	if err != nil {
+		t.Fatalf("Error parsing the captured multipart body: %v", err)
+	}
+	if file := body.Files["file"][0]; file.ContentType != "application/octet-stream" || file.Size != 11 {
+		t.Fatalf("Unexpected uploaded file: %+v", file)
+	}
+}
+
+func TestWithMultipartBodyBodilessRequest(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("POST", "/upload").WithMultipartBody(
+		map[string]string{"description": "greeting"}, nil,
+	), 201).Maybe()
+	m.WithNoResponseBody(NewMockRequest("GET", "/other"), 200).Times(2)
+
+	// requests without a body must not break matching of the other expectations
+	client := &http.Client{Timeout: 5 * time.Second}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(fmt.Sprintf("%s/other", m.URL()))
+		if err != nil {
+			t.Fatalf("Request %d: error issuing GET of /other: %v", i, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			t.Fatalf("Request %d: expected a 200 status code but got %d", i, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,599 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPISpec is the subset of an OpenAPI 3 document describing the operations
+// of an HTTP API which is needed for mocking it.
+type OpenAPISpec struct {
+	OpenAPI    string                      `yaml:"openapi" json:"openapi"`
+	Servers    []*OpenAPIServer            `yaml:"servers" json:"servers"`
+	Paths      map[string]*OpenAPIPathItem `yaml:"paths" json:"paths"`
+	Components OpenAPIComponents           `yaml:"components" json:"components"`
+}
+
+// OpenAPIServer is a server the API is served from. The path of its URL is the
+// prefix of the paths of the spec.
+type OpenAPIServer struct {
+	URL string `yaml:"url" json:"url"`
+}
+
+// OpenAPIComponents holds the reusable parts of the spec. Only schemas may be
+// referenced with $ref.
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `yaml:"schemas" json:"schemas"`
+}
+
+// OpenAPIPathItem describes the operations available on a single path.
+type OpenAPIPathItem struct {
+	Parameters []*OpenAPIParameter `yaml:"parameters" json:"parameters"`
+	Get        *OpenAPIOperation   `yaml:"get" json:"get"`
+	Put        *OpenAPIOperation   `yaml:"put" json:"put"`
+	Post       *OpenAPIOperation   `yaml:"post" json:"post"`
+	Delete     *OpenAPIOperation   `yaml:"delete" json:"delete"`
+	Options    *OpenAPIOperation   `yaml:"options" json:"options"`
+	Head       *OpenAPIOperation   `yaml:"head" json:"head"`
+	Patch      *OpenAPIOperation   `yaml:"patch" json:"patch"`
+	Trace      *OpenAPIOperation   `yaml:"trace" json:"trace"`
+}
+
+// operations returns the operations of the path item keyed by HTTP method.
+func (p *OpenAPIPathItem) operations() map[string]*OpenAPIOperation {
+	ops := map[string]*OpenAPIOperation{
+		"GET":     p.Get,
+		"PUT":     p.Put,
+		"POST":    p.Post,
+		"DELETE":  p.Delete,
+		"OPTIONS": p.Options,
+		"HEAD":    p.Head,
+		"PATCH":   p.Patch,
+		"TRACE":   p.Trace,
+	}
+	for method, op := range ops {
+		if op == nil {
+			delete(ops, method)
+		}
+	}
+	return ops
+}
+
+// OpenAPIOperation describes a single API operation on a path.
+type OpenAPIOperation struct {
+	OperationID string                      `yaml:"operationId" json:"operationId"`
+	Parameters  []*OpenAPIParameter         `yaml:"parameters" json:"parameters"`
+	RequestBody *OpenAPIRequestBody         `yaml:"requestBody" json:"requestBody"`
+	Responses   map[string]*OpenAPIResponse `yaml:"responses" json:"responses"`
+
+	// The x-mock-* vendor extensions tune what is generated for the operation.
+	MockExtensions OpenAPIMockExtensions `yaml:",inline" json:"-"`
+}
+
+// OpenAPIMockExtensions are the x-mock-* vendor extensions which may be set on an
+// operation to override what is derived from the rest of the spec.
+type OpenAPIMockExtensions struct {
+	// Skip excludes the operation entirely.
+	Skip bool `yaml:"x-mock-skip"`
+	// Name overrides the name derived from the operationId.
+	Name string `yaml:"x-mock-name"`
+	// DefaultStatus is the status code used for replies. Helpers for the
+	// operation will not take a status code when this is set.
+	DefaultStatus int `yaml:"x-mock-default-status"`
+	// Headers overrides whether headers are significant to the operation.
+	Headers *bool `yaml:"x-mock-headers"`
+	// QueryParams overrides whether query params are significant to the operation.
+	QueryParams *bool `yaml:"x-mock-query-params"`
+	// BodyFormat overrides the format of the request body.
+	BodyFormat BodyFormat `yaml:"x-mock-body-format"`
+	// BodyType is the Go type of the request body.
+	BodyType string `yaml:"x-mock-body-type"`
+	// ResponseFormat overrides the format of the response body.
+	ResponseFormat ResponseFormat `yaml:"x-mock-response-format"`
+	// ResponseType is the Go type of the response body.
+	ResponseType string `yaml:"x-mock-response-type"`
+}
+
+// apply overrides the endpoint settings with those of the extensions.
+func (x *OpenAPIMockExtensions) apply(endpoint *Endpoint) {
+	endpoint.DefaultStatus = x.DefaultStatus
+	if x.Headers != nil {
+		endpoint.Headers = *x.Headers
+	}
+	if x.QueryParams != nil {
+		endpoint.QueryParams = *x.QueryParams
+	}
+	if x.BodyFormat != "" {
+		endpoint.BodyFormat = x.BodyFormat
+	}
+	if x.BodyType != "" {
+		endpoint.BodyType = x.BodyType
+	}
+	if x.ResponseFormat != "" {
+		endpoint.ResponseFormat = x.ResponseFormat
+	}
+	if x.ResponseType != "" {
+		endpoint.ResponseType = x.ResponseType
+	}
+}
+
+// OpenAPIParameter describes a single operation parameter.
+type OpenAPIParameter struct {
+	Name     string         `yaml:"name" json:"name"`
+	In       string         `yaml:"in" json:"in"`
+	Required bool           `yaml:"required" json:"required"`
+	Schema   *OpenAPISchema `yaml:"schema" json:"schema"`
+}
+
+// OpenAPIRequestBody describes a request body.
+type OpenAPIRequestBody struct {
+	Required bool                         `yaml:"required" json:"required"`
+	Content  map[string]*OpenAPIMediaType `yaml:"content" json:"content"`
+}
+
+// OpenAPIResponse describes a single response from an API operation.
+type OpenAPIResponse struct {
+	Description string                       `yaml:"description" json:"description"`
+	Content     map[string]*OpenAPIMediaType `yaml:"content" json:"content"`
+}
+
+// OpenAPIMediaType describes the content of a request or response body for
+// a single media type.
+type OpenAPIMediaType struct {
+	Schema   *OpenAPISchema             `yaml:"schema" json:"schema"`
+	Example  interface{}                `yaml:"example" json:"example"`
+	Examples map[string]*OpenAPIExample `yaml:"examples" json:"examples"`
+}
+
+// OpenAPIExample is a named example of a request or response body.
+type OpenAPIExample struct {
+	Summary string      `yaml:"summary" json:"summary"`
+	Value   interface{} `yaml:"value" json:"value"`
+}
+
+// OpenAPISchema is the subset of a JSON schema describing the shape of a value which is
+// needed for generating Go types for it and validating values against it.
+type OpenAPISchema struct {
+	Ref        string                    `yaml:"$ref" json:"$ref,omitempty"`
+	Type       string                    `yaml:"type" json:"type,omitempty"`
+	Format     string                    `yaml:"format" json:"format,omitempty"`
+	Properties map[string]*OpenAPISchema `yaml:"properties" json:"properties,omitempty"`
+	Required   []string                  `yaml:"required" json:"required,omitempty"`
+	Items      *OpenAPISchema            `yaml:"items" json:"items,omitempty"`
+	Enum       []interface{}             `yaml:"enum" json:"enum,omitempty"`
+	Nullable   bool                      `yaml:"nullable" json:"nullable,omitempty"`
+	AllOf      []*OpenAPISchema          `yaml:"allOf" json:"allOf,omitempty"`
+	OneOf      []*OpenAPISchema          `yaml:"oneOf" json:"oneOf,omitempty"`
+	AnyOf      []*OpenAPISchema          `yaml:"anyOf" json:"anyOf,omitempty"`
+	ReadOnly   bool                      `yaml:"readOnly" json:"readOnly,omitempty"`
+	WriteOnly  bool                      `yaml:"writeOnly" json:"writeOnly,omitempty"`
+
+	Minimum          *float64 `yaml:"minimum" json:"minimum,omitempty"`
+	Maximum          *float64 `yaml:"maximum" json:"maximum,omitempty"`
+	ExclusiveMinimum bool     `yaml:"exclusiveMinimum" json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum bool     `yaml:"exclusiveMaximum" json:"exclusiveMaximum,omitempty"`
+	MinLength        *int     `yaml:"minLength" json:"minLength,omitempty"`
+	MaxLength        *int     `yaml:"maxLength" json:"maxLength,omitempty"`
+	Pattern          string   `yaml:"pattern" json:"pattern,omitempty"`
+	MinItems         *int     `yaml:"minItems" json:"minItems,omitempty"`
+	MaxItems         *int     `yaml:"maxItems" json:"maxItems,omitempty"`
+
+	// AdditionalProperties is the schema of the properties of an object besides those
+	// in Properties. NoAdditionalProperties is set instead when they are not allowed.
+	AdditionalProperties   *OpenAPISchema `yaml:"-" json:"additionalProperties,omitempty"`
+	NoAdditionalProperties bool           `yaml:"-" json:"-"`
+}
+
+// UnmarshalYAML decodes the schema. The additionalProperties keyword may be either a
+// schema or a boolean.
+func (s *OpenAPISchema) UnmarshalYAML(value *yaml.Node) error {
+	type openAPISchema OpenAPISchema
+	var raw struct {
+		openAPISchema        `yaml:",inline"`
+		AdditionalProperties yaml.Node `yaml:"additionalProperties"`
+	}
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	*s = OpenAPISchema(raw.openAPISchema)
+
+	switch raw.AdditionalProperties.Kind {
+	case 0:
+	case yaml.ScalarNode:
+		var allowed bool
+		if err := raw.AdditionalProperties.Decode(&allowed); err != nil {
+			return err
+		}
+		s.NoAdditionalProperties = !allowed
+	default:
+		return raw.AdditionalProperties.Decode(&s.AdditionalProperties)
+	}
+	return nil
+}
+
+// ParseOpenAPISpec decodes a YAML or JSON encoded OpenAPI 3 document.
+func ParseOpenAPISpec(data []byte) (*OpenAPISpec, error) {
+	var spec OpenAPISpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", spec.OpenAPI)
+	}
+	return &spec, nil
+}
+
+// LoadOpenAPISpec reads and decodes the OpenAPI 3 document stored in the file at path.
+func LoadOpenAPISpec(path string) (*OpenAPISpec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := ParseOpenAPISpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec %q: %w", path, err)
+	}
+	return spec, nil
+}
+
+var openAPIPathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Endpoints converts the operations in the spec into Endpoints keyed by the operation
+// name. Operations are named after their operationId or after the method and path when
+// they have none. Path parameters of the form {id} are converted into %s verbs, or %v
+// verbs for those whose schema is not a string. Any x-mock-* vendor extensions of the
+// operations are applied last.
+//
+// The request and response bodies are typed when their schema can be expressed without
+// declaring a new type, such as a reference to a schema of the components, an array of
+// them or a primitive type. The types of the component schemas are described by GoTypes.
+// Every documented response is recorded in Responses by status code.
+func (s *OpenAPISpec) Endpoints() (map[string]Endpoint, error) {
+	endpoints := make(map[string]Endpoint)
+	for path, item := range s.Paths {
+		for method, op := range item.operations() {
+			if op.MockExtensions.Skip {
+				continue
+			}
+
+			name := op.OperationID
+			if op.MockExtensions.Name != "" {
+				name = op.MockExtensions.Name
+			}
+			if name == "" {
+				name = strings.ToLower(method) + " " + path
+			}
+			name = goIdentifier(name, true)
+			if _, ok := endpoints[name]; ok {
+				return nil, fmt.Errorf("multiple operations are named %s", name)
+			}
+
+			endpoint := Endpoint{
+				Method:         method,
+				BodyFormat:     BodyFormatNone,
+				ResponseFormat: ResponseFormatNone,
+			}
+
+			params := append(item.Parameters, op.Parameters...)
+			typed := false
+			var pathTypes []string
+			endpoint.Path = openAPIPathParam.ReplaceAllStringFunc(path, func(match string) string {
+				name := match[1 : len(match)-1]
+				endpoint.PathParameters = append(endpoint.PathParameters, goIdentifier(name, false))
+
+				goType := "string"
+				for _, param := range params {
+					if param.In == "path" && param.Name == name {
+						if t, ok, _ := s.goType(param.Schema, false); ok && t != "interface{}" {
+							goType = t
+						}
+					}
+				}
+				pathTypes = append(pathTypes, goType)
+				if goType == "string" {
+					return "%s"
+				}
+				typed = true
+				return "%v"
+			})
+			if typed {
+				endpoint.PathParameterTypes = pathTypes
+			}
+
+			for _, param := range params {
+				switch param.In {
+				case "query":
+					endpoint.QueryParams = true
+				case "header":
+					endpoint.Headers = true
+				}
+			}
+
+			if op.RequestBody != nil {
+				if media := jsonMediaType(op.RequestBody.Content); media != nil {
+					endpoint.BodyFormat = BodyFormatJSON
+					bodyType, err := s.bodyType(media)
+					if err != nil {
+						return nil, fmt.Errorf("invalid request body schema of %s: %w", name, err)
+					}
+					endpoint.BodyType = bodyType
+				} else if len(op.RequestBody.Content) > 0 {
+					endpoint.BodyFormat = BodyFormatString
+				}
+			}
+
+			statuses := make([]string, 0, len(op.Responses))
+			for status := range op.Responses {
+				statuses = append(statuses, status)
+			}
+			sort.Strings(statuses)
+
+			for _, status := range statuses {
+				resp := op.Responses[status]
+				if resp == nil {
+					resp = &OpenAPIResponse{}
+				}
+
+				documented := EndpointResponse{Format: ResponseFormatNone}
+				if len(resp.Content) > 0 {
+					documented.Format = ResponseFormatString
+				}
+
+				media := jsonMediaType(resp.Content)
+				if media != nil {
+					documented.Format = ResponseFormatJSON
+					respType, err := s.bodyType(media)
+					if err != nil {
+						return nil, fmt.Errorf("invalid schema for the %s response of %s: %w", status, name, err)
+					}
+					documented.Type = respType
+
+					if strings.HasPrefix(status, "2") && endpoint.ResponseFormat != ResponseFormatJSON {
+						endpoint.ResponseFormat = ResponseFormatJSON
+						endpoint.ResponseType = respType
+					}
+
+					example, err := media.example()
+					if err != nil {
+						return nil, fmt.Errorf("invalid example for the %s response of %s: %w", status, name, err)
+					}
+					if example != nil {
+						if endpoint.ResponseExamples == nil {
+							endpoint.ResponseExamples = make(map[string]json.RawMessage)
+						}
+						endpoint.ResponseExamples[status] = example
+					}
+				}
+
+				if endpoint.Responses == nil {
+					endpoint.Responses = make(map[string]EndpointResponse)
+				}
+				endpoint.Responses[status] = documented
+			}
+
+			op.MockExtensions.apply(&endpoint)
+			endpoints[name] = endpoint
+		}
+	}
+	return endpoints, nil
+}
+
+// GoTypes returns the Go type definitions for the schemas of the components keyed by
+// the name of the type, such as "struct { ID string `json:\"id\"` }" for "Node". The
+// types of Endpoints refer to these for the schemas the bodies reference.
+func (s *OpenAPISpec) GoTypes() (map[string]string, error) {
+	types := make(map[string]string)
+	for name, schema := range s.Components.Schemas {
+		typeName := goIdentifier(name, true)
+		if _, ok := types[typeName]; ok {
+			return nil, fmt.Errorf("multiple schemas are named %s", typeName)
+		}
+
+		def, _, err := s.goType(schema, true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema %s: %w", name, err)
+		}
+		types[typeName] = def
+	}
+	return types, nil
+}
+
+// bodyType returns the Go type of the body of the media type, if it can be
+// expressed without declaring a new type.
+func (s *OpenAPISpec) bodyType(media *OpenAPIMediaType) (string, error) {
+	goType, ok, err := s.goType(media.Schema, false)
+	if err != nil || !ok || goType == "interface{}" {
+		return "", err
+	}
+	return goType, nil
+}
+
+const openAPISchemaRefPrefix = "#/components/schemas/"
+
+// resolve returns the component schema a $ref refers to along with its name.
+func (s *OpenAPISpec) resolve(ref string) (string, *OpenAPISchema, error) {
+	name := strings.TrimPrefix(ref, openAPISchemaRefPrefix)
+	schema, ok := s.Components.Schemas[name]
+	if name == ref || !ok {
+		return "", nil, fmt.Errorf("unresolvable schema reference %q", ref)
+	}
+	return name, schema, nil
+}
+
+// goType returns the Go type for values of the schema. Objects with properties can only
+// be expressed by a struct type, when structs is false ok is false for them instead.
+func (s *OpenAPISpec) goType(schema *OpenAPISchema, structs bool) (goType string, ok bool, err error) {
+	if schema == nil {
+		return "interface{}", true, nil
+	}
+
+	if schema.Ref != "" {
+		name, _, err := s.resolve(schema.Ref)
+		if err != nil {
+			return "", false, err
+		}
+		return goIdentifier(name, true), true, nil
+	}
+
+	if len(schema.AllOf) == 1 {
+		return s.goType(schema.AllOf[0], structs)
+	}
+	if len(schema.AllOf) > 0 || len(schema.OneOf) > 0 || len(schema.AnyOf) > 0 {
+		return "interface{}", true, nil
+	}
+
+	switch schema.Type {
+	case "string":
+		return "string", true, nil
+	case "integer":
+		switch schema.Format {
+		case "int32", "int64":
+			return schema.Format, true, nil
+		}
+		return "int", true, nil
+	case "number":
+		if schema.Format == "float" {
+			return "float32", true, nil
+		}
+		return "float64", true, nil
+	case "boolean":
+		return "bool", true, nil
+	case "array":
+		items, ok, err := s.goType(schema.Items, structs)
+		if err != nil || !ok {
+			return "", ok, err
+		}
+		return "[]" + items, true, nil
+	case "object", "":
+		if len(schema.Properties) > 0 {
+			if !structs {
+				return "", false, nil
+			}
+			return s.goStruct(schema)
+		}
+		if schema.AdditionalProperties != nil {
+			values, ok, err := s.goType(schema.AdditionalProperties, structs)
+			if err != nil || !ok {
+				return "", ok, err
+			}
+			return "map[string]" + values, true, nil
+		}
+		if schema.Type == "object" {
+			return "map[string]interface{}", true, nil
+		}
+		return "interface{}", true, nil
+	}
+	return "", false, fmt.Errorf("unsupported schema type %q", schema.Type)
+}
+
+// goStruct returns the struct type for an object schema with properties. Properties
+// which are not required are omitted when empty, with structs becoming pointers.
+func (s *OpenAPISpec) goStruct(schema *OpenAPISchema) (string, bool, error) {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("struct {\n")
+	for _, name := range names {
+		prop := schema.Properties[name]
+		fieldType, _, err := s.goType(prop, true)
+		if err != nil {
+			return "", false, fmt.Errorf("property %s: %w", name, err)
+		}
+
+		tag := name
+		if !contains(schema.Required, name) {
+			tag += ",omitempty"
+			if strings.HasPrefix(fieldType, "struct") || s.isStructRef(prop) {
+				fieldType = "*" + fieldType
+			}
+		}
+		fmt.Fprintf(&sb, "\t%s %s `json:%q`\n", goIdentifier(name, true), fieldType, tag)
+	}
+	sb.WriteString("}")
+	return sb.String(), true, nil
+}
+
+// isStructRef returns whether the schema refers to a component schema which is an
+// object with properties.
+func (s *OpenAPISpec) isStructRef(schema *OpenAPISchema) bool {
+	if schema == nil || schema.Ref == "" {
+		return false
+	}
+	_, target, err := s.resolve(schema.Ref)
+	return err == nil && len(target.Properties) > 0
+}
+
+// example returns the JSON encoding of the example for the media type. When
+// there is no single example, the first of the named examples is used.
+func (m *OpenAPIMediaType) example() (json.RawMessage, error) {
+	value := m.Example
+	if value == nil && len(m.Examples) > 0 {
+		names := make([]string, 0, len(m.Examples))
+		for name := range m.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		value = m.Examples[names[0]].Value
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+// jsonMediaType returns the JSON media type from the content if there is one.
+func jsonMediaType(content map[string]*OpenAPIMediaType) *OpenAPIMediaType {
+	for mediaType, media := range content {
+		mediaType = strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0])
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			if media == nil {
+				media = &OpenAPIMediaType{}
+			}
+			return media
+		}
+	}
+	return nil
+}
+
+// goIdentifier converts the name into a Go identifier by camel casing it
+// and dropping any invalid characters.
+func goIdentifier(name string, exported bool) string {
+	var b strings.Builder
+	upper := exported
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = b.Len() > 0 || exported
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(r) {
+			b.WriteRune('_')
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+		} else if b.Len() == 0 {
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+		upper = false
+	}
+
+	ident := b.String()
+	if token.IsKeyword(ident) {
+		ident += "_"
+	}
+	return ident
+}
@@ -0,0 +1,119 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPISpecEndpoints(t *testing.T) {
+	spec, err := LoadOpenAPISpec("testdata/openapi.yaml")
+	require.NoError(t, err)
+
+	endpoints, err := spec.Endpoints()
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]Endpoint{
+		"ListNodes": {
+			Method:         "GET",
+			Path:           "/v1/nodes",
+			BodyFormat:     BodyFormatNone,
+			ResponseFormat: ResponseFormatJSON,
+			QueryParams:    true,
+			ResponseExamples: map[string]json.RawMessage{
+				"200": json.RawMessage(`[{"id":"node-1"}]`),
+				"404": json.RawMessage(`{"title":"Not Found"}`),
+			},
+			Responses: map[string]EndpointResponse{
+				"200": {Format: ResponseFormatJSON},
+				"404": {Format: ResponseFormatJSON},
+			},
+		},
+		"DeleteV1NodesNodeId": {
+			Method:         "DELETE",
+			Path:           "/v1/nodes/%s",
+			PathParameters: []string{"nodeId"},
+			BodyFormat:     BodyFormatJSON,
+			ResponseFormat: ResponseFormatNone,
+			Headers:        true,
+			Responses: map[string]EndpointResponse{
+				"204": {Format: ResponseFormatNone},
+			},
+		},
+		"CheckHealth": {
+			Method:         "GET",
+			Path:           "/v1/health",
+			BodyFormat:     BodyFormatNone,
+			ResponseFormat: ResponseFormatString,
+			DefaultStatus:  200,
+			Responses: map[string]EndpointResponse{
+				"200": {Format: ResponseFormatNone},
+			},
+		},
+	}, endpoints)
+}
+
+func TestOpenAPISpecTypes(t *testing.T) {
+	spec, err := LoadOpenAPISpec("testdata/openapi-schemas.yaml")
+	require.NoError(t, err)
+
+	endpoints, err := spec.Endpoints()
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]Endpoint{
+		"GetNode": {
+			Method:             "GET",
+			Path:               "/v1/nodes/%v",
+			PathParameters:     []string{"id"},
+			PathParameterTypes: []string{"int64"},
+			BodyFormat:         BodyFormatNone,
+			ResponseFormat:     ResponseFormatJSON,
+			ResponseType:       "Node",
+			Responses: map[string]EndpointResponse{
+				"200": {Format: ResponseFormatJSON, Type: "Node"},
+				"404": {Format: ResponseFormatJSON, Type: "Problem"},
+			},
+		},
+		"CreateNodes": {
+			Method:         "POST",
+			Path:           "/v1/nodes",
+			BodyFormat:     BodyFormatJSON,
+			BodyType:       "[]Node",
+			ResponseFormat: ResponseFormatJSON,
+			ResponseType:   "map[string]int",
+			Responses: map[string]EndpointResponse{
+				"201": {Format: ResponseFormatJSON, Type: "map[string]int"},
+				"400": {Format: ResponseFormatString},
+			},
+		},
+		"UpdateNode": {
+			Method:         "PATCH",
+			Path:           "/v1/nodes/%s/labels",
+			PathParameters: []string{"name"},
+			BodyFormat:     BodyFormatJSON,
+			ResponseFormat: ResponseFormatNone,
+			Responses: map[string]EndpointResponse{
+				"204": {Format: ResponseFormatNone},
+			},
+		},
+	}, endpoints)
+
+	types, err := spec.GoTypes()
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{
+		"Node": "struct {\n" +
+			"\tId int64 `json:\"id\"`\n" +
+			"\tLabels map[string]string `json:\"labels,omitempty\"`\n" +
+			"\tParent *Node `json:\"parent,omitempty\"`\n" +
+			"\tSize float32 `json:\"size,omitempty\"`\n" +
+			"\tStatus Status `json:\"status\"`\n" +
+			"}",
+		"Problem": "struct {\n" +
+			"\tDetail string `json:\"detail,omitempty\"`\n" +
+			"\tTitle string `json:\"title\"`\n" +
+			"}",
+		"Status": "string",
+	}, types)
+}
@@ -0,0 +1,88 @@
+package mockapi
+
+import (
+	"fmt"
+	"net"
+)
+
+// Option is a functional option used to configure a MockAPI when it is
+// being created with New or NewMockAPI.
+type Option func(*MockAPI) error
+
+// WithTestingT sets the TestingT the MockAPI should report failures to. When
+// the value also implements CleanerT, the MockAPI will be automatically closed
+// when the test completes.
+func WithTestingT(t TestingT) Option {
+	return func(m *MockAPI) error {
+		m.t = t
+		return nil
+	}
+}
+
+// WithoutCleanup stops the MockAPI from being automatically closed when the test
+// completes even if the TestingT implements CleanerT. The caller is then responsible
+// for calling Close, which allows tests to manage the lifecycle of the MockAPI
+// themselves, for example to share it between subtests.
+func WithoutCleanup() Option {
+	return func(m *MockAPI) error {
+		m.noCleanup = true
+		return nil
+	}
+}
+
+// WithCanonicalHeaders makes the names of expected and filtered headers case-insensitive by
+// converting them to their canonical form, as is done for the headers of received requests.
+// Without it, an expectation for "x-api-key" never matches a request with an X-Api-Key header.
+func WithCanonicalHeaders() Option {
+	return func(m *MockAPI) error {
+		m.canonicalHeaders = true
+		return nil
+	}
+}
+
+// WithListenAddress sets the TCP address that the HTTP server should listen on
+// instead of an ephemeral port on the loopback interface.
+func WithListenAddress(addr string) Option {
+	return func(m *MockAPI) error {
+		m.listen = func() (net.Listener, error) {
+			l, err := net.Listen("tcp", addr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+			}
+			return l, nil
+		}
+		return nil
+	}
+}
+
+// WithListener makes the HTTP server accept connections from the given listener instead of
+// listening on an ephemeral port itself. This allows binding to a port chosen before the
+// test, for example one already written into the configuration of the system under test.
+// The listener is closed along with the MockAPI.
+func WithListener(l net.Listener) Option {
+	return func(m *MockAPI) error {
+		if l == nil {
+			return fmt.Errorf("nil listener")
+		}
+		m.listen = func() (net.Listener, error) {
+			return l, nil
+		}
+		return nil
+	}
+}
+
+// WithStartHook runs start with the MockAPI once its HTTP server is running so that additional
+// servers, such as ones for other protocols, may serve the expectations of the MockAPI
+// alongside it. The stop function returned by start is called when the MockAPI is closed.
+// An error from start fails the creation of the MockAPI.
+func WithStartHook(start func(m *MockAPI) (stop func(), err error)) Option {
+	return func(m *MockAPI) error {
+		if start == nil {
+			return fmt.Errorf("nil start hook")
+		}
+		m.startHooks = append(m.startHooks, func() (func(), error) {
+			return start(m)
+		})
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+package mockapi
+
+// NotBefore requires this call to only occur once all the given calls have occurred. The
+// order is asserted along with the other expectations, failing the test if this call first
+// occurred before the last occurrence of any of the given calls.
+func (m *MockAPICall) NotBefore(calls ...*MockAPICall) *MockAPICall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notBefore = append(m.notBefore, calls...)
+	return m
+}
+
+// InOrder requires the calls to occur in the given order such as for a create, poll and
+// delete workflow. Each call may occur multiple times but must not occur before the last
+// occurrence of the call preceding it.
+func (m *MockAPI) InOrder(calls ...*MockAPICall) {
+	for i := 1; i < len(calls); i++ {
+		calls[i].NotBefore(calls[i-1])
+	}
+}
+
+// assertOrder checks that the calls occurred in the order required by NotBefore.
+func (m *MockAPI) assertOrder(t TestingT) {
+	m.mu.Lock()
+	calls := make([]*MockAPICall, len(m.calls))
+	copy(calls, m.calls)
+	m.mu.Unlock()
+
+	for _, call := range calls {
+		call.mu.Lock()
+		notBefore := call.notBefore
+		call.mu.Unlock()
+
+		if len(notBefore) == 0 {
+			continue
+		}
+
+		at, ok := call.firstMatch()
+		if !ok {
+			// whether the call needed to happen at all is covered by the
+			// other expectation assertions
+			continue
+		}
+
+		for _, earlier := range notBefore {
+			last, ok := earlier.lastMatch()
+			if !ok {
+				t.Errorf("mockapi: %s was expected after %s which never occurred", call, earlier)
+				continue
+			}
+			if !last.Before(at) {
+				t.Errorf("mockapi: %s was expected after %s but occurred before it", call, earlier)
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestInOrder(t *testing.T) {
+	for name, tc := range map[string]struct {
+		requests []string
+		failures int
+	}{
+		"in order":     {requests: []string{"POST", "GET", "GET", "DELETE"}, failures: 0},
+		"out of order": {requests: []string{"POST", "DELETE", "GET"}, failures: 1},
+		"missing step": {requests: []string{"GET", "DELETE"}, failures: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := NewMockAPI(nil)
+			m.SetFilteredHeaders([]string{
+				"Accept-Encoding",
+				"User-Agent",
+				"Content-Length",
+			})
+
+			create := m.WithNoResponseBody(NewMockRequest("POST", "/resource"), 201).Maybe()
+			poll := m.WithNoResponseBody(NewMockRequest("GET", "/resource"), 200).Maybe()
+			del := m.WithNoResponseBody(NewMockRequest("DELETE", "/resource"), 204).Maybe()
+			m.InOrder(create, poll, del)
+
+			for _, method := range tc.requests {
+				req, err := http.NewRequest(method, fmt.Sprintf("%s/resource", m.URL()), nil)
+				if err != nil {
+					t.Fatalf("Error creating request: %v", err)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatalf("Error issuing %s of /resource: %v", method, err)
+				}
+				resp.Body.Close()
+			}
+
+			rt := &recordingT{}
+			m.AssertExpectations(rt)
+			m.Close()
+			if errs := rt.Errors(); len(errs) != tc.failures {
+				t.Fatalf("Expected %d ordering failures but got: %v", tc.failures, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package mockapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// pathParamName is the allowed form of the names of path template parameters.
+var pathParamName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type pathParamsKey struct{}
+
+// compilePathTemplate converts a path template such as "/users/{id}/posts/{postID}"
+// into a regular expression where each parameter matches a single non-empty path
+// segment, or part of one, and is captured as a named group.
+func compilePathTemplate(template string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	seen := make(map[string]bool)
+	rest := template
+	for {
+		open := strings.Index(rest, "{")
+		if open < 0 {
+			if strings.Contains(rest, "}") {
+				return nil, fmt.Errorf("unbalanced '}' in path template %q", template)
+			}
+			sb.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+
+		end := strings.Index(rest[open:], "}")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated parameter in path template %q", template)
+		}
+		name := rest[open+1 : open+end]
+		if !pathParamName.MatchString(name) {
+			return nil, fmt.Errorf("invalid parameter name %q in path template %q", name, template)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate parameter name %q in path template %q", name, template)
+		}
+		seen[name] = true
+
+		sb.WriteString(regexp.QuoteMeta(rest[:open]))
+		sb.WriteString("(?P<" + name + ">[^/]+)")
+		rest = rest[open+end+1:]
+	}
+
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// NewMockRequestPattern will create a new MockRequest matching any path the regular
+// expression matches, such as `^/v1/kv/.+$`. The expression is not anchored unless it
+// does so itself. The values of any named groups are available to the MockResponse
+// through PathParam.
+func NewMockRequestPattern(method string, pathRegexp *regexp.Regexp) *MockRequest {
+	req := &MockRequest{
+		method:      method,
+		pathPattern: pathRegexp,
+	}
+
+	if pathRegexp == nil {
+		req.errs = append(req.errs, fmt.Errorf("nil path pattern"))
+	} else {
+		req.path = pathRegexp.String()
+	}
+	return req
+}
+
+// isPathRegexp returns whether the request was created from a regular expression
+// rather than from a literal or templated path.
+func (r *MockRequest) isPathRegexp() bool {
+	return r.pathPattern != nil && r.path == r.pathPattern.String()
+}
+
+// pathArgument returns the argument to use for matching the path of the request.
+func (r *MockRequest) pathArgument() interface{} {
+	if r.pathPattern == nil {
+		return r.path
+	}
+
+	pattern := r.pathPattern
+	return mock.MatchedBy(func(path string) bool {
+		return pattern.MatchString(path)
+	})
+}
+
+// matchesPath returns whether the path of a received request matches the
+// expected path.
+func (r *MockRequest) matchesPath(path string) bool {
+	if r.pathPattern == nil {
+		return r.path == path
+	}
+	return r.pathPattern.MatchString(path)
+}
+
+// pathParams extracts the values of the named groups of the path pattern from
+// the path of a received request.
+func (r *MockRequest) pathParams(path string) map[string]string {
+	if r == nil || r.pathPattern == nil {
+		return nil
+	}
+
+	match := r.pathPattern.FindStringSubmatch(path)
+	if match == nil {
+		return nil
+	}
+
+	var params map[string]string
+	for i, name := range r.pathPattern.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params[name] = match[i]
+	}
+	return params
+}
+
+// PathParam returns the value of the named path parameter of a request matching an
+// expectation with a path template, such as the id of "/users/{id}". It is meant to
+// be used within MockResponse functions. An empty string is returned when the
+// request has no such parameter.
+func PathParam(r *http.Request, name string) string {
+	return PathParams(r)[name]
+}
+
+// PathParams returns the values of all the path parameters of a request matching an
+// expectation with a path template.
+func PathParams(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params
+}
+
+// withPathParams makes the path parameters available to the responder.
+func withPathParams(r *http.Request, params map[string]string) *http.Request {
+	if params == nil {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), pathParamsKey{}, params))
+}
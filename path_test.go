@@ -0,0 +1,119 @@
+package mockapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func TestPathTemplate(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	call := m.WithRequest(NewMockRequest("GET", "/users/{id}/posts/{postID}"), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s:%s", PathParam(r, "id"), PathParam(r, "postID"))
+	}).Twice()
+
+	for _, tc := range []struct{ path, expected string }{
+		{"/users/1/posts/abc", "1:abc"},
+		{"/users/jane.doe/posts/42", "jane.doe:42"},
+	} {
+		resp, err := http.Get(fmt.Sprintf("%s%s", m.URL(), tc.path))
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", tc.path, err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Error reading response body: %v", err)
+		}
+		if string(body) != tc.expected {
+			t.Fatalf("Expected body %q for %s but got %q", tc.expected, tc.path, body)
+		}
+	}
+
+	if params := call.Requests()[1].PathParams(); params["id"] != "jane.doe" || params["postID"] != "42" {
+		t.Fatalf("Unexpected captured path params: %v", params)
+	}
+}
+
+func TestPathTemplateSegments(t *testing.T) {
+	rt := &recordingT{}
+	m := NewMockAPI(rt)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+	m.WithNoResponseBody(NewMockRequest("GET", "/users/{id}"), 200).Maybe()
+
+	for path, expected := range map[string]int{
+		"/users/1":       200,
+		"/users/":        404,
+		"/users/1/posts": 404,
+	} {
+		resp, err := http.Get(fmt.Sprintf("%s%s", m.URL(), path))
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Fatalf("Expected status code %d for %s but got %d", expected, path, resp.StatusCode)
+		}
+	}
+	m.Close()
+}
+
+func TestPathTemplateErrors(t *testing.T) {
+	for _, path := range []string{
+		"/users/{id",
+		"/users/id}",
+		"/users/{}",
+		"/users/{id}/posts/{id}",
+		"/users/{user-id}",
+	} {
+		if err := NewMockRequest("GET", path).validate(); err == nil {
+			t.Fatalf("Expected an error for path template %q", path)
+		}
+	}
+}
+
+func TestPathPattern(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+	})
+
+	m.WithRequest(NewMockRequestPattern("PUT", regexp.MustCompile(`^/v1/kv/(?P<key>.+)$`)), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, PathParam(r, "key"))
+	}).Twice()
+
+	for _, key := range []string{"foo", "foo/bar/baz"} {
+		req, err := http.NewRequest("PUT", fmt.Sprintf("%s/v1/kv/%s", m.URL(), key), nil)
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Error issuing PUT of %s: %v", key, err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Error reading response body: %v", err)
+		}
+		if string(body) != key {
+			t.Fatalf("Expected body %q but got %q", key, body)
+		}
+	}
+
+	if err := NewMockRequestPattern("GET", nil).validate(); err == nil {
+		t.Fatalf("Expected an error for a nil path pattern")
+	}
+}
@@ -0,0 +1,328 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// PostmanCollection is a Postman collection in the v2.0 or v2.1 format. Only the parts of
+// the format needed to setup expectations from the example responses are decoded.
+type PostmanCollection struct {
+	Info     PostmanInfo       `json:"info"`
+	Item     []PostmanItem     `json:"item"`
+	Variable []PostmanVariable `json:"variable,omitempty"`
+}
+
+// PostmanInfo describes the collection.
+type PostmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// PostmanItem is either a request along with its example responses or, when Item
+// is not empty, a folder of further items.
+type PostmanItem struct {
+	Name     string            `json:"name"`
+	Item     []PostmanItem     `json:"item,omitempty"`
+	Request  *PostmanRequest   `json:"request,omitempty"`
+	Response []PostmanResponse `json:"response,omitempty"`
+}
+
+// PostmanRequest is a request of the collection.
+type PostmanRequest struct {
+	Method string            `json:"method"`
+	Header []PostmanKeyValue `json:"header,omitempty"`
+	URL    PostmanURL        `json:"url"`
+}
+
+// PostmanURL is the URL of a request. Collections may hold either the raw URL or
+// its parsed parts, both of which are decoded into a PostmanURL.
+type PostmanURL struct {
+	Raw      string            `json:"raw"`
+	Path     []string          `json:"path,omitempty"`
+	Query    []PostmanKeyValue `json:"query,omitempty"`
+	Variable []PostmanVariable `json:"variable,omitempty"`
+}
+
+// UnmarshalJSON decodes either a raw URL string or a URL object.
+func (u *PostmanURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = PostmanURL{Raw: raw}
+		return nil
+	}
+
+	type postmanURL PostmanURL
+	var parsed postmanURL
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+	*u = PostmanURL(parsed)
+	return nil
+}
+
+// PostmanKeyValue is a header or query param.
+type PostmanKeyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// PostmanVariable is a collection or path variable. Postman allows values which are
+// not strings, such as numbers, and so the value is kept as decoded.
+type PostmanVariable struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// PostmanResponse is an example response saved for a request.
+type PostmanResponse struct {
+	Name            string            `json:"name"`
+	OriginalRequest *PostmanRequest   `json:"originalRequest,omitempty"`
+	Code            int               `json:"code"`
+	Header          []PostmanKeyValue `json:"header,omitempty"`
+	Body            string            `json:"body,omitempty"`
+}
+
+// postmanVariableRef matches references to variables such as {{baseUrl}}.
+var postmanVariableRef = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// ParsePostmanCollection decodes a Postman collection.
+func ParsePostmanCollection(data []byte) (*PostmanCollection, error) {
+	var collection PostmanCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// LoadPostmanCollection reads and decodes the Postman collection stored in the file at path.
+func LoadPostmanCollection(path string) (*PostmanCollection, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, err := ParsePostmanCollection(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Postman collection %q: %w", path, err)
+	}
+	return collection, nil
+}
+
+// Fixture converts the example responses of every request in the collection, including
+// those within folders, into a Fixture. As examples describe what may happen rather than
+// what will, the expectations are optional and may be matched any number of times. When
+// a request has several examples, the first of them is the one which is replied with.
+//
+// Variables such as {{id}} are replaced by the given variables or else by the variables
+// of the collection. Variables of the path which are left unresolved, including Postman
+// path variables such as :id without a value, become path template parameters matching
+// any value. Only the method, path and query params are expected as the headers and
+// bodies of examples are mostly illustrative. Those which are significant can be added
+// to the requests of the returned Fixture.
+func (c *PostmanCollection) Fixture(variables map[string]string) (*Fixture, error) {
+	vars := make(map[string]string)
+	for _, v := range c.Variable {
+		vars[v.Key] = postmanValue(v.Value)
+	}
+	for key, value := range variables {
+		vars[key] = value
+	}
+
+	fixture := &Fixture{}
+	if err := postmanExpectations(fixture, c.Item, vars); err != nil {
+		return nil, err
+	}
+	return fixture, nil
+}
+
+func postmanExpectations(fixture *Fixture, items []PostmanItem, vars map[string]string) error {
+	for _, item := range items {
+		if err := postmanExpectations(fixture, item.Item, vars); err != nil {
+			return err
+		}
+
+		for _, example := range item.Response {
+			req := example.OriginalRequest
+			if req == nil {
+				req = item.Request
+			}
+			if req == nil {
+				continue
+			}
+
+			fixtureReq, err := req.fixtureRequest(vars)
+			if err != nil {
+				return fmt.Errorf("example %q of request %q: %w", example.Name, item.Name, err)
+			}
+
+			headers := make(map[string][]string)
+			for _, hdr := range example.Header {
+				if !hdr.Disabled {
+					headers[hdr.Key] = append(headers[hdr.Key], hdr.Value)
+				}
+			}
+
+			resp := FixtureResponse{
+				Status:  example.Code,
+				Headers: firstValues(headers, harSkippedResponseHeaders),
+			}
+			resp.setRecordedBody(example.Body)
+
+			fixture.Expectations = append(fixture.Expectations, FixtureExpectation{
+				Request:  fixtureReq,
+				Response: resp,
+				Optional: true,
+			})
+		}
+	}
+	return nil
+}
+
+// fixtureRequest converts the request into a FixtureRequest.
+func (r *PostmanRequest) fixtureRequest(vars map[string]string) (FixtureRequest, error) {
+	method := r.Method
+	if method == "" {
+		method = "GET"
+	}
+
+	segments := r.URL.Path
+	var query map[string]string
+	if len(segments) == 0 {
+		u, err := r.URL.parseRaw(vars)
+		if err != nil {
+			return FixtureRequest{}, err
+		}
+		segments = strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+		query = firstValues(u.Query(), nil)
+	} else {
+		for _, param := range r.URL.Query {
+			if param.Disabled {
+				continue
+			}
+			if query == nil {
+				query = make(map[string]string)
+			}
+			query[param.Key] = postmanResolve(param.Value, vars)
+		}
+	}
+
+	pathVars := make(map[string]string)
+	for _, v := range r.URL.Variable {
+		pathVars[v.Key] = postmanValue(v.Value)
+	}
+
+	seen := make(map[string]int)
+	param := func(name string) string {
+		name = postmanParamName(name)
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s_%d", name, seen[name])
+		}
+		return "{" + name + "}"
+	}
+
+	var path strings.Builder
+	for _, segment := range segments {
+		path.WriteString("/")
+		if strings.HasPrefix(segment, ":") {
+			value := pathVars[segment[1:]]
+			if value == "" {
+				path.WriteString(param(segment[1:]))
+				continue
+			}
+			segment = value
+		}
+
+		segment = postmanResolve(segment, vars)
+		path.WriteString(postmanVariableRef.ReplaceAllStringFunc(segment, func(ref string) string {
+			return param(ref[2 : len(ref)-2])
+		}))
+	}
+	if path.Len() == 0 {
+		path.WriteString("/")
+	}
+
+	return FixtureRequest{
+		Method:      method,
+		Path:        path.String(),
+		QueryParams: query,
+	}, nil
+}
+
+// parseRaw parses the raw URL after resolving its variables. A leading host which
+// is an unresolved variable, such as {{baseUrl}}, is dropped.
+func (u *PostmanURL) parseRaw(vars map[string]string) (*url.URL, error) {
+	raw := postmanResolve(u.Raw, vars)
+	if loc := postmanVariableRef.FindStringIndex(raw); loc != nil && loc[0] == 0 {
+		raw = raw[loc[1]:]
+	}
+	if !strings.Contains(raw, "://") && !strings.HasPrefix(raw, "/") {
+		raw = "http://" + raw
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", u.Raw, err)
+	}
+	return parsed, nil
+}
+
+// postmanResolve replaces references to known variables with their values.
+func postmanResolve(s string, vars map[string]string) string {
+	return postmanVariableRef.ReplaceAllStringFunc(s, func(ref string) string {
+		if value, ok := vars[ref[2:len(ref)-2]]; ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// postmanParamName converts a variable name into a valid path template parameter name.
+func postmanParamName(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+			sb.WriteRune(r)
+		case r >= '0' && r <= '9' && i > 0:
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	return sb.String()
+}
+
+// postmanValue formats the value of a variable.
+func postmanValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// LoadPostmanCollectionFile will load the Postman collection stored in the file at path and
+// setup an expectation for each of its example responses. See PostmanCollection.Fixture for
+// details.
+func (m *MockAPI) LoadPostmanCollectionFile(path string, variables map[string]string) error {
+	collection, err := LoadPostmanCollection(path)
+	if err != nil {
+		return err
+	}
+
+	fixture, err := collection.Fixture(variables)
+	if err != nil {
+		return fmt.Errorf("failed to convert Postman collection %q: %w", path, err)
+	}
+	return m.WithFixture(fixture)
+}
@@ -0,0 +1,65 @@
+package mockapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestLoadPostmanCollectionFile(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	if err := m.LoadPostmanCollectionFile("testdata/collection.postman.json", map[string]string{"version": "2"}); err != nil {
+		t.Fatalf("Error loading Postman collection: %v", err)
+	}
+
+	get := func(path string) (int, string, http.Header) {
+		resp, err := http.Get(fmt.Sprintf("%s%s", m.URL(), path))
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), resp.Header
+	}
+
+	if status, body, header := get("/v2/nodes/node-1"); status != 200 || body != `{"id": "node-1"}` || header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Unexpected response: %d %s %v", status, body, header)
+	}
+	if status, body, _ := get("/v2/nodes?region=east"); status != 200 || body != `[{"id": "node-1"}]` {
+		t.Fatalf("Unexpected response: %d %s", status, body)
+	}
+}
+
+func TestPostmanCollectionFixture(t *testing.T) {
+	collection, err := LoadPostmanCollection("testdata/collection.postman.json")
+	if err != nil {
+		t.Fatalf("Error loading Postman collection: %v", err)
+	}
+
+	fixture, err := collection.Fixture(nil)
+	if err != nil {
+		t.Fatalf("Error converting Postman collection: %v", err)
+	}
+
+	var paths []string
+	for _, exp := range fixture.Expectations {
+		if !exp.Optional {
+			t.Fatalf("Expected the expectations for examples to be optional")
+		}
+		paths = append(paths, exp.Request.Path)
+	}
+
+	expected := []string{"/v1/nodes/{nodeID}", "/v1/nodes", "/health"}
+	if fmt.Sprint(paths) != fmt.Sprint(expected) {
+		t.Fatalf("Expected paths %v but got %v", expected, paths)
+	}
+	if _, ok := fixture.Expectations[0].Response.Headers["Date"]; ok {
+		t.Fatalf("Expected the Date header to be dropped")
+	}
+}
@@ -0,0 +1,144 @@
+// Package nomad provides a MockAPI preset for the HashiCorp Nomad HTTP API. It
+// covers the job, allocation and evaluation endpoints along with Nomad's
+// blocking queries so that Nomad API clients can be tested without a Nomad
+// agent. The job, allocation and evaluation bodies are left to the caller and
+// may be any value which encodes to the desired JSON, such as the types of the
+// github.com/hashicorp/nomad/api package.
+package nomad
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	mockapi "github.com/mkeeler/mock-http-api"
+	"github.com/stretchr/testify/require"
+)
+
+// IndexHeader is the header holding the Raft index of the data returned by a
+// query. Clients pass it back as the index query param of a blocking query.
+const IndexHeader = "X-Nomad-Index"
+
+// MockAPI is a MockAPI with helpers for the Nomad HTTP API.
+type MockAPI struct {
+	*mockapi.MockAPI
+	t mockapi.TestingT
+}
+
+// NewMockAPI creates a MockAPI for the Nomad HTTP API. Headers added by HTTP clients
+// (Accept-Encoding, User-Agent, Content-Length and Content-Type) and the wait query
+// param of blocking queries are filtered.
+func NewMockAPI(t mockapi.TestingT, opts ...mockapi.Option) *MockAPI {
+	m := &MockAPI{
+		MockAPI: mockapi.NewMockAPI(t, opts...),
+		t:       t,
+	}
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+	m.SetFilteredQueryParams([]string{"wait"})
+	return m
+}
+
+// JobRegisterResponse is the reply to registering or deregistering a job.
+type JobRegisterResponse struct {
+	EvalID          string
+	EvalCreateIndex uint64
+	JobModifyIndex  uint64
+	Warnings        string `json:",omitempty"`
+}
+
+// RegisterJob expects the job to be registered and replies with resp. The job
+// is matched against the Job field of the request body.
+func (m *MockAPI) RegisterJob(job map[string]interface{}, resp JobRegisterResponse) *mockapi.MockAPICall {
+	req := mockapi.NewMockRequest("PUT", "/v1/jobs").WithBody(map[string]interface{}{"Job": job})
+	return m.WithRequest(req, m.reply(http.StatusOK, resp.JobModifyIndex, resp))
+}
+
+// DeregisterJob expects the job with the given ID to be stopped and replies with resp.
+func (m *MockAPI) DeregisterJob(jobID string, resp JobRegisterResponse) *mockapi.MockAPICall {
+	req := mockapi.NewMockRequest("DELETE", fmt.Sprintf("/v1/job/%s", jobID))
+	return m.WithRequest(req, m.reply(http.StatusOK, resp.JobModifyIndex, resp))
+}
+
+// ListJobs expects the jobs to be listed and replies with the job stubs.
+func (m *MockAPI) ListJobs(index uint64, jobs interface{}) *mockapi.MockAPICall {
+	return m.Read("/v1/jobs", index, jobs)
+}
+
+// ReadJob expects the job with the given ID to be read and replies with it.
+func (m *MockAPI) ReadJob(jobID string, index uint64, job interface{}) *mockapi.MockAPICall {
+	return m.Read(fmt.Sprintf("/v1/job/%s", jobID), index, job)
+}
+
+// JobNotFound expects the job with the given ID to be read and replies that it
+// does not exist.
+func (m *MockAPI) JobNotFound(jobID string) *mockapi.MockAPICall {
+	req := mockapi.NewMockRequest("GET", fmt.Sprintf("/v1/job/%s", jobID))
+	return m.WithTextReply(req, http.StatusNotFound, "job not found")
+}
+
+// JobAllocations expects the allocations of the job with the given ID to be
+// listed and replies with the allocation stubs.
+func (m *MockAPI) JobAllocations(jobID string, index uint64, allocs interface{}) *mockapi.MockAPICall {
+	return m.Read(fmt.Sprintf("/v1/job/%s/allocations", jobID), index, allocs)
+}
+
+// JobEvaluations expects the evaluations of the job with the given ID to be
+// listed and replies with them.
+func (m *MockAPI) JobEvaluations(jobID string, index uint64, evals interface{}) *mockapi.MockAPICall {
+	return m.Read(fmt.Sprintf("/v1/job/%s/evaluations", jobID), index, evals)
+}
+
+// ReadAllocation expects the allocation with the given ID to be read and replies with it.
+func (m *MockAPI) ReadAllocation(allocID string, index uint64, alloc interface{}) *mockapi.MockAPICall {
+	return m.Read(fmt.Sprintf("/v1/allocation/%s", allocID), index, alloc)
+}
+
+// ReadEvaluation expects the evaluation with the given ID to be read and replies with it.
+func (m *MockAPI) ReadEvaluation(evalID string, index uint64, eval interface{}) *mockapi.MockAPICall {
+	return m.Read(fmt.Sprintf("/v1/evaluation/%s", evalID), index, eval)
+}
+
+// EvaluationAllocations expects the allocations created by the evaluation with the
+// given ID to be listed and replies with the allocation stubs.
+func (m *MockAPI) EvaluationAllocations(evalID string, index uint64, allocs interface{}) *mockapi.MockAPICall {
+	return m.Read(fmt.Sprintf("/v1/evaluation/%s/allocations", evalID), index, allocs)
+}
+
+// Read expects a non-blocking GET of path and replies with the JSON encoding of
+// reply along with the Nomad query metadata headers for the given index.
+func (m *MockAPI) Read(path string, index uint64, reply interface{}) *mockapi.MockAPICall {
+	req := mockapi.NewMockRequest("GET", path)
+	return m.WithRequest(req, m.reply(http.StatusOK, index, reply))
+}
+
+// BlockingQuery expects a blocking GET of path waiting for data newer than
+// waitIndex and replies with reply at index. Chain WaitUntil on the returned
+// call to hold the reply until the data should change.
+func (m *MockAPI) BlockingQuery(path string, waitIndex, index uint64, reply interface{}) *mockapi.MockAPICall {
+	req := mockapi.NewMockRequest("GET", path).WithQueryParams(map[string]string{
+		"index": strconv.FormatUint(waitIndex, 10),
+	})
+	return m.WithRequest(req, m.reply(http.StatusOK, index, reply))
+}
+
+// reply creates a response with the JSON encoding of v and the query metadata
+// headers Nomad sets.
+func (m *MockAPI) reply(status int, index uint64, v interface{}) mockapi.MockResponse {
+	body, err := json.Marshal(v)
+	require.NoError(m.t, err)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(IndexHeader, strconv.FormatUint(index, 10))
+		w.Header().Set("X-Nomad-KnownLeader", "true")
+		w.Header().Set("X-Nomad-LastContact", "0")
+		w.WriteHeader(status)
+		w.Write(body)
+	}
+}
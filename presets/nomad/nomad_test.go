@@ -0,0 +1,60 @@
+package nomad
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNomadJobs(t *testing.T) {
+	m := NewMockAPI(t)
+
+	m.RegisterJob(map[string]interface{}{"ID": "example", "Type": "service"}, JobRegisterResponse{
+		EvalID:         "eval-1",
+		JobModifyIndex: 10,
+	}).Once()
+	m.ReadJob("example", 10, map[string]interface{}{"ID": "example", "Status": "pending"}).Once()
+
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("%s/v1/jobs", m.URL()), strings.NewReader(`{"Job":{"ID":"example","Type":"service"}}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error registering job: %v", err)
+	}
+	var reg JobRegisterResponse
+	json.NewDecoder(resp.Body).Decode(&reg)
+	resp.Body.Close()
+	if reg.EvalID != "eval-1" || resp.Header.Get(IndexHeader) != "10" {
+		t.Fatalf("Unexpected register response: %+v %v", reg, resp.Header)
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/v1/job/example", m.URL()))
+	if err != nil {
+		t.Fatalf("Error reading job: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 || resp.Header.Get(IndexHeader) != "10" {
+		t.Fatalf("Unexpected read response: %d %v", resp.StatusCode, resp.Header)
+	}
+}
+
+func TestNomadBlockingQuery(t *testing.T) {
+	m := NewMockAPI(t)
+
+	m.BlockingQuery("/v1/job/example/allocations", 10, 12, []map[string]interface{}{{"ID": "alloc-1"}}).
+		Once().
+		WaitUntil(time.After(20 * time.Millisecond))
+
+	start := time.Now()
+	resp, err := http.Get(fmt.Sprintf("%s/v1/job/example/allocations?index=10&wait=5m", m.URL()))
+	if err != nil {
+		t.Fatalf("Error querying allocations: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.Header.Get(IndexHeader) != "12" || time.Since(start) < 20*time.Millisecond {
+		t.Fatalf("Unexpected blocking query response: %v", resp.Header)
+	}
+}
@@ -0,0 +1,191 @@
+// Package terraform provides a MockAPI preset implementing the Terraform provider
+// and module registry protocols. Together with the service discovery document it
+// allows registry clients, including Terraform itself, to be tested fully offline.
+//
+// Terraform only talks to registries over HTTPS so the MockAPI should usually be
+// created with the mockapi.WithTLS option.
+package terraform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	mockapi "github.com/mkeeler/mock-http-api"
+)
+
+const (
+	// ProvidersPath is where the provider registry protocol is served.
+	ProvidersPath = "/v1/providers/"
+	// ModulesPath is where the module registry protocol is served.
+	ModulesPath = "/v1/modules/"
+)
+
+// MockAPI is a MockAPI with helpers for the Terraform registry protocols.
+type MockAPI struct {
+	*mockapi.MockAPI
+}
+
+// NewMockAPI creates a MockAPI for the Terraform registry protocols. Headers added
+// by HTTP clients (Accept-Encoding and User-Agent) and the X-Terraform-Version header
+// sent by Terraform are filtered.
+func NewMockAPI(t mockapi.TestingT, opts ...mockapi.Option) *MockAPI {
+	m := &MockAPI{
+		MockAPI: mockapi.NewMockAPI(t, opts...),
+	}
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"X-Terraform-Version",
+	})
+	return m
+}
+
+// ServiceDiscovery expects the service discovery document to be requested and
+// replies that both the provider and module registry protocols are supported.
+func (m *MockAPI) ServiceDiscovery() *mockapi.MockAPICall {
+	req := mockapi.NewMockRequest("GET", "/.well-known/terraform.json")
+	return m.WithJSONReply(req, http.StatusOK, map[string]string{
+		"providers.v1": ProvidersPath,
+		"modules.v1":   ModulesPath,
+	})
+}
+
+// Platform is an operating system and architecture a provider is available for.
+type Platform struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+// ProviderVersion is an available version of a provider.
+type ProviderVersion struct {
+	Version   string     `json:"version"`
+	Protocols []string   `json:"protocols"`
+	Platforms []Platform `json:"platforms"`
+}
+
+// GPGPublicKey is a key which may have signed a provider's checksums.
+type GPGPublicKey struct {
+	KeyID          string `json:"key_id"`
+	ASCIIArmor     string `json:"ascii_armor"`
+	TrustSignature string `json:"trust_signature"`
+	Source         string `json:"source"`
+	SourceURL      string `json:"source_url"`
+}
+
+// SigningKeys are the keys which may have signed a provider's checksums.
+type SigningKeys struct {
+	GPGPublicKeys []GPGPublicKey `json:"gpg_public_keys"`
+}
+
+// ProviderPackage is a provider release for a single platform.
+type ProviderPackage struct {
+	Protocols []string
+	Platform  Platform
+	// Filename defaults to terraform-provider-<type>_<version>_<os>_<arch>.zip
+	Filename string
+	// Archive is the zip archive served for download. Its checksum is computed
+	// and served in the SHA256SUMS document.
+	Archive []byte
+	// SHASumsSignature is the detached GPG signature of the SHA256SUMS document
+	// which is served as is. The SHA256SUMS document holds a single line of the
+	// form "<hex sha256>  <filename>\n" for which the signature must be created.
+	SHASumsSignature []byte
+	SigningKeys      SigningKeys
+}
+
+// providerDownload is the reply to a provider download request.
+type providerDownload struct {
+	Protocols           []string    `json:"protocols"`
+	OS                  string      `json:"os"`
+	Arch                string      `json:"arch"`
+	Filename            string      `json:"filename"`
+	DownloadURL         string      `json:"download_url"`
+	SHASumsURL          string      `json:"shasums_url"`
+	SHASumsSignatureURL string      `json:"shasums_signature_url"`
+	SHASum              string      `json:"shasum"`
+	SigningKeys         SigningKeys `json:"signing_keys"`
+}
+
+// ProviderVersions expects the available versions of the provider to be listed
+// and replies with the given versions.
+func (m *MockAPI) ProviderVersions(namespace, providerType string, versions ...ProviderVersion) *mockapi.MockAPICall {
+	if versions == nil {
+		versions = []ProviderVersion{}
+	}
+
+	req := mockapi.NewMockRequest("GET", fmt.Sprintf("%s%s/%s/versions", ProvidersPath, namespace, providerType))
+	return m.WithJSONReply(req, http.StatusOK, map[string]interface{}{"versions": versions})
+}
+
+// ProviderDownload expects the download location of the provider package to be
+// requested and replies with URLs served by the MockAPI itself. The archive,
+// SHA256SUMS document and its signature may then be downloaded any number of times.
+func (m *MockAPI) ProviderDownload(namespace, providerType, version string, pkg ProviderPackage) *mockapi.MockAPICall {
+	filename := pkg.Filename
+	if filename == "" {
+		filename = fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", providerType, version, pkg.Platform.OS, pkg.Platform.Arch)
+	}
+	sum := sha256.Sum256(pkg.Archive)
+	shasum := hex.EncodeToString(sum[:])
+	shasums := fmt.Sprintf("%s  %s\n", shasum, filename)
+
+	filesPath := fmt.Sprintf("/files/providers/%s/%s/%s", namespace, providerType, version)
+	shasumsName := fmt.Sprintf("terraform-provider-%s_%s_SHA256SUMS", providerType, version)
+
+	m.file(fmt.Sprintf("%s/%s", filesPath, filename), "application/zip", pkg.Archive)
+	m.file(fmt.Sprintf("%s/%s", filesPath, shasumsName), "text/plain", []byte(shasums))
+	m.file(fmt.Sprintf("%s/%s.sig", filesPath, shasumsName), "application/octet-stream", pkg.SHASumsSignature)
+
+	req := mockapi.NewMockRequest("GET", fmt.Sprintf("%s%s/%s/%s/download/%s/%s",
+		ProvidersPath, namespace, providerType, version, pkg.Platform.OS, pkg.Platform.Arch))
+	return m.WithJSONReply(req, http.StatusOK, &providerDownload{
+		Protocols:           pkg.Protocols,
+		OS:                  pkg.Platform.OS,
+		Arch:                pkg.Platform.Arch,
+		Filename:            filename,
+		DownloadURL:         fmt.Sprintf("%s%s/%s", m.URL(), filesPath, filename),
+		SHASumsURL:          fmt.Sprintf("%s%s/%s", m.URL(), filesPath, shasumsName),
+		SHASumsSignatureURL: fmt.Sprintf("%s%s/%s.sig", m.URL(), filesPath, shasumsName),
+		SHASum:              shasum,
+		SigningKeys:         pkg.SigningKeys,
+	})
+}
+
+// ModuleVersions expects the available versions of the module to be listed and
+// replies with the given versions.
+func (m *MockAPI) ModuleVersions(namespace, name, system string, versions ...string) *mockapi.MockAPICall {
+	type moduleVersion struct {
+		Version string `json:"version"`
+	}
+	mvs := []moduleVersion{}
+	for _, v := range versions {
+		mvs = append(mvs, moduleVersion{Version: v})
+	}
+
+	req := mockapi.NewMockRequest("GET", fmt.Sprintf("%s%s/%s/%s/versions", ModulesPath, namespace, name, system))
+	return m.WithJSONReply(req, http.StatusOK, map[string]interface{}{
+		"modules": []map[string]interface{}{{"versions": mvs}},
+	})
+}
+
+// ModuleDownload expects the download location of the module version to be
+// requested and replies with source, which may be any source address Terraform
+// understands such as a git URL.
+func (m *MockAPI) ModuleDownload(namespace, name, system, version, source string) *mockapi.MockAPICall {
+	req := mockapi.NewMockRequest("GET", fmt.Sprintf("%s%s/%s/%s/%s/download", ModulesPath, namespace, name, system, version))
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Terraform-Get", source)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// file serves the content at path any number of times.
+func (m *MockAPI) file(path, contentType string, content []byte) {
+	m.WithRequest(mockapi.NewMockRequest("GET", path), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(content)
+	}).Maybe()
+}
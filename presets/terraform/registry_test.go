@@ -0,0 +1,112 @@
+package terraform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	mockapi "github.com/mkeeler/mock-http-api"
+)
+
+func getJSON(t *testing.T, client *http.Client, url string, into interface{}) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Error issuing GET of %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(into); err != nil {
+		t.Fatalf("Error decoding response of %s: %v", url, err)
+	}
+}
+
+func TestProviderRegistry(t *testing.T) {
+	m := NewMockAPI(t, mockapi.WithTLS())
+	client := m.Client()
+
+	archive := []byte("not really a zip")
+	m.ServiceDiscovery().Once()
+	m.ProviderVersions("hashicorp", "nomad", ProviderVersion{
+		Version:   "2.0.0",
+		Protocols: []string{"5.0"},
+		Platforms: []Platform{{OS: "linux", Arch: "amd64"}},
+	}).Once()
+	m.ProviderDownload("hashicorp", "nomad", "2.0.0", ProviderPackage{
+		Protocols: []string{"5.0"},
+		Platform:  Platform{OS: "linux", Arch: "amd64"},
+		Archive:   archive,
+	}).Once()
+
+	var services map[string]string
+	getJSON(t, client, m.URL()+"/.well-known/terraform.json", &services)
+	if services["providers.v1"] != ProvidersPath {
+		t.Fatalf("Unexpected service discovery document: %v", services)
+	}
+
+	var versions struct {
+		Versions []ProviderVersion `json:"versions"`
+	}
+	getJSON(t, client, m.URL()+services["providers.v1"]+"hashicorp/nomad/versions", &versions)
+	if len(versions.Versions) != 1 || versions.Versions[0].Version != "2.0.0" {
+		t.Fatalf("Unexpected versions: %+v", versions)
+	}
+
+	var download providerDownload
+	getJSON(t, client, m.URL()+ProvidersPath+"hashicorp/nomad/2.0.0/download/linux/amd64", &download)
+
+	resp, err := client.Get(download.DownloadURL)
+	if err != nil {
+		t.Fatalf("Error downloading provider: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != download.SHASum {
+		t.Fatalf("Downloaded archive does not match the shasum %s", download.SHASum)
+	}
+
+	resp, err = client.Get(download.SHASumsURL)
+	if err != nil {
+		t.Fatalf("Error downloading shasums: %v", err)
+	}
+	body, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if !strings.HasPrefix(string(body), download.SHASum+"  "+download.Filename) {
+		t.Fatalf("Unexpected shasums document: %s", body)
+	}
+}
+
+func TestModuleRegistry(t *testing.T) {
+	m := NewMockAPI(t)
+
+	m.ModuleVersions("hashicorp", "consul", "aws", "0.1.0", "0.2.0").Once()
+	m.ModuleDownload("hashicorp", "consul", "aws", "0.2.0", "git::https://example.com/consul.git?ref=v0.2.0").Once()
+
+	var versions struct {
+		Modules []struct {
+			Versions []struct {
+				Version string `json:"version"`
+			} `json:"versions"`
+		} `json:"modules"`
+	}
+	getJSON(t, http.DefaultClient, m.URL()+ModulesPath+"hashicorp/consul/aws/versions", &versions)
+	if len(versions.Modules) != 1 || len(versions.Modules[0].Versions) != 2 {
+		t.Fatalf("Unexpected versions: %+v", versions)
+	}
+
+	resp, err := http.Get(m.URL() + ModulesPath + "hashicorp/consul/aws/0.2.0/download")
+	if err != nil {
+		t.Fatalf("Error requesting module download: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("X-Terraform-Get") != "git::https://example.com/consul.git?ref=v0.2.0" {
+		t.Fatalf("Unexpected download response: %d %v", resp.StatusCode, resp.Header)
+	}
+}
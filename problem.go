@@ -0,0 +1,32 @@
+package mockapi
+
+import (
+	"net/http"
+)
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// WithProblemReply will setup an expectation for an API call to be made. The reply is an
+// RFC 7807 problem details object with a Content-Type of application/problem+json. The
+// status member will be the supplied status code and the instance member will be the
+// request URI. When problemType is empty, the type member is omitted which per the RFC
+// is equivalent to "about:blank".
+func (m *MockAPI) WithProblemReply(req *MockRequest, status int, problemType, title, detail string) *MockAPICall {
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		problem := Problem{
+			Type:     problemType,
+			Title:    title,
+			Status:   status,
+			Detail:   detail,
+			Instance: r.URL.RequestURI(),
+		}
+		checkError(m.t, writeJSON(w, status, "application/problem+json", &problem))
+	})
+}
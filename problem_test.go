@@ -0,0 +1,49 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestWithProblemReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithProblemReply(NewMockRequest("GET", "/account"), 403,
+		"https://example.com/probs/out-of-credit", "You do not have enough credit.",
+		"Your current balance is 30, but that costs 50.").Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/account", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /account: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 403 {
+		t.Fatalf("Unexpected status code %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("Unexpected Content-Type %q", ct)
+	}
+
+	var problem Problem
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+
+	expected := Problem{
+		Type:     "https://example.com/probs/out-of-credit",
+		Title:    "You do not have enough credit.",
+		Status:   403,
+		Detail:   "Your current balance is 30, but that costs 50.",
+		Instance: "/account",
+	}
+	if problem != expected {
+		t.Fatalf("Unexpected problem: %+v", problem)
+	}
+}
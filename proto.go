@@ -0,0 +1,59 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// ProtobufContentType is the Content-Type of protobuf encoded bodies.
+const ProtobufContentType = "application/x-protobuf"
+
+// WithProtoBody will expect the request body to be the binary protobuf encoding of a message
+// equal to msg. The received body is decoded into a message of the same type, so differences in
+// field order or encoding do not matter. When the body does not match, the failure includes a
+// diff of the decoded messages. It may not be combined with any other body expectation.
+func (r *MockRequest) WithProtoBody(msg proto.Message) *MockRequest {
+	r.setBodyOption("WithProtoBody")
+	if msg == nil {
+		r.errs = append(r.errs, fmt.Errorf("WithProtoBody requires a non-nil message"))
+		return r
+	}
+
+	decode := func(body []byte) (proto.Message, error) {
+		got := msg.ProtoReflect().New().Interface()
+		return got, proto.Unmarshal(body, got)
+	}
+
+	r.body = mock.Anything
+	r.matchers = append(r.matchers, func(rr *receivedRequest) bool {
+		got, err := decode(rr.bodyBytes)
+		return err == nil && proto.Equal(got, msg)
+	})
+	r.bodyDiff = func(body []byte) string {
+		got, err := decode(body)
+		if err != nil {
+			return fmt.Sprintf("failed to decode the body as %s: %v", msg.ProtoReflect().Descriptor().FullName(), err)
+		}
+		return cmp.Diff(msg, got, protocmp.Transform())
+	}
+	return r
+}
+
+// WithProtoReply will setup an expectation for an API call to be made. The supplied status code
+// will be used for the responses reply and the binary protobuf encoding of msg will be written
+// to the response with a Content-Type of application/x-protobuf.
+func (m *MockAPI) WithProtoReply(req *MockRequest, status int, msg proto.Message) *MockAPICall {
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		data, err := proto.Marshal(msg)
+		checkError(m.t, err)
+
+		w.Header().Set("Content-Type", ProtobufContentType)
+		w.WriteHeader(status)
+		w.Write(data)
+	})
+}
@@ -0,0 +1,81 @@
+package mockapi
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestProto(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	expected, _ := structpb.NewStruct(map[string]interface{}{"name": "abc", "count": 2})
+	reply, _ := structpb.NewStruct(map[string]interface{}{"created": true})
+	m.WithProtoReply(NewMockRequest("POST", "/resource").WithProtoBody(expected), 201, reply).Once()
+
+	data, err := proto.Marshal(expected)
+	if err != nil {
+		t.Fatalf("Error encoding request: %v", err)
+	}
+	resp, err := http.Post(fmt.Sprintf("%s/resource", m.URL()), ProtobufContentType, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /resource: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 201 || resp.Header.Get("Content-Type") != ProtobufContentType {
+		t.Fatalf("Unexpected response: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Error reading response body: %v", err)
+	}
+	var got structpb.Struct
+	if err := proto.Unmarshal(body, &got); err != nil || !proto.Equal(&got, reply) {
+		t.Fatalf("Didn't get the expected response: %v %v", &got, err)
+	}
+}
+
+func TestProtoBodyMismatch(t *testing.T) {
+	rt := &recordingT{}
+	m := NewMockAPI(rt)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	expected, _ := structpb.NewStruct(map[string]interface{}{"name": "abc"})
+	m.WithNoResponseBody(NewMockRequest("POST", "/resource").WithProtoBody(expected), 201).Maybe()
+
+	actual, _ := structpb.NewStruct(map[string]interface{}{"name": "xyz"})
+	data, err := proto.Marshal(actual)
+	if err != nil {
+		t.Fatalf("Error encoding request: %v", err)
+	}
+	resp, err := http.Post(fmt.Sprintf("%s/resource", m.URL()), ProtobufContentType, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /resource: %v", err)
+	}
+	resp.Body.Close()
+	m.Close()
+
+	errs := rt.Errors()
+	if len(errs) != 1 || !strings.Contains(errs[0], "body mismatch") || !strings.Contains(errs[0], `"xyz"`) {
+		t.Fatalf("Expected a decoded body diff but got: %v", errs)
+	}
+}
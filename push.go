@@ -0,0 +1,30 @@
+package mockapi
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Push causes the given resources to be pushed to the client via HTTP/2 server push
+// before the response is sent. The server handles pushed resources as if they had been
+// requested by the client and so expectations must be setup for them as well. When the
+// connection does not support pushing, such as with HTTP/1.x or when the client has
+// disabled it, the resources are silently not pushed. Any other failure to push a
+// resource will fail the test.
+func (m *MockAPICall) Push(targets ...string) *MockAPICall {
+	api := m.api
+	m.wrap(func(next MockResponse) MockResponse {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if pusher, ok := w.(http.Pusher); ok {
+				for _, target := range targets {
+					err := pusher.Push(target, nil)
+					if err != nil && !errors.Is(err, http.ErrNotSupported) {
+						api.errorf("mockapi: failed to push %s: %v", target, err)
+					}
+				}
+			}
+			next(w, r)
+		}
+	})
+	return m
+}
@@ -0,0 +1,28 @@
+package mockapi
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPushWithoutClientSupport(t *testing.T) {
+	m := NewMockAPI(t, WithHTTP2())
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	// the Go HTTP client disables server push so no request for the pushed
+	// resource should be made
+	m.WithTextReply(NewMockRequest("GET", "/index.html"), 200, "<html></html>").Once().Push("/style.css")
+
+	resp, err := m.Client().Get(fmt.Sprintf("%s/index.html", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /index.html: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("Expected HTTP/2 to be used but got %s", resp.Proto)
+	}
+}
@@ -0,0 +1,71 @@
+package mockapi
+
+import (
+	"net/url"
+	"reflect"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// WithQueryParamSubset will expect the request to have at least these query params with these
+// values. Unlike WithQueryParams, any other query params of the request are ignored, including
+// those which would be expected by WithQueryParams or WithQueryValues as long as they are present.
+func (r *MockRequest) WithQueryParamSubset(params map[string]string) *MockRequest {
+	r.querySubset = true
+	return r.WithQueryParams(params)
+}
+
+// WithQueryParamPresent will expect the request to have the named query params with any
+// values, such as the index and wait params of a blocking query. These params are then not
+// compared with WithQueryParams.
+func (r *MockRequest) WithQueryParamPresent(names ...string) *MockRequest {
+	if r.queryPresent == nil {
+		r.queryPresent = make(map[string]struct{})
+	}
+	for _, name := range names {
+		r.queryPresent[name] = struct{}{}
+	}
+	return r
+}
+
+// queryArgument returns the argument to use for matching the query params of the request.
+func (r *MockRequest) queryArgument() interface{} {
+	if len(r.queryPresent) == 0 && !r.querySubset {
+		return r.queryParams
+	}
+
+	expected := r.queryParams
+	present := r.queryPresent
+	subset := r.querySubset
+	return mock.MatchedBy(func(params url.Values) bool {
+		for name := range present {
+			if _, ok := params[name]; !ok {
+				return false
+			}
+		}
+
+		if subset {
+			for name, values := range expected {
+				if !reflect.DeepEqual(params[name], values) {
+					return false
+				}
+			}
+			return true
+		}
+
+		var rest url.Values
+		for name, values := range params {
+			if _, ok := present[name]; ok {
+				continue
+			}
+			if rest == nil {
+				rest = make(url.Values)
+			}
+			rest[name] = values
+		}
+		if len(rest) == 0 && len(expected) == 0 {
+			return true
+		}
+		return reflect.DeepEqual(rest, expected)
+	})
+}
@@ -0,0 +1,96 @@
+package mockapi
+
+import (
+	"sync"
+	"time"
+)
+
+// RecordedCall is a request which matched an expectation of one of the MockAPIs
+// reporting into a CallRecorder.
+type RecordedCall struct {
+	// ID is the correlation ID of the request. See RequestID.
+	ID   string
+	Time time.Time
+	// API is the name the MockAPI was given when it was configured with
+	// WithCallRecorder.
+	API    string
+	Method string
+	Path   string
+	// Call is the expectation the request matched.
+	Call *MockAPICall
+}
+
+// CallRecorder records the matched requests of several MockAPIs in the order
+// they occurred. This allows asserting on the interleaving of requests made to
+// different mocked backends.
+type CallRecorder struct {
+	mu    sync.Mutex
+	calls []RecordedCall
+}
+
+// NewCallRecorder creates an empty CallRecorder.
+func NewCallRecorder() *CallRecorder {
+	return &CallRecorder{}
+}
+
+// WithCallRecorder configures the MockAPI to report every request which matches one
+// of its expectations to the CallRecorder under the given name.
+func WithCallRecorder(recorder *CallRecorder, name string) Option {
+	return func(m *MockAPI) error {
+		m.recorder = recorder
+		m.recorderName = name
+		return nil
+	}
+}
+
+func (c *CallRecorder) record(call RecordedCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, call)
+}
+
+// Calls returns all the recorded calls in the order they occurred.
+func (c *CallRecorder) Calls() []RecordedCall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	calls := make([]RecordedCall, len(c.calls))
+	copy(calls, c.calls)
+	return calls
+}
+
+// AssertOrder asserts that the first occurrences of the calls happened in the order
+// given, regardless of which MockAPI they were made to. Calls which never occurred
+// are also reported as failures. It returns whether the assertion passed.
+func (c *CallRecorder) AssertOrder(t TestingT, calls ...*MockAPICall) bool {
+	recorded := c.Calls()
+
+	first := func(call *MockAPICall) (int, bool) {
+		for i, rc := range recorded {
+			if rc.Call == call {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+
+	ok := true
+	last := -1
+	var lastCall *MockAPICall
+	for _, call := range calls {
+		idx, found := first(call)
+		if !found {
+			t.Errorf("mockapi: %s was expected to occur but never did", call)
+			ok = false
+			continue
+		}
+
+		if idx < last {
+			t.Errorf("mockapi: %s was expected to occur after %s but occurred before it", call, lastCall)
+			ok = false
+		}
+		last = idx
+		lastCall = call
+	}
+	return ok
+}
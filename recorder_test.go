@@ -0,0 +1,43 @@
+package mockapi
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCallRecorder(t *testing.T) {
+	recorder := NewCallRecorder()
+	auth := NewMockAPI(t, WithCallRecorder(recorder, "auth"))
+	api := NewMockAPI(t, WithCallRecorder(recorder, "api"))
+	for _, m := range []*MockAPI{auth, api} {
+		m.SetFilteredHeaders([]string{
+			"Accept-Encoding",
+			"User-Agent",
+		})
+	}
+
+	token := auth.WithNoResponseBody(NewMockRequest("GET", "/token"), 200).Once()
+	resource := api.WithNoResponseBody(NewMockRequest("GET", "/resource"), 200).Once()
+
+	for _, url := range []string{auth.URL() + "/token", api.URL() + "/resource"} {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", url, err)
+		}
+		resp.Body.Close()
+	}
+
+	calls := recorder.Calls()
+	if len(calls) != 2 || calls[0].API != "auth" || calls[1].API != "api" || calls[1].Path != "/resource" {
+		t.Fatalf("Unexpected recorded calls: %+v", calls)
+	}
+
+	if !recorder.AssertOrder(t, token, resource) {
+		t.Fatalf("Expected the token to be requested before the resource")
+	}
+
+	rt := &recordingT{}
+	if recorder.AssertOrder(rt, resource, token) || len(rt.errors) != 1 {
+		t.Fatalf("Expected exactly one ordering failure but got: %v", rt.errors)
+	}
+}
@@ -0,0 +1,43 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// WithRedirectReply will setup an expectation for an API call to be made. The reply will have
+// the supplied status code (typically one of 301, 302, 303, 307 or 308) and a Location header
+// holding location. Relative locations are resolved by clients against the URL of the request.
+func (m *MockAPI) WithRedirectReply(req *MockRequest, status int, location string) *MockAPICall {
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", location)
+		w.WriteHeader(status)
+	})
+}
+
+// WithRedirectChain will setup expectations for a chain of redirects. Every request but the
+// last is replied to with the supplied status code and a Location header holding the path and
+// query params of the request following it. No expectation is setup for the last request so
+// that the final reply can be expected with any of the other helpers. The calls returned hold
+// one expectation per hop in the order of the chain.
+//
+// Only requests with a literal path may be used after the first as the Location of a hop must
+// be known up front. Note that clients will change the method of the following request to GET
+// for some status codes, such as 303, so the requests must reflect what the client will send.
+func (m *MockAPI) WithRedirectChain(status int, reqs ...*MockRequest) []*MockAPICall {
+	var calls []*MockAPICall
+	for i := 0; i < len(reqs)-1; i++ {
+		next := reqs[i+1]
+		if next.pathPattern != nil {
+			checkError(m.t, fmt.Errorf("redirect chain hop %s %s does not have a literal path", next.method, next.path))
+			continue
+		}
+
+		location := next.path
+		if len(next.queryParams) > 0 {
+			location += "?" + next.queryParams.Encode()
+		}
+		calls = append(calls, m.WithRedirectReply(reqs[i], status, location))
+	}
+	return calls
+}
@@ -0,0 +1,83 @@
+package mockapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestWithRedirectChain(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Referer",
+	})
+
+	calls := m.WithRedirectChain(302,
+		NewMockRequest("GET", "/start"),
+		NewMockRequest("GET", "/middle").WithQueryParams(map[string]string{"hop": "1"}),
+		NewMockRequest("GET", "/end"),
+	)
+	if len(calls) != 2 {
+		t.Fatalf("Expected an expectation for each redirecting hop but got %d", len(calls))
+	}
+	for _, call := range calls {
+		call.Twice()
+	}
+	m.WithTextReply(NewMockRequest("GET", "/end"), 200, "done").Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/start", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /start: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 || resp.Request.URL.Path != "/end" {
+		t.Fatalf("Expected the redirects to be followed to /end but got %d from %s", resp.StatusCode, resp.Request.URL)
+	}
+
+	// a client limited to a single redirect should stop at the second hop
+	errTooMany := errors.New("too many redirects")
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > 1 {
+				return errTooMany
+			}
+			return nil
+		},
+	}
+	_, err = client.Get(fmt.Sprintf("%s/start", m.URL()))
+	if !errors.Is(err, errTooMany) {
+		t.Fatalf("Expected the redirect policy to stop the chain but got: %v", err)
+	}
+}
+
+func TestWithRedirectReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithRedirectReply(NewMockRequest("GET", "/old"), 301, "https://example.com/new").Once()
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(fmt.Sprintf("%s/old", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /old: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 301 {
+		t.Fatalf("Unexpected status code %d", resp.StatusCode)
+	}
+	if location := resp.Header.Get("Location"); location != "https://example.com/new" {
+		t.Fatalf("Unexpected Location %q", location)
+	}
+}
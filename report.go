@@ -0,0 +1,139 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// FailureKind classifies a FailureEvent.
+type FailureKind string
+
+const (
+	// FailureUnexpectedRequest is a request which did not match any expectation.
+	FailureUnexpectedRequest FailureKind = "unexpected_request"
+	// FailureUnmetExpectation is an expectation which did not occur the
+	// expected number of times.
+	FailureUnmetExpectation FailureKind = "unmet_expectation"
+	// FailureAssertion is any other failed assertion.
+	FailureAssertion FailureKind = "assertion"
+)
+
+// FailureEvent is a structured report of a test failure caused by the MockAPI.
+type FailureEvent struct {
+	Time time.Time   `json:"time"`
+	Kind FailureKind `json:"kind"`
+	// RequestID is the correlation ID of the request which failed. See RequestID.
+	RequestID string `json:"request_id,omitempty"`
+	// Method and Path identify the request or expectation which failed
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// Expected and Actual are the number of times an unmet expectation was
+	// expected to and actually occurred. An expected count of zero means at
+	// least once.
+	Expected int    `json:"expected,omitempty"`
+	Actual   int    `json:"actual,omitempty"`
+	Message  string `json:"message"`
+}
+
+// WithFailureReport configures the MockAPI to also write all of its failures to w as
+// JSON encoded FailureEvents, one per line. This allows CI tooling to aggregate which
+// expectations fail most often.
+func WithFailureReport(w io.Writer) Option {
+	return func(m *MockAPI) error {
+		m.report = &failureReport{enc: json.NewEncoder(w)}
+		return nil
+	}
+}
+
+// WithFailureReportFile is like WithFailureReport but appends the failures to the file
+// at path, creating it if necessary. The file is closed when the MockAPI is closed.
+func WithFailureReportFile(path string) Option {
+	return func(m *MockAPI) error {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open failure report file: %w", err)
+		}
+		m.report = &failureReport{enc: json.NewEncoder(f), closer: f}
+		return nil
+	}
+}
+
+type failureReport struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+// emit writes out the event. It is safe to call on a nil report.
+func (r *failureReport) emit(event FailureEvent) {
+	if r == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enc.Encode(&event)
+}
+
+// close closes the underlying file if there is one. It is safe to call on a
+// nil report.
+func (r *failureReport) close() {
+	if r == nil || r.closer == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closer.Close()
+}
+
+// reportUnmet reports every expectation which has not occurred the expected
+// number of times.
+func (m *MockAPI) reportUnmet() {
+	m.mu.Lock()
+	calls := make([]*MockAPICall, len(m.calls))
+	copy(calls, m.calls)
+	m.mu.Unlock()
+
+	for _, call := range calls {
+		call.mu.Lock()
+		expected, actual := call.expected, len(call.times)
+		unmet := !call.optional && !call.disabled &&
+			((expected > 0 && actual < expected) || (expected <= 0 && actual == 0))
+		call.mu.Unlock()
+
+		if !unmet {
+			continue
+		}
+
+		event := FailureEvent{
+			Kind:     FailureUnmetExpectation,
+			Expected: expected,
+			Actual:   actual,
+			Message:  fmt.Sprintf("%s was expected to occur but only occurred %d time(s)", call, actual),
+		}
+		if call.req != nil {
+			event.Method = call.req.method
+			event.Path = call.req.path
+		}
+		m.report.emit(event)
+	}
+}
+
+// reportingT is a TestingT which also reports failures to the failure report.
+type reportingT struct {
+	TestingT
+	report *failureReport
+}
+
+func (t *reportingT) Errorf(format string, args ...interface{}) {
+	t.report.emit(FailureEvent{Kind: FailureAssertion, Message: fmt.Sprintf(format, args...)})
+	t.TestingT.Errorf(format, args...)
+}
@@ -0,0 +1,85 @@
+package mockapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestWithFailureReport(t *testing.T) {
+	var buf bytes.Buffer
+	rt := &recordingT{}
+	m := NewMockAPI(rt, WithFailureReport(&buf))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/called"), 200).Once()
+	m.WithNoResponseBody(NewMockRequest("GET", "/missing"), 200).Twice()
+	m.WithNoResponseBody(NewMockRequest("GET", "/optional"), 200).Maybe()
+
+	for _, path := range []string{"/called", "/unexpected"} {
+		resp, err := http.Get(fmt.Sprintf("%s%s", m.URL(), path))
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", path, err)
+		}
+		resp.Body.Close()
+	}
+
+	m.Close()
+
+	var unexpected, unmet []FailureEvent
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var event FailureEvent
+		if err := dec.Decode(&event); err != nil {
+			t.Fatalf("Error decoding failure event: %v", err)
+		}
+		switch event.Kind {
+		case FailureUnexpectedRequest:
+			unexpected = append(unexpected, event)
+		case FailureUnmetExpectation:
+			unmet = append(unmet, event)
+		}
+	}
+
+	if len(unexpected) != 1 || unexpected[0].Method != "GET" || unexpected[0].Path != "/unexpected" {
+		t.Fatalf("Expected one unexpected request event for /unexpected but got: %+v", unexpected)
+	}
+	if len(unmet) != 1 || unmet[0].Path != "/missing" || unmet[0].Expected != 2 || unmet[0].Actual != 0 {
+		t.Fatalf("Expected one unmet expectation event for /missing but got: %+v", unmet)
+	}
+	if len(rt.Errors()) == 0 {
+		t.Fatalf("Expected the failures to also be reported to the TestingT")
+	}
+}
+
+func TestWithFailureReportUnmetOnce(t *testing.T) {
+	var buf bytes.Buffer
+	rt := &recordingT{}
+	m := NewMockAPI(rt, WithFailureReport(&buf))
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/missing"), 200).Once()
+	m.Close()
+
+	// the unmet expectation must only be counted once by aggregation tools
+	var events []FailureEvent
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var event FailureEvent
+		if err := dec.Decode(&event); err != nil {
+			t.Fatalf("Error decoding failure event: %v", err)
+		}
+		events = append(events, event)
+	}
+
+	if len(events) != 1 || events[0].Kind != FailureUnmetExpectation || events[0].Path != "/missing" {
+		t.Fatalf("Expected exactly one unmet expectation event for /missing but got: %+v", events)
+	}
+	if len(rt.Errors()) == 0 {
+		t.Fatalf("Expected the failure to also be reported to the TestingT")
+	}
+}
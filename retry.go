@@ -0,0 +1,151 @@
+package mockapi
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// retryAfter is a record of a Retry-After header sent to a client.
+type retryAfter struct {
+	at    time.Time
+	delay time.Duration
+	// method and path identify the request the client is expected to retry
+	method string
+	path   string
+	// retried is when the request was first retried
+	retried time.Time
+}
+
+// WithRetryAfterReply will setup an expectation for an API call to be made. Each matching
+// request is replied to with the supplied status code (typically 429 or 503) and a
+// Retry-After header holding the next of the delays. Delays are sent as whole seconds,
+// rounded up. The call is expected to occur once per delay; if it is allowed to occur more
+// often then the last delay is repeated.
+//
+// When AssertExpectations is called or the MockAPI is closed, every retry of the same
+// method and path (whichever expectation it matched) is asserted to have been made no
+// sooner than the delay the client was asked to wait.
+func (m *MockAPI) WithRetryAfterReply(req *MockRequest, status int, delays ...time.Duration) *MockAPICall {
+	var mu sync.Mutex
+	attempt := 0
+
+	var call *MockAPICall
+	call = m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		if len(delays) == 0 {
+			w.WriteHeader(status)
+			return
+		}
+
+		mu.Lock()
+		delay := delays[len(delays)-1]
+		if attempt < len(delays) {
+			delay = delays[attempt]
+		}
+		attempt++
+		mu.Unlock()
+
+		call.setRetryAfter(w, r, delay)
+		w.WriteHeader(status)
+	})
+
+	if len(delays) > 0 {
+		call.Times(len(delays))
+	}
+	return call
+}
+
+// WithRateLimitedReply will setup an expectation for an API call to be made. The first n
+// matching requests are replied to with a 429 status code, a Retry-After header holding
+// retryAfter rounded up to whole seconds, an X-RateLimit-Remaining header of 0 and an
+// X-RateLimit-Reset header holding the Unix time at which the client may retry. Later
+// requests are replied to with thenStatus and the JSON encoding of thenBody, if it is not
+// nil. The call is expected to occur n+1 times.
+//
+// As with WithRetryAfterReply, requests retried sooner than the client was told to wait
+// will fail the assertions made when AssertExpectations is called or the MockAPI is closed.
+func (m *MockAPI) WithRateLimitedReply(req *MockRequest, n int, retryAfter time.Duration, thenStatus int, thenBody interface{}) *MockAPICall {
+	var mu sync.Mutex
+	limited := 0
+
+	var call *MockAPICall
+	call = m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		limit := limited < n
+		if limit {
+			limited++
+		}
+		mu.Unlock()
+
+		if limit {
+			reset := call.setRetryAfter(w, r, retryAfter)
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		if thenBody == nil {
+			w.WriteHeader(thenStatus)
+			return
+		}
+		checkError(m.t, writeJSON(w, thenStatus, "application/json", thenBody))
+	})
+
+	return call.Times(n + 1)
+}
+
+// setRetryAfter sets the Retry-After header of the response to the delay rounded up to
+// whole seconds and records it so that assertRetries can check the client waited at
+// least that long. The time from which the client may retry is returned.
+func (m *MockAPICall) setRetryAfter(w http.ResponseWriter, r *http.Request, delay time.Duration) time.Time {
+	seconds := int(math.Ceil(delay.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+
+	now := time.Now()
+	m.api.mu.Lock()
+	m.api.retries = append(m.api.retries, &retryAfter{
+		at:     now,
+		delay:  time.Duration(seconds) * time.Second,
+		method: r.Method,
+		path:   r.URL.Path,
+	})
+	m.api.mu.Unlock()
+
+	return now.Add(time.Duration(seconds) * time.Second)
+}
+
+// observeRetries records the request received at the given time as the retry of any
+// earlier request to which a Retry-After header was sent.
+func (m *MockAPI) observeRetries(method, path string, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, retry := range m.retries {
+		if retry.retried.IsZero() && retry.method == method && retry.path == path && at.After(retry.at) {
+			retry.retried = at
+		}
+	}
+}
+
+// assertRetries checks that clients waited as long as they were told to by
+// Retry-After headers before retrying.
+func (m *MockAPI) assertRetries(t TestingT) {
+	m.mu.Lock()
+	retries := make([]retryAfter, 0, len(m.retries))
+	for _, retry := range m.retries {
+		retries = append(retries, *retry)
+	}
+	m.mu.Unlock()
+
+	for _, retry := range retries {
+		if retry.retried.IsZero() {
+			continue
+		}
+		if waited := retry.retried.Sub(retry.at); waited < retry.delay {
+			t.Errorf("mockapi: %s %s was retried after %v but the client was told to wait %v", retry.method, retry.path, waited, retry.delay)
+		}
+	}
+}
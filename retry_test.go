@@ -0,0 +1,90 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithRetryAfterReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithRetryAfterReply(NewMockRequest("GET", "/limited"), 429, time.Second, 500*time.Millisecond)
+	m.WithNoResponseBody(NewMockRequest("GET", "/limited"), 200).Once()
+
+	var retryAfter []string
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(fmt.Sprintf("%s/limited", m.URL()))
+		if err != nil {
+			t.Fatalf("Error issuing GET of /limited: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode == 200 {
+			break
+		}
+		retryAfter = append(retryAfter, resp.Header.Get("Retry-After"))
+		// only honor the first delay so that the second retry is too early
+		if i == 0 {
+			time.Sleep(time.Second)
+		}
+	}
+
+	if len(retryAfter) != 2 || retryAfter[0] != "1" || retryAfter[1] != "1" {
+		t.Fatalf("Unexpected Retry-After values: %v", retryAfter)
+	}
+
+	rt := &recordingT{}
+	m.AssertExpectations(rt)
+	if len(rt.errors) != 1 {
+		t.Fatalf("Expected exactly one retry failure but got: %v", rt.errors)
+	}
+
+	// forget the early retry so that the automatic cleanup assertions pass; the journal is
+	// not kept and so is not needed to detect it
+	m.mu.Lock()
+	m.retries = nil
+	m.mu.Unlock()
+}
+
+func TestWithRateLimitedReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithRateLimitedReply(NewMockRequest("GET", "/limited"), 2, 0, 200, map[string]string{"status": "ok"})
+
+	var statuses []int
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(fmt.Sprintf("%s/limited", m.URL()))
+		if err != nil {
+			t.Fatalf("Error issuing GET of /limited: %v", err)
+		}
+		resp.Body.Close()
+		statuses = append(statuses, resp.StatusCode)
+
+		if resp.StatusCode != 429 {
+			continue
+		}
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "0" {
+			t.Fatalf("Unexpected Retry-After %q", retryAfter)
+		}
+		if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "0" {
+			t.Fatalf("Unexpected X-RateLimit-Remaining %q", remaining)
+		}
+		if resp.Header.Get("X-RateLimit-Reset") == "" {
+			t.Fatalf("Expected an X-RateLimit-Reset header")
+		}
+	}
+
+	if statuses[0] != 429 || statuses[1] != 429 || statuses[2] != 200 {
+		t.Fatalf("Unexpected status codes: %v", statuses)
+	}
+}
@@ -0,0 +1,147 @@
+package mockapi
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ScenarioStarted is the state every scenario is in when it is created or reset.
+const ScenarioStarted = "Started"
+
+// Scenario is a named state machine which expectations can depend on. An expectation
+// in a scenario may be limited to only match requests while the scenario is in a given
+// state and may move the scenario to a new state when it matches. This allows mocking
+// APIs whose responses change over the lifecycle of a resource:
+//
+//	m.WithJSONReply(getReq, 200, pending).InScenario("provisioning").WhenState(mockapi.ScenarioStarted)
+//	m.WithJSONReply(postReq, 202, nil).InScenario("provisioning").ThenState("ready")
+//	m.WithJSONReply(getReq, 200, ready).InScenario("provisioning").WhenState("ready")
+//
+// As with groups, the state does not change whether expectations are required to be
+// met so expectations for states which may never be reached should be marked with Maybe.
+type Scenario struct {
+	name string
+
+	mu    sync.Mutex
+	state string
+}
+
+// Scenario returns the scenario with the given name, creating it in the
+// ScenarioStarted state if necessary.
+func (m *MockAPI) Scenario(name string) *Scenario {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.scenarios[name]; ok {
+		return s
+	}
+	if m.scenarios == nil {
+		m.scenarios = make(map[string]*Scenario)
+	}
+	s := &Scenario{name: name, state: ScenarioStarted}
+	m.scenarios[name] = s
+	return s
+}
+
+// Name returns the name of the scenario.
+func (s *Scenario) Name() string {
+	return s.name
+}
+
+// State returns the current state of the scenario.
+func (s *Scenario) State() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// SetState moves the scenario to the given state.
+func (s *Scenario) SetState(state string) *Scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	return s
+}
+
+// Reset moves the scenario back to the ScenarioStarted state.
+func (s *Scenario) Reset() *Scenario {
+	return s.SetState(ScenarioStarted)
+}
+
+// scenarioStep is how a call takes part in a scenario.
+type scenarioStep struct {
+	scenario *Scenario
+	// when is the state the scenario must be in for the call to match with
+	// an empty string meaning any state.
+	when string
+	// then is the state the scenario moves to when the call matches with an
+	// empty string meaning the state is left alone.
+	then string
+}
+
+// InScenario adds the call to the named scenario. WhenState and ThenState may then be
+// used to make the call depend on and change the state of the scenario.
+func (m *MockAPICall) InScenario(name string) *MockAPICall {
+	s := m.api.Scenario(name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.scenario == nil {
+		m.scenario = &scenarioStep{}
+	}
+	m.scenario.scenario = s
+	return m
+}
+
+// WhenState limits the call to only match requests while its scenario is in the given
+// state. The call must have been added to a scenario with InScenario.
+func (m *MockAPICall) WhenState(state string) *MockAPICall {
+	m.mu.Lock()
+	step := m.scenario
+	if step != nil {
+		step.when = state
+	}
+	m.mu.Unlock()
+
+	if step == nil {
+		checkError(m.api.t, fmt.Errorf("WhenState(%q) for %s requires InScenario to be called first", state, m))
+	}
+	return m
+}
+
+// ThenState moves the scenario of the call to the given state whenever the call matches
+// a request. The call must have been added to a scenario with InScenario.
+func (m *MockAPICall) ThenState(state string) *MockAPICall {
+	m.mu.Lock()
+	step := m.scenario
+	if step != nil {
+		step.then = state
+	}
+	m.mu.Unlock()
+
+	if step == nil {
+		checkError(m.api.t, fmt.Errorf("ThenState(%q) for %s requires InScenario to be called first", state, m))
+	}
+	return m
+}
+
+// inState returns whether the scenario of the call, if any, is in the state the call
+// requires.
+func (m *MockAPICall) inState() bool {
+	m.mu.Lock()
+	step := m.scenario
+	m.mu.Unlock()
+
+	return step == nil || step.when == "" || step.scenario.State() == step.when
+}
+
+// transition moves the scenario of the call, if any, to its next state.
+func (m *MockAPICall) transition() {
+	m.mu.Lock()
+	step := m.scenario
+	m.mu.Unlock()
+
+	if step != nil && step.then != "" {
+		step.scenario.SetState(step.then)
+	}
+}
@@ -0,0 +1,46 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestScenario(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+	})
+
+	get := NewMockRequest("GET", "/cluster")
+	m.WithJSONReply(get, 200, map[string]string{"status": "pending"}).
+		InScenario("provisioning").WhenState(ScenarioStarted).Once()
+	m.WithJSONReply(get, 200, map[string]string{"status": "provisioning"}).
+		InScenario("provisioning").WhenState(ScenarioStarted).ThenState("ready").Once()
+	m.WithJSONReply(get, 200, map[string]string{"status": "ready"}).
+		InScenario("provisioning").WhenState("ready").Twice()
+
+	for _, expected := range []string{"pending", "provisioning", "ready", "ready"} {
+		resp, err := http.Get(fmt.Sprintf("%s/cluster", m.URL()))
+		if err != nil {
+			t.Fatalf("Error issuing GET of /cluster: %v", err)
+		}
+
+		var output map[string]string
+		err = json.NewDecoder(resp.Body).Decode(&output)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Error decoding response: %v", err)
+		}
+		if output["status"] != expected {
+			t.Fatalf("Expected status %q but got %q", expected, output["status"])
+		}
+	}
+
+	if state := m.Scenario("provisioning").State(); state != "ready" {
+		t.Fatalf("Expected the scenario to be ready but it is %q", state)
+	}
+}
@@ -0,0 +1,95 @@
+package mockapi
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Scratchpad is a concurrency safe key-value store which can be used to correlate
+// values across requests. For example a value captured from one request can be
+// required to be present in a later request or echoed back in a later reply.
+type Scratchpad struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+// Set stores the value under the given key, replacing any existing value.
+func (s *Scratchpad) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values == nil {
+		s.values = make(map[string]interface{})
+	}
+	s.values[key] = value
+}
+
+// Lookup returns the value stored under the given key and whether there was one.
+func (s *Scratchpad) Lookup(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Get returns the value stored under the given key or nil if there is none.
+func (s *Scratchpad) Get(key string) interface{} {
+	value, _ := s.Lookup(key)
+	return value
+}
+
+// GetString returns the string value stored under the given key. An empty string
+// is returned if there is no value or the value is not a string.
+func (s *Scratchpad) GetString(key string) string {
+	value, _ := s.Get(key).(string)
+	return value
+}
+
+// Delete removes the value stored under the given key.
+func (s *Scratchpad) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+// snapshot returns a copy of all the stored values.
+func (s *Scratchpad) snapshot() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values := make(map[string]interface{}, len(s.values))
+	for key, value := range s.values {
+		values[key] = value
+	}
+	return values
+}
+
+// Keys returns the sorted keys of all the stored values.
+func (s *Scratchpad) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Scratchpad returns the Scratchpad shared by all the expectations of this MockAPI.
+func (m *MockAPI) Scratchpad() *Scratchpad {
+	return &m.scratchpad
+}
+
+// Capture registers a hook which is invoked with the request and the MockAPI's Scratchpad
+// whenever a request matches this call. The hook is run before the response is sent and
+// so values it stores are available to the responder as well as to any later expectations.
+func (m *MockAPICall) Capture(hook func(r *http.Request, pad *Scratchpad)) *MockAPICall {
+	pad := m.api.Scratchpad()
+	m.wrap(func(next MockResponse) MockResponse {
+		return func(w http.ResponseWriter, r *http.Request) {
+			hook(r, pad)
+			next(w, r)
+		}
+	})
+	return m
+}
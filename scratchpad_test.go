@@ -0,0 +1,59 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestScratchpadCorrelation(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"X-Upload-Id",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	pad := m.Scratchpad()
+
+	// the mock generates an upload ID which the client must send back later
+	m.WithRequest(NewMockRequest("POST", "/uploads"), func(w http.ResponseWriter, r *http.Request) {
+		pad.Set("upload-id", "upload-123")
+		w.Header().Set("X-Upload-Id", "upload-123")
+		w.WriteHeader(201)
+	}).Once()
+
+	m.WithNoResponseBody(NewMockRequest("PUT", "/uploads/complete").Matching(func(r *http.Request) bool {
+		return r.Header.Get("X-Upload-Id") == pad.GetString("upload-id")
+	}), 200).Once().Capture(func(r *http.Request, pad *Scratchpad) {
+		pad.Set("completed", true)
+	})
+
+	resp, err := http.Post(fmt.Sprintf("%s/uploads", m.URL()), "", nil)
+	if err != nil {
+		t.Fatalf("Error issuing POST of /uploads: %v", err)
+	}
+	resp.Body.Close()
+
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/uploads/complete", m.URL()), nil)
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+	req.Header.Set("X-Upload-Id", resp.Header.Get("X-Upload-Id"))
+
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error issuing PUT of /uploads/complete: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected a 200 status code but got %d", resp.StatusCode)
+	}
+
+	if completed, _ := pad.Get("completed").(bool); !completed {
+		t.Fatalf("Capture hook was not invoked")
+	}
+}
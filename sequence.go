@@ -0,0 +1,41 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// WithResponses will setup an expectation for an API call to be made where the Nth matching
+// request is replied to by the Nth of the responses. This is useful for mocking endpoints
+// which are polled while progressing through states. The call is expected to occur once
+// per response; if it is allowed to occur more often then the last response is repeated.
+// Responses can be built with helpers such as JSONResponse.
+func (m *MockAPI) WithResponses(req *MockRequest, responses ...MockResponse) *MockAPICall {
+	if len(responses) == 0 {
+		checkError(m.t, fmt.Errorf("no responses for expected request %s %s", req.method, req.path))
+		return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {})
+	}
+	for _, resp := range responses {
+		if resp == nil {
+			checkError(m.t, fmt.Errorf("nil responder for expected request %s %s", req.method, req.path))
+		}
+	}
+
+	var mu sync.Mutex
+	next := 0
+
+	call := m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		resp := responses[len(responses)-1]
+		if next < len(responses) {
+			resp = responses[next]
+		}
+		next++
+		mu.Unlock()
+
+		resp(w, r)
+	})
+
+	return call.Times(len(responses))
+}
@@ -0,0 +1,43 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestWithResponses(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	var responses []MockResponse
+	for _, state := range []string{"pending", "running", "done"} {
+		resp, err := JSONResponse(200, map[string]string{"state": state})
+		if err != nil {
+			t.Fatalf("Error creating response: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	m.WithResponses(NewMockRequest("GET", "/job"), responses...)
+
+	for _, expected := range []string{"pending", "running", "done"} {
+		resp, err := http.Get(fmt.Sprintf("%s/job", m.URL()))
+		if err != nil {
+			t.Fatalf("Error issuing GET of /job: %v", err)
+		}
+
+		var output map[string]string
+		err = json.NewDecoder(resp.Body).Decode(&output)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Error decoding response: %v", err)
+		}
+		if output["state"] != expected {
+			t.Fatalf("Expected state %q but got %q", expected, output["state"])
+		}
+	}
+}
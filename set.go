@@ -0,0 +1,171 @@
+package mockapi
+
+import "fmt"
+
+// ExpectationSet is a reusable, named collection of expectations which can be applied
+// to any number of MockAPIs. This allows common setup, such as a login followed by
+// fetching feature flags, to be defined once and shared between tests:
+//
+//	var loggedIn = mockapi.NewExpectationSet().
+//		AddJSON("login", mockapi.NewMockRequest("POST", "/login"), 200, token).
+//		AddJSON("flags", mockapi.NewMockRequest("GET", "/flags"), 200, defaultFlags)
+//
+//	calls := loggedIn.Apply(m, mockapi.OverrideJSON("flags", 200, betaFlags))
+//	calls["login"].Once()
+type ExpectationSet struct {
+	names   []string
+	entries map[string]setEntry
+}
+
+// ExpectationSetup registers a single expectation with the MockAPI.
+type ExpectationSetup func(m *MockAPI) *MockAPICall
+
+type setEntry struct {
+	// req is the expected request when known which allows overriding only
+	// the reply.
+	req   *MockRequest
+	setup ExpectationSetup
+}
+
+// NewExpectationSet creates an empty ExpectationSet.
+func NewExpectationSet() *ExpectationSet {
+	return &ExpectationSet{entries: make(map[string]setEntry)}
+}
+
+// Add adds a named expectation to the set. The setup function is called each time
+// the set is applied and may use any of the MockAPI methods to register the
+// expectation. Adding an expectation with the name of an existing one replaces it
+// while keeping its position in the set.
+func (s *ExpectationSet) Add(name string, setup ExpectationSetup) *ExpectationSet {
+	return s.add(name, setEntry{setup: setup})
+}
+
+// AddRequest adds a named expectation which replies to req using resp.
+func (s *ExpectationSet) AddRequest(name string, req *MockRequest, resp MockResponse) *ExpectationSet {
+	return s.add(name, setEntry{req: req, setup: func(m *MockAPI) *MockAPICall {
+		return m.WithRequest(req, resp)
+	}})
+}
+
+// AddJSON adds a named expectation which replies to req with the JSON encoded reply.
+func (s *ExpectationSet) AddJSON(name string, req *MockRequest, status int, reply interface{}) *ExpectationSet {
+	return s.add(name, setEntry{req: req, setup: func(m *MockAPI) *MockAPICall {
+		return m.WithJSONReply(req, status, reply)
+	}})
+}
+
+// Include adds all the expectations of other to the set.
+func (s *ExpectationSet) Include(other *ExpectationSet) *ExpectationSet {
+	for _, name := range other.names {
+		s.add(name, other.entries[name])
+	}
+	return s
+}
+
+func (s *ExpectationSet) add(name string, entry setEntry) *ExpectationSet {
+	if _, ok := s.entries[name]; !ok {
+		s.names = append(s.names, name)
+	}
+	s.entries[name] = entry
+	return s
+}
+
+// Apply registers all the expectations of the set with the MockAPI, in the order they
+// were added, after applying the overrides. The registered calls are returned keyed
+// by name so that they can be further configured. An override for an expectation
+// which is not part of the set will fail the test object passed into the NewMockAPI
+// constructor if that was non-nil and if it was nil, will panic.
+func (s *ExpectationSet) Apply(m *MockAPI, overrides ...SetOverride) map[string]*MockAPICall {
+	entries := make(map[string]setEntry, len(s.entries))
+	for name, entry := range s.entries {
+		entries[name] = entry
+	}
+
+	for _, override := range overrides {
+		entry, ok := entries[override.name]
+		if !ok {
+			checkError(m.t, fmt.Errorf("expectation set has no expectation named %q to override", override.name))
+			continue
+		}
+		entry, err := override.apply(entry)
+		checkError(m.t, err)
+		entries[override.name] = entry
+	}
+
+	calls := make(map[string]*MockAPICall, len(entries))
+	for _, name := range s.names {
+		entry := entries[name]
+		if entry.setup == nil {
+			continue
+		}
+		calls[name] = entry.setup(m)
+	}
+	return calls
+}
+
+// SetOverride changes a single expectation of an ExpectationSet for one application
+// of the set.
+type SetOverride struct {
+	name  string
+	apply func(setEntry) (setEntry, error)
+}
+
+// Override replaces the named expectation with the one registered by setup.
+func Override(name string, setup ExpectationSetup) SetOverride {
+	return SetOverride{name: name, apply: func(setEntry) (setEntry, error) {
+		return setEntry{setup: setup}, nil
+	}}
+}
+
+// OverrideReply replaces the reply of the named expectation while keeping its
+// request. The expectation must have been added with AddRequest or AddJSON.
+func OverrideReply(name string, resp MockResponse) SetOverride {
+	return SetOverride{name: name, apply: func(entry setEntry) (setEntry, error) {
+		if entry.req == nil {
+			return entry, fmt.Errorf("the reply of expectation %q cannot be overridden as its request is unknown", name)
+		}
+		req := entry.req
+		return setEntry{req: req, setup: func(m *MockAPI) *MockAPICall {
+			return m.WithRequest(req, resp)
+		}}, nil
+	}}
+}
+
+// OverrideJSON replaces the reply of the named expectation with the JSON encoded
+// reply while keeping its request. The expectation must have been added with
+// AddRequest or AddJSON.
+func OverrideJSON(name string, status int, reply interface{}) SetOverride {
+	return SetOverride{name: name, apply: func(entry setEntry) (setEntry, error) {
+		if entry.req == nil {
+			return entry, fmt.Errorf("the reply of expectation %q cannot be overridden as its request is unknown", name)
+		}
+		req := entry.req
+		return setEntry{req: req, setup: func(m *MockAPI) *MockAPICall {
+			return m.WithJSONReply(req, status, reply)
+		}}, nil
+	}}
+}
+
+// Configure further configures the call registered for the named expectation, for
+// example to change how many times it is expected.
+func Configure(name string, configure func(call *MockAPICall)) SetOverride {
+	return SetOverride{name: name, apply: func(entry setEntry) (setEntry, error) {
+		setup := entry.setup
+		if setup == nil {
+			return entry, nil
+		}
+		entry.setup = func(m *MockAPI) *MockAPICall {
+			call := setup(m)
+			configure(call)
+			return call
+		}
+		return entry, nil
+	}}
+}
+
+// Without leaves out the named expectation.
+func Without(name string) SetOverride {
+	return SetOverride{name: name, apply: func(entry setEntry) (setEntry, error) {
+		return setEntry{}, nil
+	}}
+}
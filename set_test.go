@@ -0,0 +1,62 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+var testSessionSet = NewExpectationSet().
+	AddJSON("login", NewMockRequest("POST", "/login"), 200, map[string]string{"token": "abc"}).
+	AddJSON("flags", NewMockRequest("GET", "/flags"), 200, map[string]bool{"beta": false}).
+	Add("logout", func(m *MockAPI) *MockAPICall {
+		return m.WithNoResponseBody(NewMockRequest("POST", "/logout"), 204)
+	})
+
+func TestExpectationSet(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Content-Length",
+		"Content-Type",
+		"User-Agent",
+	})
+
+	calls := testSessionSet.Apply(m,
+		OverrideJSON("flags", 200, map[string]bool{"beta": true}),
+		Configure("login", func(call *MockAPICall) { call.Once() }),
+		Without("logout"),
+	)
+	if _, ok := calls["logout"]; ok || len(calls) != 2 {
+		t.Fatalf("Unexpected calls applied: %v", calls)
+	}
+	calls["flags"].Once()
+
+	resp, err := http.Post(fmt.Sprintf("%s/login", m.URL()), "", nil)
+	if err != nil {
+		t.Fatalf("Error issuing POST of /login: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/flags", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /flags: %v", err)
+	}
+	var flags map[string]bool
+	json.NewDecoder(resp.Body).Decode(&flags)
+	resp.Body.Close()
+
+	if !flags["beta"] {
+		t.Fatalf("Expected the overridden flags but got: %v", flags)
+	}
+
+	// the set itself is unchanged by the overrides
+	calls = testSessionSet.Apply(NewMockAPI(t))
+	if len(calls) != 3 {
+		t.Fatalf("Expected all expectations to be applied but got: %v", calls)
+	}
+	for _, call := range calls {
+		call.Maybe()
+	}
+}
@@ -0,0 +1,157 @@
+package mockapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// UpdateSnapshotsEnv is the environment variable which, when set to a non-empty value,
+// causes response snapshots to be rewritten instead of compared.
+const UpdateSnapshotsEnv = "MOCKAPI_UPDATE_SNAPSHOTS"
+
+// Snapshot is the format of a response snapshot file.
+type Snapshot struct {
+	Responses []SnapshotResponse
+}
+
+// SnapshotResponse is a single response the MockAPI served.
+type SnapshotResponse struct {
+	// Request is the method and URL of the request, for example "GET /nodes?region=east".
+	Request string
+	Status  int
+	Headers http.Header `json:",omitempty"`
+	Body    string      `json:",omitempty"`
+}
+
+// WithSnapshot configures the MockAPI to record all the responses it serves and compare
+// them to the snapshot stored in the file at path when it is closed. Any difference fails
+// the test, which helps keep large sets of configured replies from drifting unnoticed.
+//
+// The snapshot file is written instead when it does not exist yet or when the
+// MOCKAPI_UPDATE_SNAPSHOTS environment variable is set. Responses are ordered by their
+// request so that concurrent requests do not cause spurious differences.
+func WithSnapshot(path string) Option {
+	return func(m *MockAPI) error {
+		m.snapshot = &snapshotRecorder{path: path}
+		return nil
+	}
+}
+
+type snapshotRecorder struct {
+	path string
+
+	mu        sync.Mutex
+	responses []SnapshotResponse
+}
+
+func (s *snapshotRecorder) record(resp SnapshotResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses = append(s.responses, resp)
+}
+
+// snapshot returns the recorded responses ordered by their request. The order of
+// responses to the same request is kept.
+func (s *snapshotRecorder) snapshot() *Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	responses := make([]SnapshotResponse, len(s.responses))
+	copy(responses, s.responses)
+	sort.SliceStable(responses, func(i, j int) bool {
+		return responses[i].Request < responses[j].Request
+	})
+	return &Snapshot{Responses: responses}
+}
+
+// checkSnapshot compares the served responses to the snapshot file or writes it.
+func (m *MockAPI) checkSnapshot() {
+	if m.snapshot == nil {
+		return
+	}
+
+	actual := m.snapshot.snapshot()
+	data, err := ioutil.ReadFile(m.snapshot.path)
+	if os.Getenv(UpdateSnapshotsEnv) != "" || os.IsNotExist(err) {
+		if err := writeSnapshot(m.snapshot.path, actual); err != nil {
+			m.errorf("failed to write response snapshot: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		m.errorf("failed to read response snapshot: %v", err)
+		return
+	}
+
+	var expected Snapshot
+	if err := json.Unmarshal(data, &expected); err != nil {
+		m.errorf("failed to parse response snapshot %q: %v", m.snapshot.path, err)
+		return
+	}
+
+	if diff := cmp.Diff(expected, *actual); diff != "" {
+		m.errorf("responses have drifted from the snapshot %q (-snapshot +served):\n%s\nSet %s=1 to update the snapshot.",
+			m.snapshot.path, diff, UpdateSnapshotsEnv)
+	}
+}
+
+func writeSnapshot(path string, snapshot *Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// snapshotWriter is an http.ResponseWriter which records the response being sent.
+type snapshotWriter struct {
+	wrappedWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *snapshotWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *snapshotWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// response returns the recorded response to the request.
+func (w *snapshotWriter) response(r *http.Request) SnapshotResponse {
+	if w.status == 0 {
+		w.status = http.StatusOK
+		w.header = w.Header().Clone()
+	}
+	if len(w.header) == 0 {
+		w.header = nil
+	}
+	return SnapshotResponse{
+		Request: fmt.Sprintf("%s %s", r.Method, r.URL.RequestURI()),
+		Status:  w.status,
+		Headers: w.header,
+		Body:    w.body.String(),
+	}
+}
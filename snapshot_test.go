@@ -0,0 +1,61 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithSnapshot(t *testing.T) {
+	os.Unsetenv(UpdateSnapshotsEnv)
+	path := filepath.Join(t.TempDir(), "snapshots", "responses.json")
+
+	run := func(reply string) []string {
+		rt := &recordingT{}
+		m := NewMockAPI(rt, WithSnapshot(path))
+		m.SetFilteredHeaders([]string{
+			"Accept-Encoding",
+			"User-Agent",
+		})
+		m.WithJSONReply(NewMockRequest("GET", "/nodes"), 200, map[string]string{"name": reply}).Once()
+
+		resp, err := http.Get(fmt.Sprintf("%s/nodes", m.URL()))
+		if err != nil {
+			t.Fatalf("Error issuing GET of /nodes: %v", err)
+		}
+		resp.Body.Close()
+
+		m.Close()
+		return rt.Errors()
+	}
+
+	// the first run writes the snapshot
+	if errs := run("node-1"); len(errs) != 0 {
+		t.Fatalf("Unexpected failures writing the snapshot: %v", errs)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected the snapshot to be written: %v", err)
+	}
+
+	if errs := run("node-1"); len(errs) != 0 {
+		t.Fatalf("Unexpected failures comparing an unchanged snapshot: %v", errs)
+	}
+
+	errs := run("node-2")
+	if len(errs) != 1 || !strings.Contains(errs[0], "drifted") || !strings.Contains(errs[0], "node-2") {
+		t.Fatalf("Expected the drifted response to fail but got: %v", errs)
+	}
+
+	os.Setenv(UpdateSnapshotsEnv, "1")
+	defer os.Unsetenv(UpdateSnapshotsEnv)
+	if errs := run("node-2"); len(errs) != 0 {
+		t.Fatalf("Unexpected failures updating the snapshot: %v", errs)
+	}
+	os.Unsetenv(UpdateSnapshotsEnv)
+	if errs := run("node-2"); len(errs) != 0 {
+		t.Fatalf("Unexpected failures comparing the updated snapshot: %v", errs)
+	}
+}
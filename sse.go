@@ -0,0 +1,100 @@
+package mockapi
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SSEEvent is a single Server-Sent Event.
+type SSEEvent struct {
+	// ID, when set, is sent as the id field of the event.
+	ID string
+	// Event, when set, is sent as the event type.
+	Event string
+	// Data is the payload of the event. Multi-line data is sent as multiple data fields.
+	Data string
+	// Retry, when set, is sent as the reconnection time of the event.
+	Retry time.Duration
+}
+
+// encode frames the event as specified for the text/event-stream format.
+func (e SSEEvent) encode() []byte {
+	var buf bytes.Buffer
+	if e.ID != "" {
+		fmt.Fprintf(&buf, "id: %s\n", e.ID)
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", e.Event)
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&buf, "retry: %d\n", e.Retry.Milliseconds())
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\n") {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// WithSSEReply will setup an expectation for an API call to be made. The supplied status code will
+// be used for the responses reply with a Content-Type of text/event-stream and each of the events
+// will be written and flushed, waiting for interval between events. The stream ends after the last
+// event.
+func (m *MockAPI) WithSSEReply(req *MockRequest, status int, events []SSEEvent, interval time.Duration) *MockAPICall {
+	return m.WithSSEReplyUntil(req, status, events, interval, nil)
+}
+
+// WithSSEReplyUntil is like WithSSEReply except that the stream is kept open after the last event
+// until done is closed, allowing clients to be tested against a stream which stays idle. The reply
+// is abandoned if the client goes away or the MockAPI is shut down via CloseContext.
+func (m *MockAPI) WithSSEReplyUntil(req *MockRequest, status int, events []SSEEvent, interval time.Duration, done <-chan struct{}) *MockAPICall {
+	closing := m.closing
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(status)
+		flusher, _ := w.(http.Flusher)
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		wait := func(c <-chan time.Time) bool {
+			select {
+			case <-c:
+				return true
+			case <-r.Context().Done():
+			case <-closing:
+			}
+			return false
+		}
+
+		for i, event := range events {
+			if i > 0 && interval > 0 {
+				timer := time.NewTimer(interval)
+				ok := wait(timer.C)
+				timer.Stop()
+				if !ok {
+					return
+				}
+			}
+
+			if _, err := w.Write(event.encode()); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		if done != nil {
+			select {
+			case <-done:
+			case <-r.Context().Done():
+			case <-closing:
+			}
+		}
+	})
+}
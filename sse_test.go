@@ -0,0 +1,86 @@
+package mockapi
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithSSEReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithSSEReply(NewMockRequest("GET", "/events"), 200, []SSEEvent{
+		{ID: "1", Event: "created", Data: `{"id":1}`},
+		{Data: "line one\nline two", Retry: 3 * time.Second},
+	}, 10*time.Millisecond).Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/events", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /events: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Unexpected Content-Type %q", ct)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Error reading response body: %v", err)
+	}
+	expected := "id: 1\nevent: created\ndata: {\"id\":1}\n\nretry: 3000\ndata: line one\ndata: line two\n\n"
+	if string(body) != expected {
+		t.Fatalf("Expected body %q but got %q", expected, body)
+	}
+}
+
+func TestWithSSEReplyUntil(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	done := make(chan struct{})
+	m.WithSSEReplyUntil(NewMockRequest("GET", "/events"), 200, []SSEEvent{{Data: "hello"}}, 0, done).Once()
+
+	resp, err := http.Get(fmt.Sprintf("%s/events", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /events: %v", err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	for _, expected := range []string{"data: hello\n", "\n"} {
+		line, err := reader.ReadString('\n')
+		if err != nil || line != expected {
+			t.Fatalf("Expected %q but got %q: %v", expected, line, err)
+		}
+	}
+
+	ended := make(chan error, 1)
+	go func() {
+		_, err := reader.ReadByte()
+		ended <- err
+	}()
+
+	select {
+	case err := <-ended:
+		t.Fatalf("Expected the stream to stay open but it ended: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(done)
+	select {
+	case <-ended:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the stream to end once done was closed")
+	}
+}
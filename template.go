@@ -0,0 +1,97 @@
+package mockapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"text/template"
+)
+
+// TemplateData is the request data available to the templates of WithTemplatedReply.
+type TemplateData struct {
+	// ID is the correlation ID of the request. See RequestID.
+	ID     string
+	Method string
+	Path   string
+	// PathParams holds the path parameters of a path template such as the id of "/users/{id}".
+	PathParams map[string]string
+	Query      url.Values
+	Header     http.Header
+	// Body is the decoded JSON request body or, when the body is not JSON, the raw body as
+	// a string.
+	Body interface{}
+	// Scratch holds the values of the MockAPI's Scratchpad when the reply is rendered, such
+	// as those stored by the Capture hooks of earlier requests.
+	Scratch map[string]interface{}
+}
+
+// templateFuncs are the functions available to the templates of WithTemplatedReply
+// besides the text/template builtins.
+var templateFuncs = template.FuncMap{
+	// json encodes the value as JSON, for example to echo a part of the request body
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// newTemplateData collects the data of the request for rendering a template.
+func newTemplateData(r *http.Request) (*TemplateData, error) {
+	data := &TemplateData{
+		ID:         RequestID(r),
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		PathParams: PathParams(r),
+		Query:      r.URL.Query(),
+		Header:     r.Header,
+	}
+
+	if r.Body != nil {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &data.Body); err != nil {
+				data.Body = string(body)
+			}
+		}
+	}
+	return data, nil
+}
+
+// WithTemplatedReply will setup an expectation for an API call to be made. The supplied status code
+// will be used for the responses reply and the body will be the result of rendering the text/template
+// with the TemplateData of the request, for example:
+//
+//	m.WithTemplatedReply(mockapi.NewMockRequest("PUT", "/users/{id}"), 200,
+//		`{"id": "{{.PathParams.id}}", "name": {{json .Body.name}}, "request": "{{.Header.Get "X-Request-Id"}}"}`)
+//
+// Values captured into the Scratchpad by earlier requests are available as .Scratch, for example
+// {{.Scratch.userID}}. Besides the builtin functions, a json function encodes a value as JSON. A
+// template which fails to parse fails the test when the expectation is setup.
+func (m *MockAPI) WithTemplatedReply(req *MockRequest, status int, tmpl string) *MockAPICall {
+	t, err := template.New("reply").Funcs(templateFuncs).Parse(tmpl)
+	checkError(m.t, err)
+
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		data, err := newTemplateData(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data.Scratch = m.Scratchpad().snapshot()
+
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			m.errorf("mockapi: failed to render the reply template for %s %s: %v", r.Method, r.URL.Path, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(status)
+		w.Write(buf.Bytes())
+	})
+}
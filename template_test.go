@@ -0,0 +1,79 @@
+package mockapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithTemplatedReply(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+		"X-Request-Id",
+	})
+
+	m.WithTemplatedReply(NewMockRequest("PUT", "/users/{id}").
+		WithBody(map[string]interface{}{"name": "jane", "tags": []interface{}{"a"}}).
+		WithQueryParams(map[string]string{"verbose": "true"}), 200,
+		`{"id":"{{.PathParams.id}}","name":{{json .Body.name}},"tags":{{json .Body.tags}},"verbose":{{.Query.Get "verbose"}},"request":"{{.Header.Get "X-Request-Id"}}"}`).Once()
+
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/users/42?verbose=true", m.URL()), strings.NewReader(`{"name":"jane","tags":["a"]}`))
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+	req.Header.Set("X-Request-Id", "abc")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error issuing PUT of /users/42: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Error reading response body: %v", err)
+	}
+	expected := `{"id":"42","name":"jane","tags":["a"],"verbose":true,"request":"abc"}`
+	if resp.StatusCode != 200 || string(body) != expected {
+		t.Fatalf("Expected %q but got %d %q", expected, resp.StatusCode, body)
+	}
+}
+
+func TestWithTemplatedReplyScratch(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	// the ID captured from the first request is echoed back by the later templated reply
+	m.WithNoResponseBody(NewMockRequest("POST", "/users/{id}"), 201).Once().Capture(func(r *http.Request, pad *Scratchpad) {
+		pad.Set("userID", PathParam(r, "id"))
+	})
+	m.WithTemplatedReply(NewMockRequest("GET", "/session"), 200, `{"user": "{{.Scratch.userID}}"}`).Once()
+
+	resp, err := http.Post(fmt.Sprintf("%s/users/u-42", m.URL()), "", nil)
+	if err != nil {
+		t.Fatalf("Error issuing POST of /users/u-42: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/session", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /session: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != `{"user": "u-42"}` {
+		t.Fatalf("Unexpected templated reply: %s", body)
+	}
+}
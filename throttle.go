@@ -0,0 +1,149 @@
+package mockapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithBandwidthLimit caps the combined throughput of all response bodies written by the
+// MockAPI to the given number of bytes per second. This allows exercising a whole client
+// workflow under simulated slow network conditions.
+func WithBandwidthLimit(bytesPerSecond int) Option {
+	return func(m *MockAPI) error {
+		if bytesPerSecond <= 0 {
+			return fmt.Errorf("bandwidth limit must be positive: %d", bytesPerSecond)
+		}
+		m.writeLimiter = newRateLimiter(bytesPerSecond)
+		return nil
+	}
+}
+
+// WithRequestBandwidthLimit caps the combined throughput of reading all request bodies
+// received by the MockAPI to the given number of bytes per second.
+func WithRequestBandwidthLimit(bytesPerSecond int) Option {
+	return func(m *MockAPI) error {
+		if bytesPerSecond <= 0 {
+			return fmt.Errorf("bandwidth limit must be positive: %d", bytesPerSecond)
+		}
+		m.readLimiter = newRateLimiter(bytesPerSecond)
+		return nil
+	}
+}
+
+// WithThrottle limits the rate at which the response body of each request matching this call
+// is written to the given number of bytes per second. Unlike WithBandwidthLimit, every response
+// gets the full rate and other calls are unaffected. This allows testing progress reporting and
+// read timeouts of download clients.
+func (m *MockAPICall) WithThrottle(bytesPerSecond int) *MockAPICall {
+	if bytesPerSecond <= 0 {
+		checkError(m.api.t, fmt.Errorf("throttle for %s must be positive: %d", m, bytesPerSecond))
+		return m
+	}
+
+	m.wrap(func(next MockResponse) MockResponse {
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(&throttledWriter{wrappedWriter: wrappedWriter{w}, ctx: r.Context(), limiter: newRateLimiter(bytesPerSecond)}, r)
+		}
+	})
+	return m
+}
+
+// rateLimiter schedules the transfer of bytes so that the overall rate of all
+// transfers does not exceed the configured rate.
+type rateLimiter struct {
+	rate int
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newRateLimiter(bytesPerSecond int) *rateLimiter {
+	return &rateLimiter{rate: bytesPerSecond}
+}
+
+// chunkSize is the largest amount of data that should be transferred at once in
+// order for the transfer to appear smooth.
+func (l *rateLimiter) chunkSize() int {
+	if size := l.rate / 10; size > 0 {
+		return size
+	}
+	return 1
+}
+
+// wait blocks until the transfer of n bytes would have completed at the
+// configured rate.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(n) * time.Second / time.Duration(l.rate))
+	delay := l.next.Sub(now)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttledWriter is an http.ResponseWriter whose writes are rate limited.
+type throttledWriter struct {
+	wrappedWriter
+	ctx     context.Context
+	limiter *rateLimiter
+}
+
+func (w *throttledWriter) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		chunk := b
+		if size := w.limiter.chunkSize(); len(chunk) > size {
+			chunk = chunk[:size]
+		}
+
+		if err := w.limiter.wait(w.ctx, len(chunk)); err != nil {
+			return written, err
+		}
+
+		n, err := w.ResponseWriter.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		w.Flush()
+		b = b[n:]
+	}
+	return written, nil
+}
+
+// throttledReader is an io.ReadCloser whose reads are rate limited.
+type throttledReader struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *rateLimiter
+}
+
+func (r *throttledReader) Read(b []byte) (int, error) {
+	if size := r.limiter.chunkSize(); len(b) > size {
+		b = b[:size]
+	}
+
+	n, err := r.ReadCloser.Read(b)
+	if n > 0 {
+		if werr := r.limiter.wait(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
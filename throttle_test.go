@@ -0,0 +1,73 @@
+package mockapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBandwidthLimit(t *testing.T) {
+	m := NewMockAPI(t, WithBandwidthLimit(1000))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithTextReply(NewMockRequest("GET", "/my/endpoint"), 200, strings.Repeat("a", 300)).Once()
+
+	start := time.Now()
+	resp, err := http.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /my/endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Error reading response: %v", err)
+	}
+
+	if len(body) != 300 {
+		t.Fatalf("Expected 300 bytes of response but got %d", len(body))
+	}
+
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Fatalf("Response was not throttled, took %v", elapsed)
+	}
+}
+
+func TestWithThrottle(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithTextReply(NewMockRequest("GET", "/download"), 200, strings.Repeat("a", 300)).Once().WithThrottle(1000)
+	m.WithTextReply(NewMockRequest("GET", "/other"), 200, strings.Repeat("a", 300)).Once()
+
+	for path, throttled := range map[string]bool{"/download": true, "/other": false} {
+		start := time.Now()
+		resp, err := http.Get(fmt.Sprintf("%s%s", m.URL(), path))
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", path, err)
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil || len(body) != 300 {
+			t.Fatalf("Expected 300 bytes of response but got %d: %v", len(body), err)
+		}
+
+		elapsed := time.Since(start)
+		if throttled && elapsed < 250*time.Millisecond {
+			t.Fatalf("Response for %s was not throttled, took %v", path, elapsed)
+		}
+		if !throttled && elapsed >= 250*time.Millisecond {
+			t.Fatalf("Response for %s was throttled, took %v", path, elapsed)
+		}
+	}
+}
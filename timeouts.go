@@ -0,0 +1,70 @@
+package mockapi
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// ServerTimeouts are the timeouts of the underlying http.Server. Zero values
+// mean there is no timeout.
+type ServerTimeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+}
+
+// WithServerTimeouts configures the read, write and idle timeouts of the underlying
+// http.Server. This allows testing how clients behave when the server closes their
+// connections.
+func WithServerTimeouts(timeouts ServerTimeouts) Option {
+	return func(m *MockAPI) error {
+		m.timeouts = timeouts
+		return nil
+	}
+}
+
+// WithRequestBodyTimeout configures the MockAPI to give up on clients which take
+// longer than the given duration to send the request body. Such requests are
+// replied to with a 408 Request Timeout status code and the connection is closed
+// without the request being matched against any expectations.
+func WithRequestBodyTimeout(d time.Duration) Option {
+	return func(m *MockAPI) error {
+		m.bodyTimeout = d
+		return nil
+	}
+}
+
+// applyTimeouts sets the configured timeouts on the server.
+func (m *MockAPI) applyTimeouts(s *http.Server) {
+	s.ReadHeaderTimeout = m.timeouts.ReadHeader
+	s.ReadTimeout = m.timeouts.Read
+	s.WriteTimeout = m.timeouts.Write
+	s.IdleTimeout = m.timeouts.Idle
+}
+
+// awaitBody reads the request body within the request body timeout. If the
+// body could not be read in time then a 408 reply is sent and false is returned.
+func (m *MockAPI) awaitBody(w http.ResponseWriter, r *http.Request) bool {
+	done := make(chan []byte, 1)
+	go func() {
+		// any read error leaves a truncated body which will fail to match
+		body, _ := ioutil.ReadAll(r.Body)
+		done <- body
+	}()
+
+	timer := time.NewTimer(m.bodyTimeout)
+	defer timer.Stop()
+
+	select {
+	case body := <-done:
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return true
+	case <-timer.C:
+		w.Header().Set("Connection", "close")
+		http.Error(w, "timed out reading the request body", http.StatusRequestTimeout)
+		return false
+	}
+}
@@ -0,0 +1,65 @@
+package mockapi
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithRequestBodyTimeout(t *testing.T) {
+	m := NewMockAPI(t, WithRequestBodyTimeout(50*time.Millisecond))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Content-Length",
+		"Content-Type",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("POST", "/upload").WithBody([]byte("complete")), 201).Once()
+
+	resp, err := http.Post(fmt.Sprintf("%s/upload", m.URL()), "text/plain", strings.NewReader("complete"))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /upload: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 201 {
+		t.Fatalf("Expected a 201 status code but got %d", resp.StatusCode)
+	}
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte("partial"))
+
+	resp, err = http.Post(fmt.Sprintf("%s/upload", m.URL()), "text/plain", pr)
+	if err != nil {
+		t.Fatalf("Error issuing POST of /upload: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestTimeout {
+		t.Fatalf("Expected a 408 status code but got %d", resp.StatusCode)
+	}
+}
+
+func TestWithServerTimeouts(t *testing.T) {
+	m := NewMockAPI(t, WithServerTimeouts(ServerTimeouts{Write: 50 * time.Millisecond}))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Connection",
+		"User-Agent",
+	})
+
+	m.WithRequest(NewMockRequest("GET", "/slow"), func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("too late"))
+	}).Once()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Get(fmt.Sprintf("%s/slow", m.URL()))
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("Expected the server to give up on the request")
+	}
+}
@@ -0,0 +1,111 @@
+package mockapi
+
+import (
+	"time"
+)
+
+// timeWindow is a constraint on when a call must first occur.
+type timeWindow struct {
+	// after is the call whose first occurrence starts the window. When nil
+	// the window starts when the expectation was registered.
+	after *MockAPICall
+	d     time.Duration
+}
+
+// Within asserts that this call first occurs within the given duration of the
+// expectation being registered. The assertion is performed along with the others
+// when AssertExpectations is called or the MockAPI is closed.
+func (m *MockAPICall) Within(d time.Duration) *MockAPICall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.windows = append(m.windows, timeWindow{d: d})
+	return m
+}
+
+// WithinAfter asserts that this call first occurs within the given duration of
+// the earlier call first occurring and not before it. This allows verifying the
+// polling intervals or debounce behavior of a client. The assertion is performed
+// along with the others when AssertExpectations is called or the MockAPI is closed.
+func (m *MockAPICall) WithinAfter(earlier *MockAPICall, d time.Duration) *MockAPICall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.windows = append(m.windows, timeWindow{after: earlier, d: d})
+	return m
+}
+
+// MustOccurWithin fails the test if this call has not first occurred within the given
+// duration of this method being called. Unlike Within, the failure is reported as soon
+// as the deadline passes rather than when the MockAPI is closed, catching clients which
+// silently stop polling while the test is still waiting on them. Deadlines which have
+// not yet passed when the MockAPI is closed are abandoned.
+func (m *MockAPICall) MustOccurWithin(d time.Duration) *MockAPICall {
+	api := m.api
+	timer := time.AfterFunc(d, func() {
+		if _, ok := m.firstMatch(); !ok {
+			api.errorf("mockapi: %s was expected within %v but has not occurred", m, d)
+		}
+	})
+
+	api.mu.Lock()
+	api.deadlines = append(api.deadlines, timer)
+	api.mu.Unlock()
+	return m
+}
+
+// stopDeadlines abandons all the deadlines which have not yet passed.
+func (m *MockAPI) stopDeadlines() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, timer := range m.deadlines {
+		timer.Stop()
+	}
+	m.deadlines = nil
+}
+
+// assertTiming checks all the time window constraints of the calls.
+func (m *MockAPI) assertTiming(t TestingT) {
+	m.mu.Lock()
+	calls := make([]*MockAPICall, len(m.calls))
+	copy(calls, m.calls)
+	m.mu.Unlock()
+
+	for _, call := range calls {
+		call.mu.Lock()
+		windows := call.windows
+		call.mu.Unlock()
+
+		if len(windows) == 0 {
+			continue
+		}
+
+		at, ok := call.firstMatch()
+		if !ok {
+			// whether the call needed to happen at all is covered by the
+			// other expectation assertions
+			continue
+		}
+
+		for _, window := range windows {
+			start := call.registered
+			startDesc := "the expectation was registered"
+			if window.after != nil {
+				var ok bool
+				start, ok = window.after.firstMatch()
+				if !ok {
+					t.Errorf("mockapi: %s was expected within %v after %s which never occurred", call, window.d, window.after)
+					continue
+				}
+				startDesc = window.after.String() + " occurred"
+			}
+
+			elapsed := at.Sub(start)
+			if elapsed < 0 {
+				t.Errorf("mockapi: %s was expected within %v after %s but occurred %v before it", call, window.d, startDesc, -elapsed)
+				continue
+			}
+			if elapsed > window.d {
+				t.Errorf("mockapi: %s was expected within %v after %s but occurred after %v", call, window.d, startDesc, elapsed)
+			}
+		}
+	}
+}
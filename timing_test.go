@@ -0,0 +1,188 @@
+package mockapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingT is a TestingT which records failures instead of failing the test.
+type recordingT struct {
+	mu     sync.Mutex
+	errors []string
+}
+
+func (t *recordingT) Logf(format string, args ...interface{}) {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+// Errors returns the recorded failures. It is safe to use while failures
+// are being reported from other goroutines.
+func (t *recordingT) Errors() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.errors...)
+}
+
+func (t *recordingT) FailNow() {}
+
+func TestWithin(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	first := m.WithNoResponseBody(NewMockRequest("GET", "/first"), 200).Once().Within(time.Second)
+	second := m.WithNoResponseBody(NewMockRequest("GET", "/second"), 200).Once().WithinAfter(first, time.Millisecond)
+
+	for _, path := range []string{"/first", "/second"} {
+		resp, err := http.Get(fmt.Sprintf("%s%s", m.URL(), path))
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", path, err)
+		}
+		resp.Body.Close()
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	rt := &recordingT{}
+	m.AssertExpectations(rt)
+	if len(rt.errors) != 1 {
+		t.Fatalf("Expected exactly one timing failure but got: %v", rt.errors)
+	}
+
+	// loosen the window so that the automatic cleanup assertions pass
+	second.mu.Lock()
+	second.windows = nil
+	second.mu.Unlock()
+}
+
+func TestWithinAfterOrdering(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	first := m.WithNoResponseBody(NewMockRequest("GET", "/first"), 200).Once()
+	second := m.WithNoResponseBody(NewMockRequest("GET", "/second"), 200).Once().WithinAfter(first, time.Second)
+
+	// the second call happening before the reference call is a failure
+	for _, path := range []string{"/second", "/first"} {
+		resp, err := http.Get(fmt.Sprintf("%s%s", m.URL(), path))
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", path, err)
+		}
+		resp.Body.Close()
+	}
+
+	rt := &recordingT{}
+	m.AssertExpectations(rt)
+	if len(rt.errors) != 1 {
+		t.Fatalf("Expected exactly one timing failure but got: %v", rt.errors)
+	}
+
+	// loosen the window so that the automatic cleanup assertions pass
+	second.mu.Lock()
+	second.windows = nil
+	second.mu.Unlock()
+}
+
+func TestMustOccurWithin(t *testing.T) {
+	rt := &recordingT{}
+	m := NewMockAPI(rt)
+	defer m.Close()
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/prompt"), 200).Once().MustOccurWithin(time.Second)
+	m.WithNoResponseBody(NewMockRequest("GET", "/poll"), 200).Maybe().MustOccurWithin(10 * time.Millisecond)
+
+	resp, err := http.Get(fmt.Sprintf("%s/prompt", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /prompt: %v", err)
+	}
+	resp.Body.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	if errs := rt.Errors(); len(errs) != 1 {
+		t.Fatalf("Expected exactly one deadline failure but got: %v", errs)
+	}
+}
+
+func TestWaitUntilContext(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/blocked"), 200).Once().WaitUntilContext(ctx)
+	m.WithNoResponseBody(NewMockRequest("GET", "/delayed"), 200).Twice().WaitFor(20 * time.Millisecond)
+
+	for _, path := range []string{"/blocked", "/delayed", "/delayed"} {
+		start := time.Now()
+		resp, err := http.Get(fmt.Sprintf("%s%s", m.URL(), path))
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", path, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			t.Fatalf("Unexpected status code for %s: %d", path, resp.StatusCode)
+		}
+		if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+			t.Fatalf("Expected the response for %s to be delayed but it took %s", path, elapsed)
+		}
+	}
+}
+
+func TestAfterAndJitter(t *testing.T) {
+	m := NewMockAPI(t, WithRandomSeed(1))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/after"), 200).Once().After(20 * time.Millisecond)
+	m.WithNoResponseBody(NewMockRequest("GET", "/jitter"), 200).Times(3).WithJitter(10*time.Millisecond, 30*time.Millisecond)
+
+	for _, path := range []string{"/after", "/jitter", "/jitter", "/jitter"} {
+		start := time.Now()
+		resp, err := http.Get(fmt.Sprintf("%s%s", m.URL(), path))
+		if err != nil {
+			t.Fatalf("Error issuing GET of %s: %v", path, err)
+		}
+		resp.Body.Close()
+
+		if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+			t.Fatalf("Expected the response for %s to be delayed but it took %s", path, elapsed)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	m.WithNoResponseBody(NewMockRequest("GET", "/slow"), 200).Maybe().After(time.Second)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/slow", m.URL()), nil)
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+	if resp, err := http.DefaultClient.Do(req); err == nil {
+		resp.Body.Close()
+		t.Fatalf("Expected the request to time out")
+	}
+}
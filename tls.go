@@ -0,0 +1,339 @@
+package mockapi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithTLS configures the MockAPI to serve HTTPS using a certificate generated by the
+// httptest package. Use MockAPI.Client to get an *http.Client which trusts it.
+func WithTLS() Option {
+	return func(m *MockAPI) error {
+		m.tls = true
+		return nil
+	}
+}
+
+// NewMockTLSAPI creates a MockAPI in the same manner as NewMockAPI but serving HTTPS, as if
+// created with the WithTLS option. Use MockAPI.Client to get an *http.Client which trusts the
+// server's certificate or CertificatePEM to configure other clients to trust it.
+func NewMockTLSAPI(t TestingT, opts ...Option) *MockAPI {
+	return NewMockAPI(t, append([]Option{WithTLS()}, opts...)...)
+}
+
+// WithHTTP2 configures the MockAPI to serve HTTPS with HTTP/2 enabled. Use
+// MockAPI.Client to get an *http.Client which will negotiate HTTP/2.
+func WithHTTP2() Option {
+	return func(m *MockAPI) error {
+		m.tls = true
+		m.http2 = true
+		return nil
+	}
+}
+
+// WithHTTPAndHTTPS configures the MockAPI to serve the same expectations over both
+// plaintext HTTP and HTTPS using two listeners. URL returns the URL of the scheme the
+// MockAPI would otherwise serve (HTTPS when used with WithTLS and HTTP otherwise) while
+// HTTPURL and HTTPSURL return the URL for a specific scheme. This allows testing HTTP
+// to HTTPS redirect handling in clients.
+func WithHTTPAndHTTPS() Option {
+	return func(m *MockAPI) error {
+		m.startHooks = append(m.startHooks, m.startAltScheme)
+		return nil
+	}
+}
+
+// startAltScheme starts a server for the scheme the main server isn't using.
+func (m *MockAPI) startAltScheme() (func(), error) {
+	m.alt = httptest.NewUnstartedServer(m)
+	m.applyTimeouts(m.alt.Config)
+	if m.tls {
+		if m.h2c {
+			m.alt.Config.Handler = m.h2cHandler()
+		}
+		m.alt.Start()
+	} else {
+		m.alt.TLS = m.serverTLSConfig()
+		m.alt.EnableHTTP2 = m.http2
+		m.alt.StartTLS()
+	}
+	return m.alt.Close, nil
+}
+
+// tlsServer returns the server serving HTTPS if there is one.
+func (m *MockAPI) tlsServer() *httptest.Server {
+	if !m.tls && m.alt != nil {
+		return m.alt
+	}
+	return m.s
+}
+
+// HTTPURL returns the URL for plaintext HTTP requests. It is empty when the
+// MockAPI only serves HTTPS.
+func (m *MockAPI) HTTPURL() string {
+	if m.s == nil {
+		return ""
+	}
+	if !m.tls {
+		return m.s.URL
+	}
+	if m.alt != nil {
+		return m.alt.URL
+	}
+	return ""
+}
+
+// HTTPSURL returns the URL for HTTPS requests. It is empty when the MockAPI
+// only serves plaintext HTTP.
+func (m *MockAPI) HTTPSURL() string {
+	if m.s == nil {
+		return ""
+	}
+	if m.tls {
+		return m.s.URL
+	}
+	if m.alt != nil {
+		return m.alt.URL
+	}
+	return ""
+}
+
+// Client returns an *http.Client configured for making requests to the MockAPI.
+// When serving HTTPS, the client trusts the server's certificate.
+func (m *MockAPI) Client() *http.Client {
+	if m.s == nil {
+		return &http.Client{}
+	}
+	if m.h2c && !m.tls {
+		return m.h2cClient()
+	}
+	return m.tlsServer().Client()
+}
+
+// Certificate returns the certificate used by the server when serving HTTPS and
+// nil otherwise.
+func (m *MockAPI) Certificate() *x509.Certificate {
+	if m.s == nil {
+		return nil
+	}
+	return m.tlsServer().Certificate()
+}
+
+// CertificatePEM returns the PEM encoding of the certificate returned by Certificate so that
+// it may be written to a file or added to the trusted roots of clients which are not created
+// by Client. It is nil when the server is not serving HTTPS.
+func (m *MockAPI) CertificatePEM() []byte {
+	cert := m.Certificate()
+	if cert == nil {
+		return nil
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+// TLSConfig returns a copy of the TLS configuration of the server, including its certificate,
+// when serving HTTPS and nil otherwise. It allows serving the MockAPI over other protocols
+// which use TLS, such as HTTP/3, with the same certificate.
+func (m *MockAPI) TLSConfig() *tls.Config {
+	if m.HTTPSURL() == "" {
+		return nil
+	}
+	return m.tlsServer().TLS.Clone()
+}
+
+// WithSNICertificate configures the MockAPI to serve HTTPS and to present the given
+// certificate to clients requesting the serverName via SNI. It may be used multiple
+// times to serve certificates for several hostnames from the one server. Clients
+// requesting any other name are presented with the certificate returned by Certificate.
+func WithSNICertificate(serverName string, cert tls.Certificate) Option {
+	return func(m *MockAPI) error {
+		if len(cert.Certificate) == 0 {
+			return fmt.Errorf("no certificate provided for server name %q", serverName)
+		}
+
+		m.tls = true
+		if m.sniCerts == nil {
+			m.sniCerts = make(map[string]tls.Certificate)
+		}
+		m.sniCerts[strings.ToLower(serverName)] = cert
+		return nil
+	}
+}
+
+// sniCertificate selects the certificate for the server name the client
+// requested. Returning nil falls back to the default certificate.
+func (m *MockAPI) sniCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if cert, ok := m.sniCerts[strings.ToLower(hello.ServerName)]; ok {
+		return &cert, nil
+	}
+	return nil, nil
+}
+
+// serverTLSConfig returns the TLS configuration for serving HTTPS. It is nil when the
+// defaults of the httptest package are sufficient.
+func (m *MockAPI) serverTLSConfig() *tls.Config {
+	if len(m.sniCerts) == 0 && m.clientCAs == nil {
+		return nil
+	}
+
+	config := &tls.Config{}
+	if len(m.sniCerts) > 0 {
+		config.GetCertificate = m.sniCertificate
+	}
+	if m.clientCAs != nil {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = m.clientCAs
+	}
+	return config
+}
+
+// TLSHandshakeFault is a way in which a TLS handshake can be made to fail.
+type TLSHandshakeFault int
+
+const (
+	// TLSFaultBadCertificate completes the server side of the handshake with a
+	// self-signed certificate that clients will not trust.
+	TLSFaultBadCertificate TLSHandshakeFault = iota
+	// TLSFaultUnsupportedVersion only allows TLS 1.0 to be negotiated which
+	// modern clients will refuse.
+	TLSFaultUnsupportedVersion
+	// TLSFaultReset resets the TCP connection before any of the handshake
+	// is performed.
+	TLSFaultReset
+)
+
+// WithTLSHandshakeFault configures the MockAPI to serve HTTPS and to fail the TLS handshake
+// for the first number of connection attempts in the manner specified. Afterwards, connections
+// are handled normally. This allows testing client TLS error handling and fallback logic.
+func WithTLSHandshakeFault(fault TLSHandshakeFault, attempts int) Option {
+	return func(m *MockAPI) error {
+		switch fault {
+		case TLSFaultBadCertificate, TLSFaultUnsupportedVersion, TLSFaultReset:
+		default:
+			return fmt.Errorf("unknown TLS handshake fault: %d", fault)
+		}
+
+		m.tls = true
+		m.tlsFault = &tlsFaultConfig{fault: fault, remaining: attempts}
+		return nil
+	}
+}
+
+type tlsFaultConfig struct {
+	fault TLSHandshakeFault
+
+	mu        sync.Mutex
+	remaining int
+}
+
+// take consumes one of the remaining faulty handshakes if there are any.
+func (c *tlsFaultConfig) take() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.remaining <= 0 {
+		return false
+	}
+	c.remaining--
+	return true
+}
+
+// tlsFaultListener wraps the raw TCP listener beneath the TLS listener and handles
+// the connections which should have faulty handshakes itself.
+type tlsFaultListener struct {
+	net.Listener
+	config *tlsFaultConfig
+	tls    *tls.Config
+}
+
+func newTLSFaultListener(l net.Listener, config *tlsFaultConfig) (*tlsFaultListener, error) {
+	fl := &tlsFaultListener{Listener: l, config: config}
+
+	switch config.fault {
+	case TLSFaultBadCertificate:
+		cert, err := selfSignedCertificate()
+		if err != nil {
+			return nil, err
+		}
+		fl.tls = &tls.Config{Certificates: []tls.Certificate{cert}}
+	case TLSFaultUnsupportedVersion:
+		cert, err := selfSignedCertificate()
+		if err != nil {
+			return nil, err
+		}
+		fl.tls = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS10,
+			MaxVersion:   tls.VersionTLS10,
+		}
+	}
+
+	return fl, nil
+}
+
+func (l *tlsFaultListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if !l.config.take() {
+			return conn, nil
+		}
+
+		go l.fail(conn)
+	}
+}
+
+func (l *tlsFaultListener) fail(conn net.Conn) {
+	defer conn.Close()
+
+	if l.config.fault == TLSFaultReset {
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			tcpConn.SetLinger(0)
+		}
+		return
+	}
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	tls.Server(conn, l.tls).Handshake()
+}
+
+// selfSignedCertificate generates a certificate for the loopback addresses
+// which is not signed by any trusted authority.
+func selfSignedCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "mock-http-api untrusted"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost", "example.com"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
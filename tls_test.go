@@ -0,0 +1,170 @@
+package mockapi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTLSHandshakeFault(t *testing.T) {
+	faults := map[string]TLSHandshakeFault{
+		"bad-certificate":     TLSFaultBadCertificate,
+		"unsupported-version": TLSFaultUnsupportedVersion,
+		"reset":               TLSFaultReset,
+	}
+
+	for name, fault := range faults {
+		fault := fault
+		t.Run(name, func(t *testing.T) {
+			m := NewMockAPI(t, WithTLSHandshakeFault(fault, 1))
+			m.SetFilteredHeaders([]string{
+				"Accept-Encoding",
+				"User-Agent",
+			})
+
+			m.WithNoResponseBody(NewMockRequest("GET", "/my/endpoint"), 200).Once()
+
+			client := m.Client()
+
+			_, err := client.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+			if err == nil {
+				t.Fatalf("Expected the first request to fail the TLS handshake")
+			}
+
+			resp, err := client.Get(fmt.Sprintf("%s/my/endpoint", m.URL()))
+			if err != nil {
+				t.Fatalf("Error issuing GET of /my/endpoint: %v", err)
+			}
+			resp.Body.Close()
+		})
+	}
+}
+
+func TestWithSNICertificate(t *testing.T) {
+	cert, err := selfSignedCertificate()
+	if err != nil {
+		t.Fatalf("Error generating certificate: %v", err)
+	}
+
+	m := NewMockAPI(t, WithSNICertificate("Example.com", cert))
+	addr := strings.TrimPrefix(m.URL(), "https://")
+
+	commonName := func(serverName string) string {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+		if err != nil {
+			t.Fatalf("Error dialing %s as %q: %v", addr, serverName, err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+
+	if cn := commonName("example.com"); cn != "mock-http-api untrusted" {
+		t.Fatalf("Expected the SNI certificate for example.com but got %q", cn)
+	}
+	if cn := commonName("other.test"); cn == "mock-http-api untrusted" {
+		t.Fatalf("Expected the default certificate for other.test")
+	}
+}
+
+func TestWithHTTPAndHTTPS(t *testing.T) {
+	m := NewMockAPI(t, WithHTTPAndHTTPS())
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Referer",
+		"User-Agent",
+	})
+
+	if m.URL() != m.HTTPURL() || !strings.HasPrefix(m.HTTPURL(), "http://") || !strings.HasPrefix(m.HTTPSURL(), "https://") {
+		t.Fatalf("Unexpected URLs: %s %s %s", m.URL(), m.HTTPURL(), m.HTTPSURL())
+	}
+
+	m.WithRequest(NewMockRequest("GET", "/login"), func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			w.WriteHeader(200)
+			return
+		}
+		http.Redirect(w, r, m.HTTPSURL()+"/login", http.StatusMovedPermanently)
+	}).Twice()
+
+	resp, err := m.Client().Get(fmt.Sprintf("%s/login", m.HTTPURL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /login: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 || resp.TLS == nil {
+		t.Fatalf("Expected to be redirected to HTTPS but got %d", resp.StatusCode)
+	}
+}
+
+func TestNewMockTLSAPI(t *testing.T) {
+	m := NewMockTLSAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/secure"), 200).Once()
+
+	if !strings.HasPrefix(m.URL(), "https://") {
+		t.Fatalf("Expected an HTTPS URL but got %s", m.URL())
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(m.CertificatePEM()) {
+		t.Fatalf("Error parsing the PEM encoded certificate")
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}
+
+	resp, err := client.Get(fmt.Sprintf("%s/secure", m.URL()))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /secure: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Unexpected status code %d", resp.StatusCode)
+	}
+}
+
+func TestWithStartHook(t *testing.T) {
+	var addr string
+	m := NewMockAPI(t, WithTLS(), WithStartHook(func(m *MockAPI) (func(), error) {
+		// serve the expectations from a second listener with the same certificate
+		l, err := tls.Listen("tcp", "127.0.0.1:0", m.TLSConfig())
+		if err != nil {
+			return nil, err
+		}
+		addr = l.Addr().String()
+		srv := &http.Server{Handler: m}
+		go srv.Serve(l)
+		return func() { srv.Close() }, nil
+	}))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/secure"), 200).Once()
+
+	resp, err := m.Client().Get(fmt.Sprintf("https://%s/secure", addr))
+	if err != nil {
+		t.Fatalf("Error issuing GET of /secure: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Unexpected status code %d", resp.StatusCode)
+	}
+
+	if NewMockAPI(t).TLSConfig() != nil {
+		t.Fatalf("Expected no TLS configuration when serving plaintext HTTP")
+	}
+}
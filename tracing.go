@@ -0,0 +1,133 @@
+package mockapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TraceContext is the W3C trace context (https://www.w3.org/TR/trace-context/)
+// propagated with a request.
+type TraceContext struct {
+	Version  string
+	TraceID  string
+	ParentID string
+	Flags    string
+	// TraceState is the unparsed value of the tracestate header.
+	TraceState string
+}
+
+// Sampled returns whether the sampled flag is set.
+func (tc TraceContext) Sampled() bool {
+	flags, err := hex.DecodeString(tc.Flags)
+	return err == nil && len(flags) == 1 && flags[0]&0x01 == 0x01
+}
+
+// ParseTraceparent parses the value of a W3C traceparent header.
+func ParseTraceparent(value string) (TraceContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return TraceContext{}, fmt.Errorf("invalid traceparent %q: expected 4 fields", value)
+	}
+
+	tc := TraceContext{
+		Version:  parts[0],
+		TraceID:  parts[1],
+		ParentID: parts[2],
+		Flags:    parts[3],
+	}
+
+	if !isLowerHex(tc.Version, 2) || tc.Version == "ff" || (tc.Version == "00" && len(parts) != 4) {
+		return TraceContext{}, fmt.Errorf("invalid traceparent %q: bad version", value)
+	}
+	if !isLowerHex(tc.TraceID, 32) || tc.TraceID == strings.Repeat("0", 32) {
+		return TraceContext{}, fmt.Errorf("invalid traceparent %q: bad trace id", value)
+	}
+	if !isLowerHex(tc.ParentID, 16) || tc.ParentID == strings.Repeat("0", 16) {
+		return TraceContext{}, fmt.Errorf("invalid traceparent %q: bad parent id", value)
+	}
+	if !isLowerHex(tc.Flags, 2) {
+		return TraceContext{}, fmt.Errorf("invalid traceparent %q: bad flags", value)
+	}
+	return tc, nil
+}
+
+func isLowerHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Span describes the handling of a single request by the MockAPI.
+type Span struct {
+	// Name of the span, of the form "<method> <path>"
+	Name string
+	// SpanID is a randomly generated identifier for this span.
+	SpanID string
+	// Parent is the trace context extracted from the request. It is only
+	// valid when HasParent is true.
+	Parent    TraceContext
+	HasParent bool
+	// ParentError is set when a traceparent header was present but could not be parsed.
+	ParentError error
+
+	Method string
+	Path   string
+	Status int
+	Start  time.Time
+	End    time.Time
+}
+
+// SpanHook is invoked after each request handled by the MockAPI has been replied to.
+// A hook may be used to forward spans to an OpenTelemetry exporter or to simply
+// collect them for assertions about the trace context propagated by a client.
+type SpanHook func(Span)
+
+// WithSpanHook sets a hook to be invoked with a Span for each request that
+// the MockAPI handles.
+func WithSpanHook(hook SpanHook) Option {
+	return func(m *MockAPI) error {
+		m.spanHook = hook
+		return nil
+	}
+}
+
+func newSpan(r *http.Request, status int, start time.Time) Span {
+	span := Span{
+		Name:   r.Method + " " + r.URL.Path,
+		SpanID: randomHex(8),
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Status: status,
+		Start:  start,
+		End:    time.Now(),
+	}
+
+	if traceparent := r.Header.Get("traceparent"); traceparent != "" {
+		parent, err := ParseTraceparent(traceparent)
+		if err != nil {
+			span.ParentError = err
+		} else {
+			parent.TraceState = strings.Join(r.Header.Values("tracestate"), ",")
+			span.Parent = parent
+			span.HasParent = true
+		}
+	}
+
+	return span
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
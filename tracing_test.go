@@ -0,0 +1,61 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	cases := map[string]bool{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": true,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7":    false,
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01": false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01": false,
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": false,
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01": false,
+	}
+
+	for value, valid := range cases {
+		_, err := ParseTraceparent(value)
+		if valid && err != nil {
+			t.Errorf("Expected %q to be valid: %v", value, err)
+		} else if !valid && err == nil {
+			t.Errorf("Expected %q to be invalid", value)
+		}
+	}
+}
+
+func TestSpanHook(t *testing.T) {
+	spans := make(chan Span, 1)
+	m := NewMockAPI(t, WithSpanHook(func(span Span) {
+		spans <- span
+	}))
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Traceparent",
+	})
+
+	m.WithNoResponseBody(NewMockRequest("GET", "/my/endpoint"), 200).Once()
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/my/endpoint", m.URL()), nil)
+	if err != nil {
+		t.Fatalf("Error creating request: %v", err)
+	}
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error issuing GET of /my/endpoint: %v", err)
+	}
+	resp.Body.Close()
+
+	span := <-spans
+	if !span.HasParent || span.Parent.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || !span.Parent.Sampled() {
+		t.Fatalf("Span did not have the expected parent: %+v", span)
+	}
+	if span.Name != "GET /my/endpoint" || span.Status != 200 {
+		t.Fatalf("Unexpected span: %+v", span)
+	}
+}
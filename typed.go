@@ -0,0 +1,43 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// ExpectJSONBody creates a MockRequest expecting a JSON body equal to want. The value
+// is JSON encoded and then decoded the same way as received bodies are, so that an
+// expectation written from a Go struct compares equal to the request the struct would
+// be sent as, regardless of field tags, omitted fields or number types. JSON values
+// other than objects are compared after decoding both sides, ignoring formatting.
+func ExpectJSONBody[T any](method, path string, want T) *MockRequest {
+	req := NewMockRequest(method, path)
+	req.setBodyOption("ExpectJSONBody")
+
+	data, err := json.Marshal(want)
+	if err != nil {
+		req.errs = append(req.errs, fmt.Errorf("failed to encode expected JSON body: %w", err))
+		return req
+	}
+
+	if body, ok := decodeBody(data).(map[string]interface{}); ok && body != nil {
+		req.body = body
+		return req
+	}
+
+	var wantValue interface{}
+	json.Unmarshal(data, &wantValue)
+
+	req.body = mock.Anything
+	req.matchers = append(req.matchers, func(rr *receivedRequest) bool {
+		var got interface{}
+		if err := json.Unmarshal(rr.bodyBytes, &got); err != nil {
+			return false
+		}
+		return reflect.DeepEqual(got, wantValue)
+	})
+	return req
+}
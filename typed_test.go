@@ -0,0 +1,45 @@
+package mockapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type typedNode struct {
+	ID      string            `json:"id"`
+	Port    int               `json:"port"`
+	Tags    []string          `json:"tags,omitempty"`
+	Meta    map[string]string `json:"meta"`
+	private string
+}
+
+func TestExpectJSONBody(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"Content-Length",
+		"Content-Type",
+		"User-Agent",
+	})
+
+	node := typedNode{ID: "node-1", Port: 8080, Meta: map[string]string{"zone": "a"}, private: "ignored"}
+	m.WithNoResponseBody(ExpectJSONBody("PUT", "/node", node), 200).Once()
+	m.WithNoResponseBody(ExpectJSONBody("PUT", "/nodes", []typedNode{node}), 200).Once()
+
+	for path, body := range map[string]interface{}{"/node": node, "/nodes": []typedNode{node}} {
+		data, _ := json.MarshalIndent(body, "", "  ")
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("%s%s", m.URL(), path), bytes.NewReader(data))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Error issuing PUT of %s: %v", path, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			t.Fatalf("Unexpected status code for %s: %d", path, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package mockapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// knownMethods are the HTTP methods an expectation may be set up for. Besides the
+// methods of RFC 9110 these include PATCH and the WebDAV methods.
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	"PROPFIND":         true,
+	"PROPPATCH":        true,
+	"MKCOL":            true,
+	"COPY":             true,
+	"MOVE":             true,
+	"LOCK":             true,
+	"UNLOCK":           true,
+	"REPORT":           true,
+	"SEARCH":           true,
+	"PURGE":            true,
+}
+
+// setBodyOption records which builder method set the body expectation so that
+// conflicting body expectations can be reported.
+func (r *MockRequest) setBodyOption(option string) {
+	if r.bodyOption != "" && r.bodyOption != option {
+		r.errs = append(r.errs, fmt.Errorf("conflicting body expectations: %s and %s may not be combined", r.bodyOption, option))
+	}
+	r.bodyOption = option
+}
+
+// validate checks that the request could ever be matched. It is called when the
+// expectation is registered so that mistakes fail the test immediately rather than
+// resulting in an unexpected request later on.
+func (r *MockRequest) validate() error {
+	var problems []string
+	for _, err := range r.errs {
+		problems = append(problems, err.Error())
+	}
+
+	if !knownMethods[r.method] {
+		msg := fmt.Sprintf("unknown HTTP method %q", r.method)
+		if upper := strings.ToUpper(r.method); knownMethods[upper] {
+			msg = fmt.Sprintf("%s, methods are case sensitive, did you mean %q?", msg, upper)
+		}
+		problems = append(problems, msg)
+	}
+
+	if !r.isPathRegexp() && !strings.HasPrefix(r.path, "/") && !(r.path == "*" && r.method == http.MethodOptions) {
+		problems = append(problems, fmt.Sprintf("path %q must start with a '/'", r.path))
+	}
+
+	switch len(problems) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("invalid expected request %s %s: %s", r.method, r.path, problems[0])
+	default:
+		return fmt.Errorf("invalid expected request %s %s:\n\t%s", r.method, r.path, strings.Join(problems, "\n\t"))
+	}
+}
@@ -0,0 +1,64 @@
+package mockapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMockRequestValidate(t *testing.T) {
+	cases := map[string]struct {
+		req      *MockRequest
+		expected string
+	}{
+		"valid": {
+			req: NewMockRequest("GET", "/resource").WithBody([]byte("abc")),
+		},
+		"options-asterisk": {
+			req: NewMockRequest("OPTIONS", "*"),
+		},
+		"lowercase-method": {
+			req:      NewMockRequest("get", "/resource"),
+			expected: `did you mean "GET"`,
+		},
+		"unknown-method": {
+			req:      NewMockRequest("FETCH", "/resource"),
+			expected: `unknown HTTP method "FETCH"`,
+		},
+		"relative-path": {
+			req:      NewMockRequest("GET", "resource"),
+			expected: `must start with a '/'`,
+		},
+		"conflicting-body": {
+			req:      NewMockRequest("PUT", "/resource").WithBody([]byte("abc")).WithBodyDigest("sha256", "abc"),
+			expected: "WithBody and WithBodyDigest may not be combined",
+		},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tcase.req.validate()
+			if tcase.expected == "" {
+				if err != nil {
+					t.Fatalf("Unexpected validation error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tcase.expected) {
+				t.Fatalf("Expected a validation error containing %q but got: %v", tcase.expected, err)
+			}
+		})
+	}
+}
+
+func TestWithRequestNilResponder(t *testing.T) {
+	m := NewMockAPI(nil)
+	defer m.Close()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("Expected registering a nil responder to panic without a TestingT")
+		}
+	}()
+	m.WithRequest(NewMockRequest(http.MethodGet, "/resource"), nil)
+}
@@ -0,0 +1,216 @@
+package mockapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RecordCassettesEnv is the environment variable which, when set to a non-empty value,
+// causes cassettes used with WithCassette to be recorded again instead of replayed.
+const RecordCassettesEnv = "MOCKAPI_RECORD_CASSETTES"
+
+// hopHeaders are the hop-by-hop headers which are not forwarded by the proxy.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// WithRecording configures the MockAPI to proxy every request to the upstream URL instead
+// of matching it against the expectations. Each request and the response it received are
+// recorded and written as a Cassette to the file at path when the MockAPI is closed. The
+// cassette is written as JSON when path has a .json extension and as YAML otherwise.
+//
+// Headers filtered with SetFilteredHeaders are not recorded so that the cassette can be
+// replayed with the same filters. Responses are recorded decompressed.
+func WithRecording(upstream, path string) Option {
+	return func(m *MockAPI) error {
+		u, err := url.Parse(upstream)
+		if err != nil {
+			return fmt.Errorf("invalid upstream URL %q: %w", upstream, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("upstream URL %q must be absolute", upstream)
+		}
+
+		m.cassette = &cassetteRecorder{upstream: u, path: path}
+		return nil
+	}
+}
+
+// WithCassette configures the MockAPI to replay the Cassette stored in the file at path
+// as expectations, as if loaded with LoadCassetteFile. When the file does not exist yet or
+// when the MOCKAPI_RECORD_CASSETTES environment variable is set, requests are instead
+// proxied to the upstream URL and recorded to the file as for WithRecording.
+func WithCassette(path, upstream string) Option {
+	return func(m *MockAPI) error {
+		_, err := os.Stat(path)
+		if os.Getenv(RecordCassettesEnv) != "" || os.IsNotExist(err) {
+			return WithRecording(upstream, path)(m)
+		}
+		if err != nil {
+			return err
+		}
+
+		m.startHooks = append(m.startHooks, func() (func(), error) {
+			return func() {}, m.LoadCassetteFile(path)
+		})
+		return nil
+	}
+}
+
+// LoadCassetteFile will load the Cassette stored in the file at path and setup an expectation
+// for each of the recorded interactions in the manner described for Cassette.Fixture.
+func (m *MockAPI) LoadCassetteFile(path string) error {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return err
+	}
+
+	fixture, err := cassette.Fixture()
+	if err != nil {
+		return fmt.Errorf("failed to convert cassette file %q: %w", path, err)
+	}
+	return m.WithFixture(fixture)
+}
+
+type cassetteRecorder struct {
+	upstream *url.URL
+	path     string
+	client   http.Client
+
+	mu           sync.Mutex
+	interactions []CassetteInteraction
+}
+
+// proxy forwards the request to the upstream server, records the interaction and
+// copies the upstream response to the client.
+func (c *cassetteRecorder) proxy(m *MockAPI, w http.ResponseWriter, r *http.Request, body []byte) {
+	target := *c.upstream
+	target.Path = strings.TrimSuffix(target.Path, "/") + r.URL.Path
+	target.RawPath = ""
+	target.RawQuery = r.URL.RawQuery
+
+	out, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		m.errorf("mockapi: failed to create upstream request for %s %s: %v", r.Method, r.URL, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	out.Header = r.Header.Clone()
+	for _, hdr := range hopHeaders {
+		out.Header.Del(hdr)
+	}
+	// let the transport negotiate compression so that bodies are recorded decompressed
+	out.Header.Del("Accept-Encoding")
+	out.ContentLength = int64(len(body))
+
+	resp, err := c.client.Do(out)
+	if err != nil {
+		m.errorf("mockapi: failed to proxy %s %s to %s: %v", r.Method, r.URL, c.upstream, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		m.errorf("mockapi: failed to read upstream response for %s %s: %v", r.Method, r.URL, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	var reqHeaders map[string][]string
+	for hdr, values := range r.Header {
+		if _, ok := m.filteredHeaders[hdr]; ok || contains(hopHeaders, hdr) {
+			continue
+		}
+		if reqHeaders == nil {
+			reqHeaders = make(map[string][]string)
+		}
+		reqHeaders[hdr] = values
+	}
+
+	header := resp.Header.Clone()
+	for _, hdr := range hopHeaders {
+		header.Del(hdr)
+	}
+
+	c.mu.Lock()
+	c.interactions = append(c.interactions, CassetteInteraction{
+		Request: CassetteRequest{
+			Method:  r.Method,
+			URL:     target.String(),
+			Headers: reqHeaders,
+			Body:    string(body),
+		},
+		Response: CassetteResponse{
+			Code:    resp.StatusCode,
+			Headers: header,
+			Body:    string(respBody),
+		},
+	})
+	c.mu.Unlock()
+
+	for hdr, values := range header {
+		w.Header()[hdr] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+}
+
+// cassette returns the recorded interactions.
+func (c *cassetteRecorder) cassette() *Cassette {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	interactions := make([]CassetteInteraction, len(c.interactions))
+	copy(interactions, c.interactions)
+	return &Cassette{Version: 1, Interactions: interactions}
+}
+
+// saveCassette writes the recorded interactions when recording.
+func (m *MockAPI) saveCassette() {
+	if m.cassette == nil {
+		return
+	}
+
+	if err := writeCassette(m.cassette.path, m.cassette.cassette()); err != nil {
+		m.errorf("failed to write cassette: %v", err)
+	}
+}
+
+func writeCassette(path string, cassette *Cassette) error {
+	var data []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err = json.MarshalIndent(cassette, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(cassette)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,69 @@
+package mockapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithCassette(t *testing.T) {
+	os.Unsetenv(RecordCassettesEnv)
+	path := filepath.Join(t.TempDir(), "cassettes", "nodes.yaml")
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"region": r.URL.Query().Get("region")})
+	}))
+
+	run := func() string {
+		m := NewMockAPI(t, WithCassette(path, upstream.URL), WithoutCleanup())
+		m.SetFilteredHeaders([]string{
+			"Accept-Encoding",
+			"User-Agent",
+		})
+
+		resp, err := http.Get(fmt.Sprintf("%s/v1/nodes?region=east", m.URL()))
+		if err != nil {
+			t.Fatalf("Error issuing GET of /v1/nodes: %v", err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Error reading response: %v", err)
+		}
+
+		m.Close()
+		return strings.TrimSpace(string(body))
+	}
+
+	// the first run records the upstream interactions
+	if body := run(); body != `{"region":"east"}` {
+		t.Fatalf("Unexpected recorded body: %s", body)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected the cassette to be written: %v", err)
+	}
+
+	// later runs replay the cassette without the upstream
+	upstream.Close()
+	if body := run(); body != `{"region":"east"}` {
+		t.Fatalf("Unexpected replayed body: %s", body)
+	}
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("Error loading cassette: %v", err)
+	}
+	if len(cassette.Interactions) != 1 || cassette.Interactions[0].Request.URL != upstream.URL+"/v1/nodes?region=east" {
+		t.Fatalf("Unexpected recorded interactions: %+v", cassette.Interactions)
+	}
+	if _, ok := cassette.Interactions[0].Request.Headers["User-Agent"]; ok {
+		t.Fatalf("Expected filtered headers to not be recorded")
+	}
+}
@@ -0,0 +1,586 @@
+package mockapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// wireMockDefaultPriority is the priority WireMock gives to mappings without one.
+const wireMockDefaultPriority = 5
+
+// wireMockAnyMethods are the methods expected for mappings with the ANY method.
+var wireMockAnyMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// WireMockMapping is a WireMock JSON stub mapping. Only the parts of the format which can be
+// expressed with MockRequest and MockAPICall are supported, see MockAPI.WithWireMockMappings.
+type WireMockMapping struct {
+	Name     string           `json:"name,omitempty"`
+	Priority int              `json:"priority,omitempty"`
+	Request  WireMockRequest  `json:"request"`
+	Response WireMockResponse `json:"response"`
+
+	ScenarioName          string `json:"scenarioName,omitempty"`
+	RequiredScenarioState string `json:"requiredScenarioState,omitempty"`
+	NewScenarioState      string `json:"newScenarioState,omitempty"`
+}
+
+// WireMockRequest describes the requests a mapping matches. At most one of URL, URLPath,
+// URLPattern and URLPathPattern may be set and when none are every URL is matched.
+type WireMockRequest struct {
+	Method         string `json:"method,omitempty"`
+	URL            string `json:"url,omitempty"`
+	URLPath        string `json:"urlPath,omitempty"`
+	URLPattern     string `json:"urlPattern,omitempty"`
+	URLPathPattern string `json:"urlPathPattern,omitempty"`
+
+	QueryParameters      map[string]WireMockPattern `json:"queryParameters,omitempty"`
+	Headers              map[string]WireMockPattern `json:"headers,omitempty"`
+	Cookies              map[string]WireMockPattern `json:"cookies,omitempty"`
+	BasicAuthCredentials *WireMockBasicAuth         `json:"basicAuthCredentials,omitempty"`
+	BodyPatterns         []WireMockPattern          `json:"bodyPatterns,omitempty"`
+}
+
+// WireMockBasicAuth are the HTTP Basic credentials a request must have.
+type WireMockBasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// WireMockPattern is a WireMock value matcher such as {"equalTo": "abc"}. Exactly one of the
+// operators must be set, the others being modifiers of it. Operators other than those below,
+// such as matchesJsonPath or equalToXml, are rejected when the mapping is decoded.
+type WireMockPattern struct {
+	EqualTo       *string         `json:"equalTo,omitempty"`
+	Contains      *string         `json:"contains,omitempty"`
+	Matches       *string         `json:"matches,omitempty"`
+	DoesNotMatch  *string         `json:"doesNotMatch,omitempty"`
+	Absent        *bool           `json:"absent,omitempty"`
+	BinaryEqualTo *string         `json:"binaryEqualTo,omitempty"`
+	EqualToJSON   json.RawMessage `json:"equalToJson,omitempty"`
+
+	CaseInsensitive     bool `json:"caseInsensitive,omitempty"`
+	IgnoreExtraElements bool `json:"ignoreExtraElements,omitempty"`
+	IgnoreArrayOrder    bool `json:"ignoreArrayOrder,omitempty"`
+}
+
+// wireMockPatternFields are the fields of WireMockPattern.
+var wireMockPatternFields = map[string]bool{
+	"equalTo":             true,
+	"contains":            true,
+	"matches":             true,
+	"doesNotMatch":        true,
+	"absent":              true,
+	"binaryEqualTo":       true,
+	"equalToJson":         true,
+	"caseInsensitive":     true,
+	"ignoreExtraElements": true,
+	"ignoreArrayOrder":    true,
+}
+
+// UnmarshalJSON decodes the pattern, rejecting unsupported operators rather than
+// silently matching more requests than the mapping does with WireMock.
+func (p *WireMockPattern) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for name := range fields {
+		if !wireMockPatternFields[name] {
+			return fmt.Errorf("unsupported WireMock matcher %q", name)
+		}
+	}
+
+	type wireMockPattern WireMockPattern
+	var parsed wireMockPattern
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return err
+	}
+	*p = WireMockPattern(parsed)
+	return nil
+}
+
+// WireMockResponse is the response of a mapping. The body is taken from the first of Body,
+// JSONBody, Base64Body and BodyFileName which is set. Header values may be either a string
+// or an array of strings.
+type WireMockResponse struct {
+	Status                 int                    `json:"status,omitempty"`
+	Headers                map[string]interface{} `json:"headers,omitempty"`
+	Body                   string                 `json:"body,omitempty"`
+	JSONBody               interface{}            `json:"jsonBody,omitempty"`
+	Base64Body             string                 `json:"base64Body,omitempty"`
+	BodyFileName           string                 `json:"bodyFileName,omitempty"`
+	FixedDelayMilliseconds int                    `json:"fixedDelayMilliseconds,omitempty"`
+	Fault                  string                 `json:"fault,omitempty"`
+}
+
+// ParseWireMockMappings decodes either a single WireMock mapping or a mappings file in the
+// form {"mappings": [...]}.
+func ParseWireMockMappings(data []byte) ([]WireMockMapping, error) {
+	var file struct {
+		Mappings []WireMockMapping `json:"mappings"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Mappings != nil {
+		return file.Mappings, nil
+	}
+
+	var mapping WireMockMapping
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, err
+	}
+	return []WireMockMapping{mapping}, nil
+}
+
+// ReadWireMockMappings reads the WireMock mappings stored in the file at path or, when path is
+// a directory, within every JSON (.json) file of the directory and its sub-directories in
+// lexical order. As with WireMock, the BodyFileName of responses is resolved relative to the
+// __files directory next to the directory holding the mapping files, so that pointing this at
+// the mappings directory of a WireMock root directory works as is.
+func ReadWireMockMappings(path string) ([]WireMockMapping, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return readWireMockMappingsFile(path, filepath.Join(filepath.Dir(filepath.Dir(path)), "__files"))
+	}
+
+	filesDir := filepath.Join(filepath.Dir(filepath.Clean(path)), "__files")
+	var mappings []WireMockMapping
+	err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.EqualFold(filepath.Ext(file), ".json") {
+			return err
+		}
+
+		fileMappings, err := readWireMockMappingsFile(file, filesDir)
+		mappings = append(mappings, fileMappings...)
+		return err
+	})
+	return mappings, err
+}
+
+func readWireMockMappingsFile(path, filesDir string) ([]WireMockMapping, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings, err := ParseWireMockMappings(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse WireMock mappings file %q: %w", path, err)
+	}
+	for i := range mappings {
+		if name := mappings[i].Response.BodyFileName; name != "" && !filepath.IsAbs(name) {
+			mappings[i].Response.BodyFileName = filepath.Join(filesDir, name)
+		}
+	}
+	return mappings, nil
+}
+
+// WithWireMockMappings will setup an expectation for each of the WireMock mappings. As WireMock
+// stubs are not assertions, the expectations are optional and may be matched any number of
+// times. As with WireMock, the mappings are matched in order of their priority, 1 being the
+// highest and 5 the default, and among mappings of the same priority the later ones are
+// matched first. Mappings are only ordered amongst each other and so expectations setup
+// before will still be matched first.
+//
+// The parts of a request map onto the matching of MockRequest:
+//   - url expects the exact path and query params while urlPath only expects the path.
+//   - urlPathPattern is a path pattern as for NewMockRequestPattern and urlPattern is matched
+//     against both the path and the query. Both must match in full as with WireMock.
+//   - headers, queryParameters and cookies only constrain those named, other headers,
+//     query params and cookies of the request are ignored.
+//   - bodyPatterns must all match the body, equalToJson with ignoreExtraElements matching as
+//     for WithBodySubset.
+//   - The ANY method, or no method, expects any of the common methods.
+//
+// Scenarios are mapped onto InScenario, WhenState and ThenState. Responses may use a fixed
+// delay and the CONNECTION_RESET_BY_PEER, EMPTY_RESPONSE and MALFORMED_RESPONSE_CHUNK faults.
+// Response templating and other extensions are not supported and are ignored.
+func (m *MockAPI) WithWireMockMappings(mappings []WireMockMapping) error {
+	ordered := make([]int, len(mappings))
+	for i := range ordered {
+		ordered[i] = len(mappings) - 1 - i
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return wireMockPriority(mappings[ordered[i]]) < wireMockPriority(mappings[ordered[j]])
+	})
+
+	for _, i := range ordered {
+		mapping := &mappings[i]
+		if err := m.withWireMockMapping(mapping); err != nil {
+			name := mapping.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i)
+			}
+			return fmt.Errorf("WireMock mapping %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func wireMockPriority(mapping WireMockMapping) int {
+	if mapping.Priority == 0 {
+		return wireMockDefaultPriority
+	}
+	return mapping.Priority
+}
+
+func (m *MockAPI) withWireMockMapping(mapping *WireMockMapping) error {
+	resp, err := mapping.Response.mockResponse()
+	if err != nil {
+		return err
+	}
+
+	methods := []string{mapping.Request.Method}
+	if mapping.Request.Method == "" || mapping.Request.Method == "ANY" {
+		methods = wireMockAnyMethods
+	}
+
+	for _, method := range methods {
+		req, err := mapping.Request.mockRequest(method)
+		if err != nil {
+			return err
+		}
+
+		call := m.WithRequest(req, resp).Maybe()
+		if mapping.Response.FixedDelayMilliseconds > 0 {
+			call.WaitFor(time.Duration(mapping.Response.FixedDelayMilliseconds) * time.Millisecond)
+		}
+		if mapping.ScenarioName != "" {
+			call.InScenario(mapping.ScenarioName)
+			if mapping.RequiredScenarioState != "" {
+				call.WhenState(mapping.RequiredScenarioState)
+			}
+			if mapping.NewScenarioState != "" {
+				call.ThenState(mapping.NewScenarioState)
+			}
+		}
+	}
+	return nil
+}
+
+// mockRequest converts the request into a MockRequest for the given method.
+func (w *WireMockRequest) mockRequest(method string) (*MockRequest, error) {
+	var urls []string
+	for _, u := range []string{w.URL, w.URLPath, w.URLPattern, w.URLPathPattern} {
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	if len(urls) > 1 {
+		return nil, fmt.Errorf("only one of url, urlPath, urlPattern and urlPathPattern may be set")
+	}
+
+	var req *MockRequest
+	switch {
+	case w.URL != "":
+		u, err := url.Parse(w.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url %q: %w", w.URL, err)
+		}
+		req = NewMockRequest(method, u.Path)
+		if u.RawQuery != "" {
+			req.WithQueryValues(u.Query())
+		}
+	case w.URLPath != "":
+		req = NewMockRequest(method, w.URLPath)
+		req.querySubset = true
+	case w.URLPathPattern != "":
+		pattern, err := regexp.Compile("^(?:" + w.URLPathPattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid urlPathPattern: %w", err)
+		}
+		req = NewMockRequestPattern(method, pattern)
+		req.querySubset = true
+	default:
+		req = NewMockRequestPattern(method, regexp.MustCompile(".*"))
+		req.querySubset = true
+		if w.URLPattern != "" {
+			pattern, err := regexp.Compile("^(?:" + w.URLPattern + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid urlPattern: %w", err)
+			}
+			req.Matching(func(r *http.Request) bool {
+				return pattern.MatchString(r.URL.RequestURI())
+			})
+		}
+	}
+
+	// WireMock ignores any headers and bodies which are not matched explicitly
+	req.headerSubset = true
+	req.body = mock.Anything
+
+	for name, pattern := range w.Headers {
+		match, err := pattern.matcher()
+		if err != nil {
+			return nil, fmt.Errorf("header %q: %w", name, err)
+		}
+		req.withHeaderMatcher(name, func(values []string) bool {
+			return matchAnyValue(match, values)
+		})
+	}
+
+	for name, pattern := range w.QueryParameters {
+		match, err := pattern.matcher()
+		if err != nil {
+			return nil, fmt.Errorf("query parameter %q: %w", name, err)
+		}
+		name := name
+		req.Matching(func(r *http.Request) bool {
+			return matchAnyValue(match, r.URL.Query()[name])
+		})
+	}
+
+	for name, pattern := range w.Cookies {
+		match, err := pattern.matcher()
+		if err != nil {
+			return nil, fmt.Errorf("cookie %q: %w", name, err)
+		}
+		name := name
+		req.Matching(func(r *http.Request) bool {
+			cookie, err := r.Cookie(name)
+			if err != nil {
+				return match("", false)
+			}
+			return match(cookie.Value, true)
+		})
+	}
+
+	if auth := w.BasicAuthCredentials; auth != nil {
+		req.WithBasicAuth(auth.Username, auth.Password)
+	}
+
+	for i, pattern := range w.BodyPatterns {
+		match, err := pattern.matcher()
+		if err != nil {
+			return nil, fmt.Errorf("body pattern %d: %w", i, err)
+		}
+		req.matchers = append(req.matchers, func(rr *receivedRequest) bool {
+			return match(string(rr.bodyBytes), true)
+		})
+	}
+
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// matchAnyValue returns whether any of the values match or, when there are none,
+// whether an absent value matches.
+func matchAnyValue(match func(value string, present bool) bool, values []string) bool {
+	if len(values) == 0 {
+		return match("", false)
+	}
+	for _, value := range values {
+		if match(value, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// matcher compiles the pattern into a function reporting whether a value, which may
+// not be present at all, matches.
+func (p *WireMockPattern) matcher() (func(value string, present bool) bool, error) {
+	var operators []string
+	for name, set := range map[string]bool{
+		"equalTo":       p.EqualTo != nil,
+		"contains":      p.Contains != nil,
+		"matches":       p.Matches != nil,
+		"doesNotMatch":  p.DoesNotMatch != nil,
+		"absent":        p.Absent != nil,
+		"binaryEqualTo": p.BinaryEqualTo != nil,
+		"equalToJson":   len(p.EqualToJSON) > 0,
+	} {
+		if set {
+			operators = append(operators, name)
+		}
+	}
+	if len(operators) != 1 {
+		sort.Strings(operators)
+		return nil, fmt.Errorf("expected exactly one matcher but got %v", operators)
+	}
+
+	switch {
+	case p.EqualTo != nil:
+		want := *p.EqualTo
+		if p.CaseInsensitive {
+			return func(value string, present bool) bool {
+				return present && strings.EqualFold(value, want)
+			}, nil
+		}
+		return func(value string, present bool) bool {
+			return present && value == want
+		}, nil
+
+	case p.Contains != nil:
+		want := *p.Contains
+		return func(value string, present bool) bool {
+			return present && strings.Contains(value, want)
+		}, nil
+
+	case p.Matches != nil, p.DoesNotMatch != nil:
+		expr, negate := p.Matches, false
+		if expr == nil {
+			expr, negate = p.DoesNotMatch, true
+		}
+		pattern, err := regexp.Compile("^(?:" + *expr + ")$")
+		if err != nil {
+			return nil, err
+		}
+		return func(value string, present bool) bool {
+			return present && pattern.MatchString(value) != negate
+		}, nil
+
+	case p.Absent != nil:
+		absent := *p.Absent
+		return func(value string, present bool) bool {
+			return present != absent
+		}, nil
+
+	case p.BinaryEqualTo != nil:
+		want, err := base64.StdEncoding.DecodeString(*p.BinaryEqualTo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid binaryEqualTo: %w", err)
+		}
+		return func(value string, present bool) bool {
+			return present && bytes.Equal([]byte(value), want)
+		}, nil
+
+	default:
+		if p.IgnoreArrayOrder {
+			return nil, fmt.Errorf("ignoreArrayOrder is not supported")
+		}
+
+		// the expected JSON may be given either as JSON or as a string holding JSON
+		data := []byte(p.EqualToJSON)
+		var s string
+		if err := json.Unmarshal(data, &s); err == nil {
+			data = []byte(s)
+		}
+		var want interface{}
+		if err := json.Unmarshal(data, &want); err != nil {
+			return nil, fmt.Errorf("invalid equalToJson: %w", err)
+		}
+
+		subset := p.IgnoreExtraElements
+		return func(value string, present bool) bool {
+			var got interface{}
+			if !present || json.Unmarshal([]byte(value), &got) != nil {
+				return false
+			}
+			if subset {
+				return containsJSON(got, want)
+			}
+			return reflect.DeepEqual(got, want)
+		}, nil
+	}
+}
+
+// mockResponse converts the response into a MockResponse.
+func (w *WireMockResponse) mockResponse() (MockResponse, error) {
+	switch w.Fault {
+	case "":
+	case "CONNECTION_RESET_BY_PEER":
+		return resetConnection, nil
+	case "EMPTY_RESPONSE":
+		return dropConnection, nil
+	case "MALFORMED_RESPONSE_CHUNK":
+		return MalformedResponder(MalformedChunkedEncoding)
+	default:
+		return nil, fmt.Errorf("unsupported fault %q", w.Fault)
+	}
+
+	header := make(http.Header)
+	for name, value := range w.Headers {
+		switch v := value.(type) {
+		case string:
+			header.Add(name, v)
+		case []interface{}:
+			for _, item := range v {
+				header.Add(name, fmt.Sprint(item))
+			}
+		default:
+			header.Add(name, fmt.Sprint(v))
+		}
+	}
+
+	var body []byte
+	switch {
+	case w.Body != "":
+		body = []byte(w.Body)
+	case w.JSONBody != nil:
+		data, err := json.Marshal(w.JSONBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode jsonBody: %w", err)
+		}
+		body = data
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", "application/json")
+		}
+	case w.Base64Body != "":
+		data, err := base64.StdEncoding.DecodeString(w.Base64Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64Body: %w", err)
+		}
+		body = data
+	case w.BodyFileName != "":
+		data, err := ioutil.ReadFile(w.BodyFileName)
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	status := w.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		for name, values := range header {
+			rw.Header()[name] = values
+		}
+		rw.WriteHeader(status)
+		rw.Write(body)
+	}, nil
+}
+
+// LoadWireMockMappings will read the WireMock mappings stored in the file or directory at
+// path, as for ReadWireMockMappings, and setup expectations for them as described for
+// WithWireMockMappings.
+func (m *MockAPI) LoadWireMockMappings(path string) error {
+	mappings, err := ReadWireMockMappings(path)
+	if err != nil {
+		return err
+	}
+	return m.WithWireMockMappings(mappings)
+}
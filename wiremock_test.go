@@ -0,0 +1,91 @@
+package mockapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLoadWireMockMappings(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+	})
+
+	if err := m.LoadWireMockMappings("testdata/wiremock/mappings"); err != nil {
+		t.Fatalf("Error loading WireMock mappings: %v", err)
+	}
+
+	do := func(method, path, accept, body string) (int, string, http.Header) {
+		req, err := http.NewRequest(method, fmt.Sprintf("%s%s", m.URL(), path), strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Error creating request: %v", err)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Error issuing %s of %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(respBody), resp.Header
+	}
+
+	status, body, header := do("GET", "/v1/nodes/node-1", "application/json", "")
+	if status != 200 || body != "{\"id\": \"node-1\", \"status\": \"ready\"}\n" || header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Unexpected response from urlPathPattern mapping: %d %q %v", status, body, header)
+	}
+
+	// the Accept header pattern is not met so the lower priority mapping is matched
+	if status, body, _ := do("GET", "/v1/nodes/node-1", "text/plain", ""); status != 404 || body != "not found" {
+		t.Fatalf("Unexpected response from fallback mapping: %d %q", status, body)
+	}
+
+	if status, body, _ := do("GET", "/v1/nodes?status=ready&page=2", "", ""); status != 200 || body != `[{"id":"node-1"}]` {
+		t.Fatalf("Unexpected response from urlPattern mapping: %d %q", status, body)
+	}
+	if status, _, _ := do("GET", "/v1/nodes?status=down", "", ""); status != 404 {
+		t.Fatalf("Expected the fallback mapping to match but got %d", status)
+	}
+
+	// the body patterns are not all met
+	if status, _, _ := do("POST", "/v1/nodes", "", `{"name": "node-2"}`); status != 404 {
+		t.Fatalf("Expected the fallback mapping to match but got %d", status)
+	}
+
+	status, _, header = do("POST", "/v1/nodes", "", `{"name": "node-2", "region": "east", "size": 3}`)
+	if status != 201 || header.Get("Location") != "/v1/nodes/node-2" {
+		t.Fatalf("Unexpected response from bodyPatterns mapping: %d %v", status, header)
+	}
+	if state := m.Scenario("nodes").State(); state != "created" {
+		t.Fatalf("Expected the scenario to move to created but it is %q", state)
+	}
+
+	// the scenario is no longer in the required state
+	if status, _, _ := do("POST", "/v1/nodes", "", `{"name": "node-2", "region": "east"}`); status != 404 {
+		t.Fatalf("Expected the fallback mapping to match but got %d", status)
+	}
+}
+
+func TestParseWireMockMappings(t *testing.T) {
+	_, err := ParseWireMockMappings([]byte(`{"request": {"bodyPatterns": [{"matchesJsonPath": "$.id"}]}}`))
+	if err == nil || !strings.Contains(err.Error(), `unsupported WireMock matcher "matchesJsonPath"`) {
+		t.Fatalf("Expected an unsupported matcher error but got %v", err)
+	}
+
+	mappings, err := ParseWireMockMappings([]byte(`{"request": {"url": "/a", "urlPath": "/a"}}`))
+	if err != nil {
+		t.Fatalf("Error parsing mapping: %v", err)
+	}
+
+	m := NewMockAPI(t)
+	err = m.WithWireMockMappings(mappings)
+	if err == nil || !strings.Contains(err.Error(), "only one of url, urlPath, urlPattern and urlPathPattern may be set") {
+		t.Fatalf("Expected an error for multiple URLs but got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package mockapi
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// wrappedWriter is an http.ResponseWriter which passes flushing, hijacking and
+// pushing through to the wrapped writer when it supports them. It is meant to be embedded
+// by writers which alter some of the other behavior.
+type wrappedWriter struct {
+	http.ResponseWriter
+}
+
+func (w wrappedWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w wrappedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("the http.ResponseWriter does not support hijacking")
+}
+
+func (w wrappedWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// statusWriter is an http.ResponseWriter which keeps track of the status
+// code that was sent.
+type statusWriter struct {
+	wrappedWriter
+	status int
+}
+
+func newStatusWriter(w http.ResponseWriter) *statusWriter {
+	return &statusWriter{wrappedWriter: wrappedWriter{w}}
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Status returns the status code sent to the client. If nothing has been
+// written yet then the implicit 200 status code is returned.
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
@@ -0,0 +1,68 @@
+package mockapi
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// WithXMLBody will expect the request body to be XML which decodes into a value equal to
+// v. The received body is decoded into a new value of the same type as v, or of the type
+// v points to, so fields and elements not mapped by that type are ignored. It may not be
+// combined with any other body expectation.
+func (r *MockRequest) WithXMLBody(v interface{}) *MockRequest {
+	r.setBodyOption("WithXMLBody")
+
+	want := reflect.ValueOf(v)
+	for want.Kind() == reflect.Ptr && !want.IsNil() {
+		want = want.Elem()
+	}
+	if !want.IsValid() || want.Kind() == reflect.Ptr {
+		r.errs = append(r.errs, fmt.Errorf("WithXMLBody requires a non-nil value"))
+		return r
+	}
+
+	// round trip the expected value so that it compares equal to decoded values
+	// such as with the XMLName populated
+	data, err := xml.Marshal(want.Interface())
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("failed to encode expected XML body: %w", err))
+		return r
+	}
+	expected := reflect.New(want.Type())
+	if err := xml.Unmarshal(data, expected.Interface()); err != nil {
+		r.errs = append(r.errs, fmt.Errorf("failed to decode expected XML body: %w", err))
+		return r
+	}
+
+	r.body = mock.Anything
+	r.matchers = append(r.matchers, func(rr *receivedRequest) bool {
+		got := reflect.New(want.Type())
+		if err := xml.Unmarshal(rr.bodyBytes, got.Interface()); err != nil {
+			return false
+		}
+		return reflect.DeepEqual(got.Elem().Interface(), expected.Elem().Interface())
+	})
+	return r
+}
+
+// WithXMLReply will setup an expectation for an API call to be made. The supplied status code
+// will be used for the responses reply and the XML encoding of the reply object, preceded by
+// the standard XML header, will be written to the response with a Content-Type of
+// application/xml.
+func (m *MockAPI) WithXMLReply(req *MockRequest, status int, reply interface{}) *MockAPICall {
+	return m.WithRequest(req, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(status)
+
+		if reply == nil {
+			return
+		}
+
+		w.Write([]byte(xml.Header))
+		checkError(m.t, xml.NewEncoder(w).Encode(reply))
+	})
+}
@@ -0,0 +1,50 @@
+package mockapi
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type xmlOrder struct {
+	XMLName xml.Name `xml:"order"`
+	ID      string   `xml:"id,attr"`
+	Items   []string `xml:"item"`
+}
+
+func TestXML(t *testing.T) {
+	m := NewMockAPI(t)
+	m.SetFilteredHeaders([]string{
+		"Accept-Encoding",
+		"User-Agent",
+		"Content-Length",
+		"Content-Type",
+	})
+
+	m.WithXMLReply(NewMockRequest("POST", "/orders").WithXMLBody(&xmlOrder{
+		ID:    "42",
+		Items: []string{"apple", "pear"},
+	}), 201, &xmlOrder{ID: "42"}).Once()
+
+	body := `<?xml version="1.0"?>
+<order id="42" status="new">
+  <item>apple</item>
+  <item>pear</item>
+</order>`
+	resp, err := http.Post(fmt.Sprintf("%s/orders", m.URL()), "application/xml", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Error issuing POST of /orders: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 201 || resp.Header.Get("Content-Type") != "application/xml" {
+		t.Fatalf("Unexpected response: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	var reply xmlOrder
+	if err := xml.NewDecoder(resp.Body).Decode(&reply); err != nil || reply.ID != "42" {
+		t.Fatalf("Didn't get the expected response: %+v %v", reply, err)
+	}
+}